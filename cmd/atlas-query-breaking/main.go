@@ -0,0 +1,60 @@
+// Command atlas-query-breaking reports changes of query validation rules
+// between two versions of proto files which may break existing API clients:
+// removed filterable, sortable or selectable fields, newly denied filtering
+// operators, changed value types, new or changed key patterns, tighter
+// literal constraints, fields losing nullability and fields made sensitive.
+//
+// Both versions are passed as FileDescriptorSet files, e.g. produced by
+//
+//	protoc --include_imports --descriptor_set_out=head.pb example/example.proto
+//
+// The command exits with status 1 if any breaking change is found.
+//
+//	atlas-query-breaking -base base.pb -head head.pb
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/plugin"
+)
+
+func main() {
+	var (
+		base  = flag.String("base", "", "FileDescriptorSet of the base version")
+		head  = flag.String("head", "", "FileDescriptorSet of the head version")
+		param = flag.String("param", "", "plugin parameters, e.g. nested_field_depth_limit=3,enable_nested_fields=true")
+		all   = flag.Bool("all", false, "report non-breaking changes too")
+	)
+	flag.Parse()
+
+	if *base == "" || *head == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	baseRules, err := plugin.ManifestFile(*base, *param)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	headRules, err := plugin.ManifestFile(*head, *param)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	changes := options.CompareRules(baseRules, headRules)
+	for _, c := range changes {
+		if c.Breaking || *all {
+			fmt.Println(c)
+		}
+	}
+
+	if options.HasBreakingChanges(changes) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,21 @@
+// Command atlas-query-revalidate checks stored filtering expressions, e.g.
+// saved searches, against the filtering rules of the example service
+// compiled into the binary.
+//
+// Services build the same command with the rules of their own methods by
+// importing their generated package instead of the example one, see package
+// revalidate.
+//
+//	atlas-query-revalidate -method /example.TestService/List -alias name=first_name filters.txt
+package main
+
+import (
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/revalidate"
+
+	_ "github.com/infobloxopen/protoc-gen-atlas-query-validate/example"
+)
+
+func main() {
+	revalidate.Main(options.Registry)
+}
@@ -3,6 +3,7 @@
 
 package example // import "github.com/infobloxopen/protoc-gen-atlas-query-validate/example"
 
+import context "context"
 import options "github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
 import query "github.com/infobloxopen/atlas-app-toolkit/query"
 import _ "github.com/golang/protobuf/ptypes/wrappers"
@@ -11,40 +12,92 @@ import _ "github.com/golang/protobuf/ptypes/wrappers"
 
 var ExampleMethodsRequireFilteringValidation = map[string]map[string]options.FilteringOption{
 	"/example.TestService/List": map[string]options.FilteringOption{
-		"custom_search_2":                options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"custom_search.city":             options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"custom_search.country":          options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"list_of_addresses.city":         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"list_of_addresses.country":      options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"user_friend.custom_search_2":    options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"user_friend.first_name":         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"user_friend.weight":             options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_LE}, ValueType: options.QueryValidate_NUMBER},
-		"user_friend.on_vacation":        options.FilteringOption{ValueType: options.QueryValidate_BOOL},
-		"user_friend.speciality":         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"user_friend.comment":            options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, ValueType: options.QueryValidate_STRING},
-		"user_friend.last_name":          options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"user_friend.id":                 options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, ValueType: options.QueryValidate_STRING},
-		"user_friend.array":              options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, ValueType: options.QueryValidate_DEFAULT},
-		"user_friend.custom_type_string": options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"user_friend.company":            options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"user_friend.nationality":        options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, ValueType: options.QueryValidate_STRING},
-		"user_friend.boolean_field":      options.FilteringOption{ValueType: options.QueryValidate_BOOL},
-		"first_name":                     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"weight":                         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_LE}, ValueType: options.QueryValidate_NUMBER},
-		"on_vacation":                    options.FilteringOption{ValueType: options.QueryValidate_BOOL},
-		"speciality":                     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"comment":                        options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, ValueType: options.QueryValidate_STRING},
-		"last_name":                      options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"id":                             options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, ValueType: options.QueryValidate_STRING},
-		"array":                          options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, ValueType: options.QueryValidate_DEFAULT},
-		"custom_type.name":               options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"custom_type_string":             options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"home_address.city":              options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"home_address.country":           options.FilteringOption{ValueType: options.QueryValidate_STRING},
-		"work_address":                   options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, ValueType: options.QueryValidate_DEFAULT},
-		"company":                        options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IEQ}, ValueType: options.QueryValidate_STRING},
-		"nationality":                    options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, ValueType: options.QueryValidate_STRING},
-		"boolean_field":                  options.FilteringOption{ValueType: options.QueryValidate_BOOL},
+		"custom_search_2":                options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"custom_search.country":          options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"list_of_addresses.city":         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"list_of_addresses.country":      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.custom_search_2":    options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.first_name":         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.weight":             options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_LE}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_NUMBER},
+		"user_friend.on_vacation":        options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_BOOL},
+		"user_friend.speciality":         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_MATCH), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.comment":            options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.last_name":          options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.id":                 options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.array":              options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_DEFAULT},
+		"user_friend.custom_type_string": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.company":            options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IEQ}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.nationality":        options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.boolean_field":      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_BOOL},
+		"user_friend.ssn":                options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user_friend.nickname":           options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"first_name":                     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"weight":                         options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_LE}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_NUMBER},
+		"on_vacation":                    options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_BOOL},
+		"speciality":                     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_MATCH), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"comment":                        options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"last_name":                      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"id":                             options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"array":                          options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_DEFAULT},
+		"custom_type.name":               options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"custom_type.code":               options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"custom_type_string":             options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"home_address.city":              options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"home_address.country":           options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"work_address":                   options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_DEFAULT},
+		"company":                        options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IEQ}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"nationality":                    options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_IN}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"boolean_field":                  options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_BOOL},
+		"ssn":                            options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"nickname":                       options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+	},
+	"/example.TestService/ListSites": map[string]options.FilteringOption{
+		"name":             options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"location.city":    options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"location.country": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"location.geo.lat": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_NUMBER},
+		"location.geo.lon": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_NUMBER},
+		"labels.env":       options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"labels.tier":      options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"metrics.*":        options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, KeyPattern: "[a-z_]+", Nullable: true, ValueType: options.QueryValidate_NUMBER},
+		"annotations.a.*":  options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, KeyPattern: "[a-z]+(\\.[a-z]+)*", Nullable: true, ValueType: options.QueryValidate_NUMBER},
+		"annotations.*":    options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, KeyPattern: "[a-z]+(\\.[a-z]+)*", Nullable: true, ValueType: options.QueryValidate_STRING},
+		"tags":             options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, Repeated: options.QueryValidate_REPEATED_ANY, ValueType: options.QueryValidate_STRING},
+		"branches.city":    options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, Repeated: options.QueryValidate_REPEATED_ALL, ValueType: options.QueryValidate_STRING},
+		"branches.geo.lat": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, Repeated: options.QueryValidate_REPEATED_ALL, ValueType: options.QueryValidate_NUMBER},
+		"branches.geo.lon": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, Repeated: options.QueryValidate_REPEATED_ALL, ValueType: options.QueryValidate_NUMBER},
+		"branches.geo.alt": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, Repeated: options.QueryValidate_REPEATED_ALL, ValueType: options.QueryValidate_NUMBER},
+		"account_id":       options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"secret_token":     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"member_count":     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_NUMBER},
+		"created_by":       options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+	},
+	"/example.TestService/ListTargets": map[string]options.FilteringOption{
+		"name":          options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"host.hostname": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"host.ip":       options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"host.mac":      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"host.password": options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"host.serial":   options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"net.cidr":      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"net.psk":       options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL}, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"user":          options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"group":         options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN, options.QueryValidate_IS_NULL), AllowedSet: true, Nullable: true, ValueType: options.QueryValidate_STRING},
+		"tenant_id":     options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_NUMBER},
+	},
+	"/example.TestService/ListDevices": map[string]options.FilteringOption{
+		"name":        options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"cache_key":   options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"owner.name":  options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"owner.email": options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
+		"ports":       options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_DEFAULT},
+		"address":     options.FilteringOption{Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, AllowedSet: true, ValueType: options.QueryValidate_DEFAULT},
+		"serial":      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, Literal: &options.LiteralConstraint{Format: "uuid"}, ValueType: options.QueryValidate_STRING},
+		"hostname":    options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, Literal: &options.LiteralConstraint{MaxLen: 16}, ValueType: options.QueryValidate_STRING},
+		"status":      options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, Literal: &options.LiteralConstraint{In: []string{"UNKNOWN", "ONLINE", "OFFLINE"}}, ValueType: options.QueryValidate_STRING},
+		"rack":        options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IN), AllowedSet: true, Literal: &options.LiteralConstraint{Min: options.Float64(1), Max: options.Float64(42)}, ValueType: options.QueryValidate_NUMBER},
+		"tags":        options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, Repeated: options.QueryValidate_REPEATED_ANY, Literal: &options.LiteralConstraint{In: []string{"edge", "core"}}, ValueType: options.QueryValidate_STRING},
+		"label":       options.FilteringOption{Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_MATCH, options.QueryValidate_GT, options.QueryValidate_GE, options.QueryValidate_LT, options.QueryValidate_LE, options.QueryValidate_IEQ, options.QueryValidate_IN), AllowedSet: true, ValueType: options.QueryValidate_STRING},
 	},
 }
 var ExampleMethodsRequireSortingValidation = map[string][]string{
@@ -55,11 +108,14 @@ var ExampleMethodsRequireSortingValidation = map[string][]string{
 		"last_name",
 		"id",
 		"custom_type.name",
+		"custom_type.code",
 		"custom_type_string",
 		"home_address.country",
 		"company",
 		"nationality",
 		"boolean_field",
+		"ssn",
+		"nickname",
 	},
 	"/example.TestService/Read": []string{
 		"first_name",
@@ -68,11 +124,43 @@ var ExampleMethodsRequireSortingValidation = map[string][]string{
 		"last_name",
 		"id",
 		"custom_type.name",
+		"custom_type.code",
 		"custom_type_string",
 		"home_address.country",
 		"company",
 		"nationality",
 		"boolean_field",
+		"ssn",
+		"nickname",
+	},
+	"/example.TestService/ListSites": []string{
+		"name",
+		"location.city",
+		"location.country",
+		"location.geo.lat",
+		"location.geo.lon",
+		"account_id",
+	},
+	"/example.TestService/ListTargets": []string{
+		"name",
+		"host.hostname",
+		"host.ip",
+		"host.mac",
+		"host.serial",
+		"net.cidr",
+		"user",
+		"group",
+		"tenant_id",
+	},
+	"/example.TestService/ListDevices": []string{
+		"name",
+		"owner.name",
+		"owner.email",
+		"serial",
+		"hostname",
+		"status",
+		"rack",
+		"label",
 	},
 }
 var ExampleMethodsRequireFieldSelectionValidation = map[string][]string{
@@ -88,7 +176,9 @@ var ExampleMethodsRequireFieldSelectionValidation = map[string][]string{
 		"last_name",
 		"id",
 		"array",
+		"custom_type.recur",
 		"custom_type.name",
+		"custom_type.code",
 		"custom_type",
 		"custom_type_string",
 		"home_address.city",
@@ -100,6 +190,8 @@ var ExampleMethodsRequireFieldSelectionValidation = map[string][]string{
 		"company",
 		"nationality",
 		"boolean_field",
+		"ssn",
+		"nickname",
 	},
 	"/example.TestService/Read": {
 		"list_of_addresses.city",
@@ -113,7 +205,9 @@ var ExampleMethodsRequireFieldSelectionValidation = map[string][]string{
 		"last_name",
 		"id",
 		"array",
+		"custom_type.recur",
 		"custom_type.name",
+		"custom_type.code",
 		"custom_type",
 		"custom_type_string",
 		"home_address.city",
@@ -125,27 +219,347 @@ var ExampleMethodsRequireFieldSelectionValidation = map[string][]string{
 		"company",
 		"nationality",
 		"boolean_field",
+		"ssn",
+		"nickname",
+	},
+	"/example.TestService/ListSites": {
+		"name",
+		"location.city",
+		"location.country",
+		"location.geo.lat",
+		"location.geo.lon",
+		"location.geo",
+		"location",
+		"labels",
+		"metrics",
+		"annotations",
+		"tags",
+		"branches.city",
+		"branches.geo.lat",
+		"branches.geo.lon",
+		"branches.geo.alt",
+		"branches.geo",
+		"branches",
+		"account_id",
+		"member_count",
+		"created_by",
+	},
+	"/example.TestService/ListTargets": {
+		"name",
+		"host.hostname",
+		"host.ip",
+		"host.mac",
+		"host.serial",
+		"host",
+		"net.cidr",
+		"net",
+		"user",
+		"group",
+		"tenant_id",
+		"target",
+		"owner",
+	},
+	"/example.TestService/ListDevices": {
+		"name",
+		"cache_key",
+		"owner.name",
+		"owner.email",
+		"owner",
+		"ports.number",
+		"ports",
+		"address.city",
+		"address.country",
+		"address",
+		"serial",
+		"hostname",
+		"status",
+		"rack",
+		"tags",
+		"label",
+	},
+}
+var ExampleMethodsFieldSelectionRequireParent = map[string][]string{
+	"/example.TestService/ListTargets": {
+		"host.mac",
+	},
+}
+var ExampleMethodsRequireOneofValidation = map[string]map[string]options.OneofRule{
+	"/example.TestService/ListTargets": {
+		"target": {Members: []string{"host", "net"}},
+		"owner":  {Members: []string{"user", "group"}, SingleBranch: true},
+	},
+}
+var ExampleMethodsRequireRecursionValidation = map[string]options.MethodRecursions{
+	"/example.TestService/List": {
+		Filtering: map[string]options.Recursion{
+			"custom_type.recur": {Target: "custom_type", MaxDepth: 4},
+			"user_friend":       {MaxDepth: 1},
+		},
+		Sorting: map[string]options.Recursion{
+			"custom_type.recur": {Target: "custom_type", MaxDepth: 4},
+		},
+		FieldSelection: map[string]options.Recursion{
+			"custom_type.recur": {Target: "custom_type", MaxDepth: 4},
+		},
+	},
+	"/example.TestService/Read": {
+		Sorting: map[string]options.Recursion{
+			"custom_type.recur": {Target: "custom_type", MaxDepth: 4},
+		},
+		FieldSelection: map[string]options.Recursion{
+			"custom_type.recur": {Target: "custom_type", MaxDepth: 4},
+		},
+	},
+}
+var ExampleMethodsRequirePermissions = map[string]options.MethodPermissions{
+	"/example.TestService/List": {
+		Filtering: map[string][]string{
+			"speciality":             {"admin", "hr"},
+			"user_friend.speciality": {"admin", "hr"},
+		},
+		Sorting: map[string][]string{
+			"weight": {"admin"},
+		},
+		FieldSelection: map[string][]string{
+			"home_address": {"admin"},
+			"ssn":          {"admin"},
+		},
+	},
+	"/example.TestService/Read": {
+		Sorting: map[string][]string{
+			"weight": {"admin"},
+		},
+		FieldSelection: map[string][]string{
+			"home_address": {"admin"},
+			"ssn":          {"admin"},
+		},
+	},
+	"/example.TestService/ListTargets": {
+		FieldSelection: map[string][]string{
+			"host.ip": {"admin"},
+		},
+	},
+}
+var ExampleMethodsColumns = map[string]map[string]string{
+	"/example.TestService/ListDevices": {
+		"name":       "device_name",
+		"owner.name": "full_name",
 	},
 }
+var ExampleMethodsDeprecatedFields = map[string][]string{
+	"/example.TestService/List": {
+		"custom_type.code",
+	},
+	"/example.TestService/Read": {
+		"custom_type.code",
+	},
+	"/example.TestService/ListDevices": {
+		"label",
+		"owner.email",
+	},
+}
+var ExampleMethodsSensitiveFields = map[string][]string{
+	"/example.TestService/ListTargets": {
+		"host.password",
+		"net.psk",
+	},
+}
+var ExampleMethodsHTTPRules = map[string][]options.HTTPRule{
+	"/example.TestService/List": {
+		{Method: "GET", Pattern: "/v1/users"},
+	},
+	"/example.TestService/Read": {
+		{Method: "GET", Pattern: "/v1/users/{id}"},
+	},
+	"/example.TestService/ListSites": {
+		{Method: "GET", Pattern: "/v1/sites"},
+		{Method: "POST", Pattern: "/v1/sites:search"},
+	},
+	"/example.TestService/ListTargets": {
+		{Method: "GET", Pattern: "/v1/targets"},
+	},
+	"/example.TestService/ListDevices": {
+		{Method: "GET", Pattern: "/v1/devices"},
+	},
+}
+var ExampleMethodValidators = map[string]*options.MethodValidator{
+	"/example.TestService/List": options.MustRulesValidator(
+		options.MethodRules{
+			Filtering:                   ExampleMethodsRequireFilteringValidation["/example.TestService/List"],
+			Sorting:                     ExampleMethodsRequireSortingValidation["/example.TestService/List"],
+			FieldSelection:              ExampleMethodsRequireFieldSelectionValidation["/example.TestService/List"],
+			FieldSelectionRequireParent: ExampleMethodsFieldSelectionRequireParent["/example.TestService/List"],
+			Oneofs:                      ExampleMethodsRequireOneofValidation["/example.TestService/List"],
+			Recursions:                  ExampleMethodsRequireRecursionValidation["/example.TestService/List"],
+			Permissions:                 ExampleMethodsRequirePermissions["/example.TestService/List"],
+			Columns:                     ExampleMethodsColumns["/example.TestService/List"],
+			Deprecated:                  ExampleMethodsDeprecatedFields["/example.TestService/List"],
+			Sensitive:                   ExampleMethodsSensitiveFields["/example.TestService/List"],
+			HTTPRules:                   ExampleMethodsHTTPRules["/example.TestService/List"],
+		},
+		func(req interface{}) (f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) {
+			if r, ok := req.(interface{ GetFilter() *query.Filtering }); ok {
+				f = r.GetFilter()
+			}
+			if r, ok := req.(interface{ GetOrderBy() *query.Sorting }); ok {
+				s = r.GetOrderBy()
+			}
+			if r, ok := req.(interface{ GetFields() *query.FieldSelection }); ok {
+				fs = r.GetFields()
+			}
+			return
+		},
+	),
+	"/example.TestService/Read": options.MustRulesValidator(
+		options.MethodRules{
+			Filtering:                   ExampleMethodsRequireFilteringValidation["/example.TestService/Read"],
+			Sorting:                     ExampleMethodsRequireSortingValidation["/example.TestService/Read"],
+			FieldSelection:              ExampleMethodsRequireFieldSelectionValidation["/example.TestService/Read"],
+			FieldSelectionRequireParent: ExampleMethodsFieldSelectionRequireParent["/example.TestService/Read"],
+			Oneofs:                      ExampleMethodsRequireOneofValidation["/example.TestService/Read"],
+			Recursions:                  ExampleMethodsRequireRecursionValidation["/example.TestService/Read"],
+			Permissions:                 ExampleMethodsRequirePermissions["/example.TestService/Read"],
+			Columns:                     ExampleMethodsColumns["/example.TestService/Read"],
+			Deprecated:                  ExampleMethodsDeprecatedFields["/example.TestService/Read"],
+			Sensitive:                   ExampleMethodsSensitiveFields["/example.TestService/Read"],
+			HTTPRules:                   ExampleMethodsHTTPRules["/example.TestService/Read"],
+		},
+		func(req interface{}) (f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) {
+			if r, ok := req.(interface{ GetOrderBy() *query.Sorting }); ok {
+				s = r.GetOrderBy()
+			}
+			if r, ok := req.(interface{ GetFields() *query.FieldSelection }); ok {
+				fs = r.GetFields()
+			}
+			return
+		},
+	),
+	"/example.TestService/ListSites": options.MustRulesValidator(
+		options.MethodRules{
+			Filtering:                   ExampleMethodsRequireFilteringValidation["/example.TestService/ListSites"],
+			Sorting:                     ExampleMethodsRequireSortingValidation["/example.TestService/ListSites"],
+			FieldSelection:              ExampleMethodsRequireFieldSelectionValidation["/example.TestService/ListSites"],
+			FieldSelectionRequireParent: ExampleMethodsFieldSelectionRequireParent["/example.TestService/ListSites"],
+			Oneofs:                      ExampleMethodsRequireOneofValidation["/example.TestService/ListSites"],
+			Recursions:                  ExampleMethodsRequireRecursionValidation["/example.TestService/ListSites"],
+			Permissions:                 ExampleMethodsRequirePermissions["/example.TestService/ListSites"],
+			Columns:                     ExampleMethodsColumns["/example.TestService/ListSites"],
+			Deprecated:                  ExampleMethodsDeprecatedFields["/example.TestService/ListSites"],
+			Sensitive:                   ExampleMethodsSensitiveFields["/example.TestService/ListSites"],
+			HTTPRules:                   ExampleMethodsHTTPRules["/example.TestService/ListSites"],
+			Scope:                       &options.ScopeRule{Field: "account_id", ValueType: options.QueryValidate_STRING},
+		},
+		func(req interface{}) (f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) {
+			if r, ok := req.(interface{ GetFilter() *query.Filtering }); ok {
+				f = r.GetFilter()
+			}
+			if r, ok := req.(interface{ GetOrderBy() *query.Sorting }); ok {
+				s = r.GetOrderBy()
+			}
+			if r, ok := req.(interface{ GetFields() *query.FieldSelection }); ok {
+				fs = r.GetFields()
+			}
+			return
+		},
+	),
+	"/example.TestService/ListTargets": options.MustRulesValidator(
+		options.MethodRules{
+			Filtering:                   ExampleMethodsRequireFilteringValidation["/example.TestService/ListTargets"],
+			Sorting:                     ExampleMethodsRequireSortingValidation["/example.TestService/ListTargets"],
+			FieldSelection:              ExampleMethodsRequireFieldSelectionValidation["/example.TestService/ListTargets"],
+			FieldSelectionRequireParent: ExampleMethodsFieldSelectionRequireParent["/example.TestService/ListTargets"],
+			Oneofs:                      ExampleMethodsRequireOneofValidation["/example.TestService/ListTargets"],
+			Recursions:                  ExampleMethodsRequireRecursionValidation["/example.TestService/ListTargets"],
+			Permissions:                 ExampleMethodsRequirePermissions["/example.TestService/ListTargets"],
+			Columns:                     ExampleMethodsColumns["/example.TestService/ListTargets"],
+			Deprecated:                  ExampleMethodsDeprecatedFields["/example.TestService/ListTargets"],
+			Sensitive:                   ExampleMethodsSensitiveFields["/example.TestService/ListTargets"],
+			HTTPRules:                   ExampleMethodsHTTPRules["/example.TestService/ListTargets"],
+			DropUnauthorizedFields:      true,
+			Scope:                       &options.ScopeRule{Field: "tenant_id", ValueType: options.QueryValidate_NUMBER},
+		},
+		func(req interface{}) (f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) {
+			if r, ok := req.(interface{ GetFilter() *query.Filtering }); ok {
+				f = r.GetFilter()
+			}
+			if r, ok := req.(interface{ GetOrderBy() *query.Sorting }); ok {
+				s = r.GetOrderBy()
+			}
+			if r, ok := req.(interface{ GetFields() *query.FieldSelection }); ok {
+				fs = r.GetFields()
+			}
+			return
+		},
+	),
+	"/example.TestService/ListDevices": options.MustRulesValidator(
+		options.MethodRules{
+			Filtering:                   ExampleMethodsRequireFilteringValidation["/example.TestService/ListDevices"],
+			Sorting:                     ExampleMethodsRequireSortingValidation["/example.TestService/ListDevices"],
+			FieldSelection:              ExampleMethodsRequireFieldSelectionValidation["/example.TestService/ListDevices"],
+			FieldSelectionRequireParent: ExampleMethodsFieldSelectionRequireParent["/example.TestService/ListDevices"],
+			Oneofs:                      ExampleMethodsRequireOneofValidation["/example.TestService/ListDevices"],
+			Recursions:                  ExampleMethodsRequireRecursionValidation["/example.TestService/ListDevices"],
+			Permissions:                 ExampleMethodsRequirePermissions["/example.TestService/ListDevices"],
+			Columns:                     ExampleMethodsColumns["/example.TestService/ListDevices"],
+			Deprecated:                  ExampleMethodsDeprecatedFields["/example.TestService/ListDevices"],
+			Sensitive:                   ExampleMethodsSensitiveFields["/example.TestService/ListDevices"],
+			HTTPRules:                   ExampleMethodsHTTPRules["/example.TestService/ListDevices"],
+		},
+		func(req interface{}) (f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) {
+			if r, ok := req.(interface{ GetFilter() *query.Filtering }); ok {
+				f = r.GetFilter()
+			}
+			if r, ok := req.(interface{ GetOrderBy() *query.Sorting }); ok {
+				s = r.GetOrderBy()
+			}
+			if r, ok := req.(interface{ GetFields() *query.FieldSelection }); ok {
+				fs = r.GetFields()
+			}
+			return
+		},
+	),
+}
 
+func init() {
+	for method, v := range ExampleMethodValidators {
+		if err := options.Registry.Register(method, v); err != nil {
+			panic(err)
+		}
+	}
+}
 func ExampleValidateFiltering(methodName string, f *query.Filtering) error {
-	info, ok := ExampleMethodsRequireFilteringValidation[methodName]
+	v, ok := ExampleMethodValidators[methodName]
 	if !ok {
 		return nil
 	}
-	return options.ValidateFiltering(f, info)
+	return v.ValidateFiltering(f)
+}
+func ExampleValidateFilteringString(methodName string, expr string) error {
+	v, ok := ExampleMethodValidators[methodName]
+	if !ok {
+		_, err := options.ParseFilteringString(expr)
+		return err
+	}
+	return v.ValidateFilteringString(expr)
 }
 func ExampleValidateSorting(methodName string, s *query.Sorting) error {
-	info, ok := ExampleMethodsRequireSortingValidation[methodName]
+	v, ok := ExampleMethodValidators[methodName]
 	if !ok {
 		return nil
 	}
-	return options.ValidateSorting(s, info)
+	return v.ValidateSorting(s)
 }
 func ExampleValidateFieldSelection(methodName string, s *query.FieldSelection) error {
-	info, ok := ExampleMethodsRequireFieldSelectionValidation[methodName]
+	v, ok := ExampleMethodValidators[methodName]
 	if !ok {
 		return nil
 	}
-	return options.ValidateFieldSelection(s, info)
+	return v.ValidateFieldSelection(s)
+}
+func ExampleScopeFiltering(ctx context.Context, methodName string, f *query.Filtering) (*query.Filtering, error) {
+	v, ok := ExampleMethodValidators[methodName]
+	if !ok {
+		return nil, &options.UnscopedMethodError{Method: methodName}
+	}
+	return v.ScopeFiltering(ctx, f)
 }
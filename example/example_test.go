@@ -1,9 +1,27 @@
 package example
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/infobloxopen/atlas-app-toolkit/query"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/example/common"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/interceptor"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/policy/expr"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/policy/opa"
 )
 
 func TestValidateFiltering(t *testing.T) {
@@ -43,6 +61,13 @@ func TestValidateFiltering(t *testing.T) {
 		{`boolean_field=="True"`, false},
 		{`boolean_field=="Blah"`, true},
 		{`boolean_field:="True"`, true},
+		{`custom_search.country=="country"`, false},
+		{`custom_search.city=="city"`, true},
+		// Conditions of a logical operator nested on the right hand side
+		// are validated as well.
+		{`first_name=="Sam" and (weight==1 or id=="some_id")`, true},
+		{`first_name=="Sam" and (weight==1 or not (comment=="x" and weight<=1))`, true},
+		{`first_name=="Sam" and (weight==1 or weight==2)`, false},
 	}
 
 	for _, test := range tests {
@@ -60,6 +85,11 @@ func TestValidateFiltering(t *testing.T) {
 				t.Errorf("Expected error for %s query, but got no error", test.Query)
 			}
 		}
+
+		legacy := options.ValidateFiltering(f, ExampleMethodsRequireFilteringValidation["/example.TestService/List"])
+		if (legacy != nil) != test.Err {
+			t.Errorf("Unexpected result of options.ValidateFiltering for %s query: %v", test.Query, legacy)
+		}
 	}
 }
 
@@ -121,7 +151,13 @@ func TestValidateFieldSelection(t *testing.T) {
 		{`work_address.city`, false},
 		{`work_address.country`, false},
 		{`first_name,weight,on_vacation`, false},
+		{`home_address.*`, false},
+		{`*`, false},
+		{`first_name,work_address.*`, false},
 		{`unknown_field`, true},
+		{`home_address.unknown_field`, true},
+		{`unknown_field.*`, true},
+		{`last_name.*`, true},
 		{`work_address.unknown_field`, true},
 		{`last_name.value`, true},
 		{`first_name,unknown_field`, true},
@@ -141,3 +177,1603 @@ func TestValidateFieldSelection(t *testing.T) {
 		}
 	}
 }
+
+type testListRequest struct {
+	filter  *query.Filtering
+	orderBy *query.Sorting
+	fields  *query.FieldSelection
+}
+
+func (r *testListRequest) GetFilter() *query.Filtering        { return r.filter }
+func (r *testListRequest) GetOrderBy() *query.Sorting         { return r.orderBy }
+func (r *testListRequest) GetFields() *query.FieldSelection   { return r.fields }
+func (r *testListRequest) SetFields(fs *query.FieldSelection) { r.fields = fs }
+func (r *testListRequest) SetFilter(f *query.Filtering)       { r.filter = f }
+
+// testFilterRequest is a request whose filter is set by the interceptor
+// through the Filter field, like the filter of the generated messages.
+type testFilterRequest struct {
+	Filter *query.Filtering
+}
+
+func (r *testFilterRequest) GetFilter() *query.Filtering { return r.Filter }
+
+func TestNestedFields(t *testing.T) {
+	tests := []struct {
+		Filter  string
+		OrderBy string
+		Fields  string
+		Err     bool
+	}{
+		{`name=="site"`, `name`, `name`, false},
+		{`location.city=="city"`, `location.city`, `location.city`, false},
+		{`location.country=="country"`, `location.country`, `location.country`, false},
+		{`location.geo.lat>1.5 and location.geo.lon<2`, `location.geo.lat,location.geo.lon`, `location.geo.lat,location.geo.lon`, false},
+		{``, ``, `location,location.geo`, false},
+		{`location.street=="street"`, ``, ``, true},
+		{`location.geo.alt>1`, ``, ``, true},
+		{``, `location.street`, ``, true},
+		{``, `location.geo.alt`, ``, true},
+		{``, ``, `location.street`, true},
+		{``, ``, `location.geo.alt`, true},
+	}
+
+	v := ExampleMethodValidators["/example.TestService/ListSites"]
+	for _, test := range tests {
+		f, err := query.ParseFiltering(test.Filter)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Filter)
+		}
+		s, err := query.ParseSorting(test.OrderBy)
+		if err != nil {
+			t.Fatalf("Invalid sorting data '%s'", test.OrderBy)
+		}
+		req := &testListRequest{filter: f, orderBy: s, fields: query.ParseFieldSelection(test.Fields)}
+		err = v.Validate(req)
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %+v request: %s", test, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %+v request, but got no error", test)
+			}
+		}
+	}
+}
+
+func TestMapFields(t *testing.T) {
+	tests := []struct {
+		Query string
+		Err   bool
+	}{
+		{`labels.env=="prod"`, false},
+		{`labels.env~"pr"`, false},
+		{`labels.tier=="gold"`, false},
+		{`labels.tier~"go"`, true},
+		{`labels.owner=="me"`, true},
+		{`labels=="prod"`, true},
+		{`metrics.cpu_load>0.5`, false},
+		{`metrics.cpu_load=="high"`, true},
+		{`metrics.CPU>0.5`, true},
+		{`annotations.a.b>1`, false},
+		{`annotations.a.b.c<=1`, false},
+		{`annotations.a.b=="x"`, true},
+		{`annotations.team=="core"`, false},
+		{`annotations.team.name~"co"`, false},
+		{`annotations.Team=="core"`, true},
+	}
+
+	for _, test := range tests {
+		err := ExampleValidateFilteringString("/example.TestService/ListSites", test.Query)
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %s query, but got no error", test.Query)
+			}
+		}
+	}
+
+	rules := ExampleMethodsRequireFilteringValidation["/example.TestService/ListSites"]
+	if err := options.ValidateFilteringString(`metrics.cpu_load>0.5`, rules); err != nil {
+		t.Errorf("Unexpected error for metrics.cpu_load: %s", err)
+	}
+	if err := options.ValidateFilteringString(`metrics.CPU>0.5`, rules); err == nil {
+		t.Errorf("Expected error for metrics.CPU, but got no error")
+	}
+
+	invalid := map[string]options.FilteringOption{"metrics.*": {ValueType: options.QueryValidate_NUMBER, KeyPattern: "[a-z"}}
+	if err := options.ValidateFilteringString(`metrics.cpu>0.5`, invalid); err == nil {
+		t.Errorf("Expected error for invalid key pattern, but got no error")
+	}
+	if _, err := options.NewRulesValidator(options.MethodRules{Filtering: invalid}, nil); err == nil {
+		t.Errorf("Expected error for invalid key pattern, but got no error")
+	}
+
+	if err := ExampleValidateFieldSelection("/example.TestService/ListSites", query.ParseFieldSelection(`labels,metrics`)); err != nil {
+		t.Errorf("Unexpected error for map field selection: %s", err)
+	}
+	if err := ExampleValidateFieldSelection("/example.TestService/ListSites", query.ParseFieldSelection(`labels.key`)); err == nil {
+		t.Errorf("Expected error for map entry field selection, but got no error")
+	}
+}
+
+func TestRepeatedFields(t *testing.T) {
+	tests := []struct {
+		Query string
+		Err   bool
+	}{
+		{`tags=="prod"`, false},
+		{`tags~"pr"`, false},
+		{`tags in ["prod", "dev"]`, false},
+		{`tags==1`, true},
+		{`branches.city=="city"`, false},
+		{`branches.geo.alt>100`, false},
+		{`branches.geo.alt=="high"`, true},
+		{`branches.country=="country"`, true},
+		{`branches=="branch"`, true},
+	}
+
+	for _, test := range tests {
+		err := ExampleValidateFilteringString("/example.TestService/ListSites", test.Query)
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %s query, but got no error", test.Query)
+			}
+		}
+	}
+
+	rules := ExampleMethodsRequireFilteringValidation["/example.TestService/ListSites"]
+	if r := rules["tags"].Repeated; r != options.QueryValidate_REPEATED_CONTAINS {
+		t.Errorf("Unexpected repeated semantics of tags: %s", r)
+	}
+	if r := rules["branches.geo.lat"].Repeated; r != options.QueryValidate_REPEATED_ALL {
+		t.Errorf("Unexpected repeated semantics of branches.geo.lat: %s", r)
+	}
+	if r := rules["location.city"].Repeated; r != options.QueryValidate_REPEATED_NONE {
+		t.Errorf("Unexpected repeated semantics of location.city: %s", r)
+	}
+}
+
+func TestOneofs(t *testing.T) {
+	tests := []struct {
+		Query string
+		Err   bool
+	}{
+		{`host.hostname=="h" and host.ip=="10.0.0.1"`, false},
+		{`host.hostname=="h" or net.cidr=="10.0.0.0/8"`, false},
+		{`host.hostname=="h" and net.cidr=="10.0.0.0/8"`, true},
+		{`name=="n" and (host.ip=="10.0.0.1" and net.cidr=="10.0.0.0/8")`, true},
+		{`(host.ip=="10.0.0.1" or name=="n") and net.cidr=="10.0.0.0/8"`, false},
+		{`(host.ip=="10.0.0.1" or host.hostname=="h") and net.cidr=="10.0.0.0/8"`, true},
+		{`not host.hostname=="h" and net.cidr=="10.0.0.0/8"`, false},
+		{`not (host.hostname=="h" and net.cidr=="10.0.0.0/8")`, true},
+		{`user=="u"`, false},
+		{`user=="u" or group=="g"`, true},
+		{`not user=="u" and name=="n" or group=="g"`, true},
+	}
+
+	for _, test := range tests {
+		err := ExampleValidateFilteringString("/example.TestService/ListTargets", test.Query)
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %s query, but got no error", test.Query)
+			}
+		}
+	}
+
+	fieldTests := []struct {
+		Fields   string
+		Expanded []string
+	}{
+		{`name,target`, []string{"host", "name", "net"}},
+		{`owner`, []string{"group", "user"}},
+		{`host.ip`, []string{"host.ip"}},
+	}
+
+	v := ExampleMethodValidators["/example.TestService/ListTargets"]
+	for _, test := range fieldTests {
+		fs := query.ParseFieldSelection(test.Fields)
+		if err := v.ValidateFieldSelection(fs); err != nil {
+			t.Errorf("Unexpected error for %s field selection: %s", test.Fields, err)
+		}
+
+		var expanded []string
+		for name, f := range v.ExpandFieldSelection(fs).GetFields() {
+			if len(f.GetSubs()) == 0 {
+				expanded = append(expanded, name)
+			}
+			for sub := range f.GetSubs() {
+				expanded = append(expanded, name+"."+sub)
+			}
+		}
+		sort.Strings(expanded)
+		if !reflect.DeepEqual(expanded, test.Expanded) {
+			t.Errorf("Unexpected expansion of %s field selection: %v", test.Fields, expanded)
+		}
+	}
+}
+
+func TestFieldSelectionPaths(t *testing.T) {
+	tests := []struct {
+		Method string
+		Fields string
+		Err    bool
+		Paths  []string
+	}{
+		{"/example.TestService/ListTargets", `host`, false, []string{"host", "host.hostname", "host.ip", "host.mac", "host.serial"}},
+		{"/example.TestService/ListTargets", `host.*`, false, []string{"host.hostname", "host.ip", "host.mac", "host.serial"}},
+		{"/example.TestService/ListTargets", `host.ip,name`, false, []string{"host.ip", "name"}},
+		{"/example.TestService/ListTargets", `target`, false, []string{"host", "host.hostname", "host.ip", "host.mac", "host.serial", "net", "net.cidr"}},
+		{"/example.TestService/ListTargets", `host.mac`, true, nil},
+		{"/example.TestService/ListTargets", `host.mac,host.ip`, true, nil},
+		{"/example.TestService/List", `home_address.*`, false, []string{"home_address.city", "home_address.country"}},
+		{"/example.TestService/List", `first_name,home_address`, false, []string{"first_name", "home_address", "home_address.city", "home_address.country"}},
+		{"/example.TestService/Read", `custom_type.recur.*`, false, []string{"custom_type.recur.code", "custom_type.recur.name", "custom_type.recur.recur"}},
+		{"/example.TestService/Read", ``, false, nil},
+	}
+
+	for _, test := range tests {
+		v := ExampleMethodValidators[test.Method]
+		fs := query.ParseFieldSelection(test.Fields)
+		if err := v.ValidateFieldSelection(fs); err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %s field selection: %s", test.Fields, err)
+			}
+			continue
+		} else if test.Err == true {
+			t.Errorf("Expected error for %s field selection, but got no error", test.Fields)
+		}
+
+		if paths := v.FieldSelectionPaths(fs); !reflect.DeepEqual(paths, test.Paths) {
+			t.Errorf("Unexpected paths of %s field selection: %v", test.Fields, paths)
+		}
+	}
+}
+
+func TestFieldSelectionToFieldMask(t *testing.T) {
+	allowed := ExampleMethodsRequireFieldSelectionValidation["/example.TestService/List"]
+	tests := []struct {
+		Fields string
+		Paths  []string
+	}{
+		{`first_name,home_address.city`, []string{"first_name", "home_address.city"}},
+		{`home_address.*,home_address.city`, []string{"home_address.city", "home_address.country"}},
+		{`work_address`, []string{"work_address"}},
+		{``, nil},
+	}
+
+	for _, test := range tests {
+		mask := options.FieldSelectionToFieldMask(query.ParseFieldSelection(test.Fields), allowed)
+		if !reflect.DeepEqual(mask.GetPaths(), test.Paths) {
+			t.Errorf("Unexpected field mask of %s field selection: %v", test.Fields, mask.GetPaths())
+		}
+	}
+
+	v := ExampleMethodValidators["/example.TestService/ListTargets"]
+	if mask := v.FieldMask(query.ParseFieldSelection(`name,owner`)); !reflect.DeepEqual(mask.GetPaths(), []string{"group", "name", "user"}) {
+		t.Errorf("Unexpected field mask of oneof: %v", mask.GetPaths())
+	}
+
+	tree := options.NewFieldTree(&types.FieldMask{Paths: []string{"host.ip", "name", "host.hostname", "net", "net.cidr"}})
+	expected := options.FieldTree{
+		"host": {"ip": nil, "hostname": nil},
+		"name": nil,
+		"net":  nil,
+	}
+	if !reflect.DeepEqual(tree, expected) {
+		t.Errorf("Unexpected field tree: %v", tree)
+	}
+	if tree := options.NewFieldTree(nil); tree != nil {
+		t.Errorf("Unexpected field tree of nil mask: %v", tree)
+	}
+}
+
+func TestPruners(t *testing.T) {
+	newShelf := func() *Shelf {
+		return &Shelf{
+			Name:     "fiction",
+			Books:    []*Book{{Title: "Dune", Isbn: "0441013597"}},
+			Address:  &common.Address{City: "Tacoma", Country: "US"},
+			Location: &Shelf_Branch{Branch: &common.Address{City: "Seattle", Country: "US"}},
+			Labels:   map[string]string{"genre": "sf"},
+		}
+	}
+
+	tests := []struct {
+		Fields   string
+		Expected *Shelf
+	}{
+		{`name,books.title,address.city`, &Shelf{
+			Name:    "fiction",
+			Books:   []*Book{{Title: "Dune"}},
+			Address: &common.Address{City: "Tacoma"},
+		}},
+		{`branch.country,labels`, &Shelf{
+			Location: &Shelf_Branch{Branch: &common.Address{Country: "US"}},
+			Labels:   map[string]string{"genre": "sf"},
+		}},
+		{`location,address`, &Shelf{
+			Address:  &common.Address{City: "Tacoma", Country: "US"},
+			Location: &Shelf_Branch{Branch: &common.Address{City: "Seattle", Country: "US"}},
+		}},
+		{``, newShelf()},
+	}
+
+	v := LibraryMethodValidators["/example.Library/ListShelves"]
+	for _, test := range tests {
+		fs := query.ParseFieldSelection(test.Fields)
+		if err := v.ValidateFieldSelection(fs); err != nil {
+			t.Fatalf("Unexpected error for %s field selection: %s", test.Fields, err)
+		}
+		shelf := newShelf()
+		LibraryPruneShelf(shelf, options.NewFieldTree(v.FieldMask(fs)))
+		if !reflect.DeepEqual(shelf, test.Expected) {
+			t.Errorf("Unexpected shelf pruned by %s field selection: %+v", test.Fields, shelf)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	v := ExampleMethodValidators["/example.TestService/List"]
+	admin := &options.Principal{Subject: "root", Roles: []string{"admin"}}
+	hr := &options.Principal{Subject: "jane", Roles: []string{"hr"}}
+
+	tests := []struct {
+		Principal *options.Principal
+		Filter    string
+		OrderBy   string
+		Fields    string
+		Err       bool
+		Denied    bool
+	}{
+		{nil, `first_name=="Sam"`, `first_name`, `first_name`, false, false},
+		{admin, `speciality~"dev"`, `weight`, `home_address.city`, false, false},
+		{hr, `speciality~"dev"`, ``, ``, false, false},
+		{nil, `speciality~"dev"`, ``, ``, true, true},
+		{nil, `first_name=="Sam" or not speciality~"dev"`, ``, ``, true, true},
+		{nil, `user_friend.speciality~"dev"`, ``, ``, true, true},
+		{hr, ``, `weight`, ``, true, true},
+		{hr, ``, ``, `home_address`, true, true},
+		{hr, ``, ``, `home_address.*`, true, true},
+		{hr, ``, ``, `first_name,home_address.city`, true, true},
+		{hr, ``, ``, `*`, true, true},
+		{admin, ``, ``, `*`, false, false},
+		{nil, `speciality=="dev"`, ``, ``, true, false},
+		{nil, `unknown=="dev"`, ``, ``, true, false},
+	}
+
+	for _, test := range tests {
+		req := &testListRequest{fields: query.ParseFieldSelection(test.Fields)}
+		if test.Filter != "" {
+			req.filter, _ = query.ParseFiltering(test.Filter)
+		}
+		if test.OrderBy != "" {
+			req.orderBy, _ = query.ParseSorting(test.OrderBy)
+		}
+		ctx := options.NewPrincipalContext(context.Background(), test.Principal)
+		err := v.ValidateCtx(ctx, req)
+		if (err != nil) != test.Err || options.IsPermissionDenied(err) != test.Denied {
+			t.Errorf("Unexpected error for %+v: %v", test, err)
+		}
+		if err := v.Validate(req); err != nil && !test.Err {
+			t.Errorf("Unexpected error without permission checks for %+v: %v", test, err)
+		}
+	}
+
+	if err := v.ValidateFilteringStringCtx(context.Background(), `speciality~"dev"`); !options.IsPermissionDenied(err) {
+		t.Errorf("Expected permission error, but got %v", err)
+	}
+
+	registry := options.NewMethodRegistry()
+	registry.SetPrincipalExtractor(options.PrincipalExtractorFunc(func(ctx context.Context) (*options.Principal, error) {
+		return hr, nil
+	}))
+	registry.Register("/example.TestService/List", options.MustRulesValidator(v.Rules(), func(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection) {
+		r := req.(*testListRequest)
+		return r.filter, r.orderBy, r.fields
+	}))
+	req := &testListRequest{fields: query.ParseFieldSelection("home_address")}
+	ctx := options.NewPrincipalContext(context.Background(), admin)
+	if err := registry.ValidateRequestCtx(ctx, "/example.TestService/List", req); !options.IsPermissionDenied(err) {
+		t.Errorf("Expected permission error from the extractor of the registry, but got %v", err)
+	}
+
+	// A parent of a field the caller is not allowed to see is denied unless
+	// the unauthorized fields are dropped.
+	rules := ExampleMethodValidators["/example.TestService/ListTargets"].Rules()
+	rules.DropUnauthorizedFields = false
+	targets := options.MustRulesValidator(rules, nil)
+	ctx = options.NewPrincipalContext(context.Background(), hr)
+	for _, fields := range []string{`host`, `target`, `host.*`} {
+		if err := targets.ValidateFieldSelectionCtx(ctx, query.ParseFieldSelection(fields)); !options.IsPermissionDenied(err) {
+			t.Errorf("Expected permission error for %s field selection, but got %v", fields, err)
+		}
+		if _, err := targets.AuthorizeFieldSelection(ctx, query.ParseFieldSelection(fields)); !options.IsPermissionDenied(err) {
+			t.Errorf("Expected permission error for %s authorized field selection, but got %v", fields, err)
+		}
+	}
+	if err := targets.ValidateFieldSelectionCtx(ctx, query.ParseFieldSelection(`name,host.hostname`)); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	// No selection is narrowed to the fields the caller is allowed to see.
+	fs, err := v.AuthorizeFieldSelection(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if paths := v.FieldSelectionPaths(fs); len(paths) == 0 {
+		t.Errorf("Expected the default field selection, but got all fields")
+	} else {
+		for _, p := range paths {
+			if p == "ssn" || strings.HasPrefix(p, "home_address") {
+				t.Errorf("Unexpected %s field in the default field selection", p)
+			}
+		}
+	}
+}
+
+func TestFieldVisibility(t *testing.T) {
+	admin := options.NewPrincipalContext(context.Background(), &options.Principal{Roles: []string{"admin"}})
+	support := options.NewPrincipalContext(context.Background(), &options.Principal{Roles: []string{"support"}})
+
+	tests := []struct {
+		Method string
+		Ctx    context.Context
+		Fields string
+		Err    bool
+		Paths  []string
+	}{
+		{"/example.TestService/List", support, `first_name,id`, false, []string{"first_name", "id"}},
+		{"/example.TestService/List", support, `first_name,ssn`, true, nil},
+		{"/example.TestService/List", support, `home_address.city`, true, nil},
+		{"/example.TestService/List", admin, `first_name,ssn`, false, []string{"first_name", "ssn"}},
+		{"/example.TestService/ListTargets", support, `name,host.ip`, false, []string{"name"}},
+		{"/example.TestService/ListTargets", support, `host`, false, []string{"host.hostname", "host.mac", "host.serial"}},
+		{"/example.TestService/ListTargets", support, `host.*`, false, []string{"host.hostname", "host.mac", "host.serial"}},
+		{"/example.TestService/ListTargets", support, `host.ip`, true, nil},
+		{"/example.TestService/ListTargets", admin, `host`, false, []string{"host.hostname", "host.ip", "host.mac", "host.serial"}},
+		{"/example.TestService/ListTargets", admin, `name,host.ip`, false, []string{"host.ip", "name"}},
+		{"/example.TestService/ListTargets", admin, `target`, false, []string{"host.hostname", "host.ip", "host.mac", "host.serial", "net.cidr"}},
+		{"/example.TestService/ListTargets", support, ``, false, []string{"group", "host.hostname", "host.mac", "host.serial", "name", "net.cidr", "tenant_id", "user"}},
+		{"/example.TestService/ListTargets", admin, ``, false, []string{"group", "host.hostname", "host.ip", "host.mac", "host.serial", "name", "net.cidr", "tenant_id", "user"}},
+		{"/example.TestService/List", admin, ``, false, nil},
+	}
+
+	for _, test := range tests {
+		v := ExampleMethodValidators[test.Method]
+		fs, err := v.AuthorizeFieldSelection(test.Ctx, query.ParseFieldSelection(test.Fields))
+		if err != nil {
+			if !test.Err || !options.IsPermissionDenied(err) {
+				t.Errorf("Unexpected error for %s field selection: %s", test.Fields, err)
+			}
+			continue
+		} else if test.Err {
+			t.Errorf("Expected error for %s field selection, but got no error", test.Fields)
+		}
+		if paths := v.FieldMask(fs).GetPaths(); !reflect.DeepEqual(paths, test.Paths) {
+			t.Errorf("Unexpected authorized selection of %s field selection: %v", test.Fields, paths)
+		}
+	}
+
+	v := ExampleMethodValidators["/example.TestService/List"]
+	fs, err := v.DefaultFieldSelection(support)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, hidden := range []string{"ssn", "home_address"} {
+		if fs.GetFields()[hidden] != nil {
+			t.Errorf("Unexpected %s field in the default selection", hidden)
+		}
+	}
+	if fs.GetFields()["first_name"] == nil {
+		t.Errorf("Missing first_name field in the default selection")
+	}
+
+	// The unauthorized fields of ListTargets are dropped rather than rejected.
+	req := &testListRequest{fields: query.ParseFieldSelection(`host.ip`)}
+	if err := ExampleMethodValidators["/example.TestService/ListTargets"].ValidateCtx(support, req); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	// The interceptor replaces the field selection of the request.
+	const method = "/example.Library/ListShelves"
+	rules := LibraryMethodValidators[method].Rules()
+	rules.Permissions.FieldSelection = map[string][]string{"address.city": {"admin"}}
+	rules.DropUnauthorizedFields = true
+	registry := options.NewMethodRegistry()
+	registry.Register(method, options.MustRulesValidator(rules, func(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection) {
+		return nil, nil, req.(*ListShelvesRequest).GetFields()
+	}))
+	unary := (&interceptor.Interceptor{Registry: registry}).Unary()
+	interceptorTests := []struct {
+		Fields string
+		Paths  []string
+	}{
+		{`name,address`, []string{"address.country", "name"}},
+		{`name,address.city`, []string{"name"}},
+		{`name,books`, []string{"books", "name"}},
+		{``, []string{"address.country", "books", "branch", "labels", "name", "room"}},
+	}
+	for _, test := range interceptorTests {
+		req := &ListShelvesRequest{}
+		if test.Fields != "" {
+			req.Fields = query.ParseFieldSelection(test.Fields)
+		}
+		var fs *query.FieldSelection
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			fs = req.(*ListShelvesRequest).GetFields()
+			return nil, nil
+		}
+		if _, err := unary(support, req, &grpc.UnaryServerInfo{FullMethod: method}, handler); err != nil {
+			t.Fatalf("Unexpected error for %s field selection: %s", test.Fields, err)
+		}
+		if paths := LibraryMethodValidators[method].FieldMask(fs).GetPaths(); !reflect.DeepEqual(paths, test.Paths) {
+			t.Errorf("Unexpected field selection of the request having %s field selection: %v", test.Fields, paths)
+		}
+	}
+}
+
+func TestSensitiveFields(t *testing.T) {
+	const method = "/example.TestService/ListTargets"
+
+	for _, test := range []struct {
+		Query string
+		Err   bool
+	}{
+		{`host.password == "secret"`, true},
+		{`net.psk == "secret"`, false},
+		{`net.psk ~ "secret"`, true},
+	} {
+		f, err := query.ParseFiltering(test.Query)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Query)
+		}
+		if err := ExampleValidateFiltering(method, f); err != nil && !test.Err {
+			t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+		} else if err == nil && test.Err {
+			t.Errorf("Expected error for %s query, but got no error", test.Query)
+		}
+	}
+
+	for _, field := range []string{"host.password", "net.psk"} {
+		s, err := query.ParseSorting(field)
+		if err != nil {
+			t.Fatalf("Invalid sorting data '%s'", field)
+		}
+		if err := ExampleValidateSorting(method, s); err == nil {
+			t.Errorf("Expected error for %s sorting, but got no error", field)
+		}
+		if err := ExampleValidateFieldSelection(method, query.ParseFieldSelection(field)); err == nil {
+			t.Errorf("Expected error for %s field selection, but got no error", field)
+		}
+	}
+}
+
+func TestFieldBehaviors(t *testing.T) {
+	const method = "/example.TestService/ListSites"
+
+	for _, test := range []struct {
+		Query string
+		Err   bool
+	}{
+		{`secret_token == "x"`, true},
+		{`member_count > 10`, true},
+		{`created_by == "admin"`, false},
+		{`created_by ~ "adm"`, true},
+	} {
+		f, err := query.ParseFiltering(test.Query)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Query)
+		}
+		if err := ExampleValidateFiltering(method, f); err != nil && !test.Err {
+			t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+		} else if err == nil && test.Err {
+			t.Errorf("Expected error for %s query, but got no error", test.Query)
+		}
+	}
+
+	for _, test := range []struct {
+		Field                 string
+		SortErr, SelectionErr bool
+	}{
+		{"secret_token", true, true},
+		{"member_count", true, false},
+		{"created_by", true, false},
+	} {
+		s, err := query.ParseSorting(test.Field)
+		if err != nil {
+			t.Fatalf("Invalid sorting data '%s'", test.Field)
+		}
+		if err := ExampleValidateSorting(method, s); (err != nil) != test.SortErr {
+			t.Errorf("Unexpected result for %s sorting: %v", test.Field, err)
+		}
+		if err := ExampleValidateFieldSelection(method, query.ParseFieldSelection(test.Field)); (err != nil) != test.SelectionErr {
+			t.Errorf("Unexpected result for %s field selection: %v", test.Field, err)
+		}
+	}
+}
+
+func TestGormOptions(t *testing.T) {
+	const method = "/example.TestService/ListDevices"
+
+	for _, test := range []struct {
+		Query string
+		Err   bool
+	}{
+		{`name == "gw"`, false},
+		{`owner.name == "bob"`, false},
+		{`cache_key == "x"`, true},
+		{`ports.number == 22`, true},
+		{`address.city == "Tacoma"`, true},
+	} {
+		f, err := query.ParseFiltering(test.Query)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Query)
+		}
+		if err := ExampleValidateFiltering(method, f); err != nil && !test.Err {
+			t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+		} else if err == nil && test.Err {
+			t.Errorf("Expected error for %s query, but got no error", test.Query)
+		}
+	}
+
+	for _, test := range []struct {
+		Query string
+		Err   bool
+	}{
+		{`name, owner.name`, false},
+		{`cache_key`, true},
+		{`address.city`, true},
+	} {
+		s, err := query.ParseSorting(test.Query)
+		if err != nil {
+			t.Fatalf("Invalid sorting data '%s'", test.Query)
+		}
+		if err := ExampleValidateSorting(method, s); err != nil && !test.Err {
+			t.Errorf("Unexpected error for %s sorting: %s", test.Query, err)
+		} else if err == nil && test.Err {
+			t.Errorf("Expected error for %s sorting, but got no error", test.Query)
+		}
+	}
+
+	v := ExampleMethodValidators[method]
+	f, err := query.ParseFiltering(`name == "gw" and owner.name == "bob"`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	s, err := query.ParseSorting(`owner.name desc`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := v.ValidateCtx(context.Background(), &testListRequest{filter: f, orderBy: s}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// The validation keeps the field paths the gorm layer of atlas-app-toolkit
+	// expects, the column paths are only used when asked explicitly.
+	if fields := options.NewQueryInput(f, s, nil).FilterFields; !reflect.DeepEqual(fields, []string{"name", "owner.name"}) {
+		t.Errorf("Unexpected validated filtering fields: %v", fields)
+	}
+	v.MapColumns(f, s)
+	if fields := options.NewQueryInput(f, s, nil).FilterFields; !reflect.DeepEqual(fields, []string{"device_name", "owner.full_name"}) {
+		t.Errorf("Unexpected mapped filtering fields: %v", fields)
+	}
+	if tag := s.GetCriterias()[0].GetTag(); tag != "owner.full_name" {
+		t.Errorf("Unexpected mapped sorting field: %s", tag)
+	}
+}
+
+func TestLiteralConstraints(t *testing.T) {
+	tests := []struct {
+		Query string
+		Err   bool
+	}{
+		{`serial == "0b8b5b8e-4b8c-4cbb-9b5e-3f0f6f1a0c3d"`, false},
+		{`serial == "x"`, true},
+		{`serial != "x"`, false},
+		{`not serial == "x"`, false},
+		{`not (serial == "x" and rack == 43)`, false},
+		{`not (serial == "x" or not rack == 43)`, true},
+		{`not (serial == "x" or not rack == 42)`, false},
+		{`not (not serial == "x")`, true},
+		{`serial in ["0b8b5b8e-4b8c-4cbb-9b5e-3f0f6f1a0c3d", "x"]`, true},
+		{`not serial in ["0b8b5b8e-4b8c-4cbb-9b5e-3f0f6f1a0c3d", "x"]`, false},
+		{`serial ~ "^0b8b"`, false},
+		{`hostname == "gw"`, false},
+		{`hostname == "a-very-long-hostname"`, true},
+		{`status == "ONLINE"`, false},
+		{`status ieq "online"`, false},
+		{`status == "BROKEN"`, true},
+		{`status in ["ONLINE", "OFFLINE"]`, false},
+		{`rack == 42`, false},
+		{`rack == 43`, true},
+		{`rack in [1, 0]`, true},
+		{`rack > 100`, false},
+		{`tags == "edge"`, false},
+		{`tags == "leaf"`, true},
+	}
+
+	for _, test := range tests {
+		f, err := query.ParseFiltering(test.Query)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Query)
+		}
+		if err := ExampleValidateFiltering("/example.TestService/ListDevices", f); err != nil && !test.Err {
+			t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+		} else if err == nil && test.Err {
+			t.Errorf("Expected error for %s query, but got no error", test.Query)
+		}
+	}
+
+	empty := map[string]options.FilteringOption{
+		"name": {ValueType: options.QueryValidate_STRING, Literal: &options.LiteralConstraint{MinLen: 3, IgnoreEmpty: true}},
+		"rack": {ValueType: options.QueryValidate_NUMBER, Literal: &options.LiteralConstraint{Min: options.Float64(1), IgnoreEmpty: true}},
+	}
+	for q, fails := range map[string]bool{`name == ""`: false, `name == "x"`: true, `rack == 0`: false, `rack == -1`: true} {
+		f, _ := query.ParseFiltering(q)
+		if err := options.ValidateFiltering(f, empty); err != nil && !fails {
+			t.Errorf("Unexpected error for %s query: %s", q, err)
+		} else if err == nil && fails {
+			t.Errorf("Expected error for %s query, but got no error", q)
+		}
+	}
+
+	bad := map[string]options.FilteringOption{
+		"name": {ValueType: options.QueryValidate_STRING, Literal: &options.LiteralConstraint{Pattern: "("}},
+	}
+	f, _ := query.ParseFiltering(`name == "x"`)
+	if err := options.ValidateFiltering(f, bad); err == nil {
+		t.Errorf("Expected error for an invalid literal pattern")
+	}
+	if _, err := options.NewRulesValidator(options.MethodRules{Filtering: bad}, nil); err == nil {
+		t.Errorf("Expected error for an invalid literal pattern")
+	}
+}
+
+func TestNullChecks(t *testing.T) {
+	tests := []struct {
+		Method string
+		Query  string
+		Err    bool
+	}{
+		{"/example.TestService/List", `last_name == null`, false},
+		{"/example.TestService/List", `not(last_name == null)`, false},
+		{"/example.TestService/List", `nickname == null`, false},
+		{"/example.TestService/List", `first_name == null`, true},
+		{"/example.TestService/List", `weight == null`, true},
+		{"/example.TestService/List", `boolean_field == null`, true},
+		{"/example.TestService/List", `id == null`, true},
+		{"/example.TestService/List", `custom_type == null`, true},
+		{"/example.TestService/List", `home_address.city == null`, true},
+		{"/example.TestService/ListSites", `labels.env == null`, false},
+		{"/example.TestService/ListSites", `labels.tier == null`, true},
+		{"/example.TestService/ListTargets", `user == null`, false},
+	}
+
+	for _, test := range tests {
+		f, err := query.ParseFiltering(test.Query)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Query)
+		}
+		if err := ExampleValidateFiltering(test.Method, f); err != nil && !test.Err {
+			t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+		} else if err == nil && test.Err {
+			t.Errorf("Expected error for %s query, but got no error", test.Query)
+		}
+	}
+
+	f, _ := query.ParseFiltering(`name == null`)
+	if err := options.ValidateFiltering(f, map[string]options.FilteringOption{
+		"name": {ValueType: options.QueryValidate_STRING},
+	}); err == nil {
+		t.Errorf("Expected error for null check on a field which is not nullable")
+	}
+	if err := options.ValidateFiltering(f, map[string]options.FilteringOption{
+		"name": {ValueType: options.QueryValidate_STRING, Nullable: true},
+	}); err != nil {
+		t.Errorf("Unexpected error for null check on a nullable field: %s", err)
+	}
+}
+
+func TestDeprecatedFields(t *testing.T) {
+	getQuery := func(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection) {
+		r := req.(*testListRequest)
+		return r.GetFilter(), r.GetOrderBy(), r.GetFields()
+	}
+	registry := options.NewMethodRegistry()
+	for method, v := range ExampleMethodValidators {
+		registry.Register(method, options.MustRulesValidator(v.Rules(), getQuery))
+	}
+	v, _ := registry.Validator("/example.TestService/ListDevices")
+
+	tests := []struct {
+		Filter   string
+		Sort     string
+		Fields   string
+		Warnings []string
+	}{
+		{`name == "gw"`, "name", "name,rack", nil},
+		{`label == "gw" and (label ~ "^g" or owner.email == "sam@example.com")`, "", "", []string{
+			"Filtering by 'label' is deprecated",
+			"Filtering by 'owner.email' is deprecated",
+		}},
+		{"", "owner.email desc", "owner.email,label", []string{
+			"Sorting by 'owner.email' is deprecated",
+			"Selecting 'label' is deprecated",
+			"Selecting 'owner.email' is deprecated",
+		}},
+	}
+
+	for _, test := range tests {
+		var (
+			f  *query.Filtering
+			s  *query.Sorting
+			fs *query.FieldSelection
+		)
+		if test.Filter != "" {
+			f, _ = query.ParseFiltering(test.Filter)
+		}
+		if test.Sort != "" {
+			s, _ = query.ParseSorting(test.Sort)
+		}
+		if test.Fields != "" {
+			fs = query.ParseFieldSelection(test.Fields)
+		}
+
+		var warnings []string
+		for _, w := range v.Warnings(f, s, fs) {
+			warnings = append(warnings, w.String())
+		}
+		if !reflect.DeepEqual(warnings, test.Warnings) {
+			t.Errorf("Unexpected warnings for %q, %q, %q: %q", test.Filter, test.Sort, test.Fields, warnings)
+		}
+	}
+
+	recursive, _ := registry.Validator("/example.TestService/List")
+	s, _ := query.ParseSorting("custom_type.recur.recur.code")
+	if w := recursive.Warnings(nil, s, nil); len(w) != 1 || w[0].String() != "Sorting by 'custom_type.recur.recur.code' is deprecated" {
+		t.Errorf("Unexpected warnings for a recursive field: %v", w)
+	}
+
+	counts := registry.DeprecationCounts()
+	expected := map[string]map[string]uint64{
+		"/example.TestService/List":        {"custom_type.code": 1},
+		"/example.TestService/Read":        {"custom_type.code": 0},
+		"/example.TestService/ListDevices": {"label": 2, "owner.email": 3},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Unexpected deprecation counts %v", counts)
+	}
+
+	stream := &trailerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	info := &grpc.UnaryServerInfo{FullMethod: "/example.TestService/ListDevices"}
+	s, _ = query.ParseSorting("label")
+	req := &testListRequest{orderBy: s}
+	i := &interceptor.Interceptor{Registry: registry}
+	if _, err := i.Unary()(ctx, req, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if trailer := stream.trailer.Get(interceptor.WarningTrailer); !reflect.DeepEqual(trailer, []string{"Sorting by 'label' is deprecated"}) {
+		t.Errorf("Unexpected %s trailer %q", interceptor.WarningTrailer, trailer)
+	}
+}
+
+type trailerStream struct {
+	grpc.ServerTransportStream
+	trailer metadata.MD
+}
+
+func (s *trailerStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestRecursiveFields(t *testing.T) {
+	tests := []struct {
+		Query string
+		Err   bool
+	}{
+		{`user_friend.first_name=="Sam"`, false},
+		{`user_friend.user_friend.first_name=="Sam"`, true},
+		{`user_friend.home_address.city=="city"`, true},
+		{`user_friend.custom_type.recur.name=="name"`, true},
+		{`user_friend.first_name<"Sam"`, true},
+		{`user_friend.work_address.city=="city"`, true},
+		{`custom_type.recur.name=="name"`, false},
+		{`custom_type.recur.recur.recur.recur.name=="name"`, false},
+		{`custom_type.recur.recur.recur.recur.recur.name=="name"`, true},
+		{`custom_type.recur.unknown=="name"`, true},
+	}
+
+	rules := ExampleMethodsRequireFilteringValidation["/example.TestService/List"]
+	for _, test := range tests[:5] {
+		if err := options.ValidateFilteringString(test.Query, rules); (err != nil) != test.Err {
+			t.Errorf("Unexpected result of legacy validation of %s query: %v", test.Query, err)
+		}
+	}
+
+	for _, test := range tests {
+		err := ExampleValidateFilteringString("/example.TestService/List", test.Query)
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %s query, but got no error", test.Query)
+			}
+		}
+	}
+
+	fieldTests := []struct {
+		Fields string
+		Err    bool
+	}{
+		{`custom_type.recur.recur.name`, false},
+		{`custom_type.recur.recur.recur.recur.recur`, false},
+		{`custom_type.recur.recur.recur.recur.recur.recur`, true},
+		{`custom_type_string.recur`, true},
+	}
+
+	for _, test := range fieldTests {
+		err := ExampleValidateFieldSelection("/example.TestService/Read", query.ParseFieldSelection(test.Fields))
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %s field selection: %s", test.Fields, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %s field selection, but got no error", test.Fields)
+			}
+		}
+	}
+
+	r := ExampleMethodsRequireRecursionValidation["/example.TestService/List"].Filtering["custom_type.recur"]
+	if r.Target != "custom_type" || r.MaxDepth != 4 {
+		t.Errorf("Unexpected recursion of custom_type.recur: %+v", r)
+	}
+}
+
+func TestMethodValidator(t *testing.T) {
+	tests := []struct {
+		Filter  string
+		OrderBy string
+		Fields  string
+		Err     bool
+	}{
+		{`first_name=="Sam"`, `weight`, `first_name,weight`, false},
+		{``, ``, ``, false},
+		{`first_name<"Sam"`, `weight`, `first_name`, true},
+		{`first_name=="Sam"`, `on_vacation`, `first_name`, true},
+		{`first_name=="Sam"`, `weight`, `unknown_field`, true},
+		{`first_name=="Sam" and (weight==1 or id=="some_id")`, ``, ``, true},
+	}
+
+	v := ExampleMethodValidators["/example.TestService/List"]
+	for _, test := range tests {
+		f, err := query.ParseFiltering(test.Filter)
+		if err != nil {
+			t.Fatalf("Invalid filtering data '%s'", test.Filter)
+		}
+		s, err := query.ParseSorting(test.OrderBy)
+		if err != nil {
+			t.Fatalf("Invalid sorting data '%s'", test.OrderBy)
+		}
+		req := &testListRequest{filter: f, orderBy: s, fields: query.ParseFieldSelection(test.Fields)}
+		err = v.Validate(req)
+		if err != nil {
+			if test.Err == false {
+				t.Errorf("Unexpected error for %+v request: %s", test, err)
+			}
+		} else {
+			if test.Err == true {
+				t.Errorf("Expected error for %+v request, but got no error", test)
+			}
+		}
+	}
+}
+
+func TestFilteringOptionAllowedOperators(t *testing.T) {
+	for method, rules := range ExampleMethodsRequireFilteringValidation {
+		for field, rule := range rules {
+			legacy := options.FilteringOption{ValueType: rule.ValueType, Deny: rule.Deny, Nullable: rule.Nullable}
+			if got := legacy.AllowedOperators(); got != rule.AllowedOperators() {
+				t.Errorf("%s %s: operators computed from deny list %v differ from generated %v", method, field, got.Operators(), rule.AllowedOperators().Operators())
+			}
+			if !rule.AllowedSet {
+				t.Errorf("%s %s: generated option lacks AllowedSet", method, field)
+			}
+		}
+	}
+
+	denyAll := options.FilteringOption{ValueType: options.QueryValidate_STRING, AllowedSet: true}
+	if ops := denyAll.AllowedOperators(); ops != 0 {
+		t.Errorf("Expected an empty set mask to deny all operators, but got %v", ops.Operators())
+	}
+	legacy := options.FilteringOption{ValueType: options.QueryValidate_STRING}
+	if ops := legacy.AllowedOperators(); ops != options.SupportedFilterOperators(options.QueryValidate_STRING) {
+		t.Errorf("Expected a missing mask to allow all supported operators, but got %v", ops.Operators())
+	}
+	legacyMask := options.FilteringOption{ValueType: options.QueryValidate_STRING, Nullable: true, Allowed: options.NewFilterOperatorMask(options.QueryValidate_EQ)}
+	if ops := legacyMask.AllowedOperators(); ops != options.NewFilterOperatorMask(options.QueryValidate_EQ) {
+		t.Errorf("Expected a mask without AllowedSet to be used as is, but got %v", ops.Operators())
+	}
+}
+
+func TestNewQueryInput(t *testing.T) {
+	f, err := query.ParseFiltering(`first_name=="Sam" and weight>1 and not (comment~"x" or id in ["1","2"]) and last_name==null`)
+	if err != nil {
+		t.Fatalf("Invalid filtering data: %s", err)
+	}
+	s, err := query.ParseSorting("weight desc,first_name")
+	if err != nil {
+		t.Fatalf("Invalid sorting data: %s", err)
+	}
+	in := &options.AuthorizationInput{
+		Method:    "/example.TestService/List",
+		Principal: &options.Principal{Subject: "jane", Roles: []string{"hr"}, Attributes: map[string]interface{}{"tenant": "acme"}},
+		Query:     options.NewQueryInput(f, s, query.ParseFieldSelection("weight,first_name")),
+	}
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := `{"method":"/example.TestService/List",` +
+		`"principal":{"subject":"jane","roles":["hr"],"attributes":{"tenant":"acme"}},` +
+		`"query":{"filter":{"op":"and","operands":[` +
+		`{"op":"eq","field":"first_name","value":"Sam"},` +
+		`{"op":"gt","field":"weight","value":1},` +
+		`{"op":"or","negate":true,"operands":[{"op":"match","field":"comment","value":"x"},{"op":"in","field":"id","value":["1","2"]}]},` +
+		`{"op":"null","field":"last_name"}]},` +
+		`"filter_fields":["comment","first_name","id","last_name","weight"],` +
+		`"sort":[{"field":"weight","desc":true},{"field":"first_name"}],` +
+		`"sort_fields":["first_name","weight"],` +
+		`"fields":["first_name","weight"]}}`
+	if string(data) != expected {
+		t.Errorf("Unexpected authorization input:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	data, _ = json.Marshal(options.NewQueryInput(nil, nil, nil))
+	if expected := `{"filter":null,"filter_fields":[],"sort":[],"sort_fields":[],"fields":[]}`; string(data) != expected {
+		t.Errorf("Unexpected empty query input: %s", data)
+	}
+}
+
+func TestPolicyExpressions(t *testing.T) {
+	vars := map[string]interface{}{
+		"principal": map[string]interface{}{"roles": []interface{}{"hr", "dev"}, "attributes": map[string]interface{}{"level": 3.0}},
+		"query":     map[string]interface{}{"filter_fields": []interface{}{"first_name", "weight"}},
+	}
+
+	tests := []struct {
+		Expr   string
+		Result interface{}
+		Err    bool
+	}{
+		{`"hr" in principal.roles`, true, false},
+		{`"admin" in principal.roles || principal.attributes.level >= 3`, true, false},
+		{`!("weight" in query.filter_fields) || "admin" in principal.roles`, false, false},
+		{`query.filter_fields.all(f, f.startsWith("first") || f == "weight")`, true, false},
+		{`query.filter_fields.exists_one(f, f.contains("e"))`, false, false},
+		{`query.filter_fields.filter(f, f.endsWith("name")).size()`, 1.0, false},
+		{`query.filter_fields.map(f, f.upperAscii())[1]`, "WEIGHT", false},
+		{`size(principal.roles) * 2 + 1 == 5 ? 'odd' : "even"`, "odd", false},
+		{`has(principal.attributes.tenant) ? principal.attributes.tenant : "none"`, "none", false},
+		{`principal.attributes.tenant == "acme" || true`, true, false},
+		{`principal.attributes.tenant == "acme"`, nil, true},
+		{`"level" in principal.attributes && {"a": [1, 2]}.a[1] == 2.0`, true, false},
+		{`"first_name".matches("^first_[a-z]+$") && -(1 - 3) % 2 == 0`, true, false},
+		{`unknown == 1`, nil, true},
+		{`principal.roles.exists(r, r == role)`, nil, true},
+		{`frobnicate(principal)`, nil, true},
+		{`principal.roles[`, nil, true},
+		{`1 + "a"`, nil, true},
+	}
+
+	for _, test := range tests {
+		p, err := expr.Compile(test.Expr, expr.Variables...)
+		var res interface{}
+		if err == nil {
+			res, err = p.Eval(vars)
+		}
+		if err != nil {
+			if !test.Err {
+				t.Errorf("Unexpected error for %s: %s", test.Expr, err)
+			}
+			continue
+		}
+		if test.Err {
+			t.Errorf("Expected error for %s, but got %v", test.Expr, res)
+		} else if !reflect.DeepEqual(res, test.Result) {
+			t.Errorf("Unexpected result of %s: %v", test.Expr, res)
+		}
+	}
+}
+
+func TestQueryAuthorizer(t *testing.T) {
+	const method = "/example.TestService/List"
+	onlyAdmins := func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		var deny []interface{}
+		for _, f := range input["query"].(map[string]interface{})["filter_fields"].([]interface{}) {
+			if f != "comment" {
+				continue
+			}
+			principal, _ := input["principal"].(map[string]interface{})
+			roles, _ := principal["roles"].([]interface{})
+			admin := false
+			for _, r := range roles {
+				admin = admin || r == "admin"
+			}
+			if !admin {
+				deny = append(deny, "only admins may filter by comment")
+			}
+		}
+		return map[string]interface{}{"deny": deny}, nil
+	}
+
+	authorizers := map[string]options.QueryAuthorizer{
+		"expr": expr.MustNewAuthorizer(
+			expr.Policy{
+				Name:    "comment",
+				Expr:    `!("comment" in query.filter_fields) || "admin" in principal.roles`,
+				Message: "only admins may filter by comment",
+			},
+			expr.Policy{
+				Name:    "other-method",
+				Methods: []string{"/example.TestService/ListSites"},
+				Expr:    `false`,
+			},
+		),
+		"opa": opa.NewAuthorizer(opa.EvaluatorFunc(onlyAdmins)),
+	}
+
+	admin := &options.Principal{Subject: "root", Roles: []string{"admin"}}
+	tests := []struct {
+		Principal *options.Principal
+		Filter    string
+		Denied    bool
+	}{
+		{nil, `first_name=="Sam"`, false},
+		{admin, `comment=="x"`, false},
+		{nil, `comment=="x"`, true},
+		{nil, `user_friend.comment=="x"`, true},
+		{&options.Principal{Roles: []string{"hr"}}, `first_name=="Sam" or not comment=="x"`, true},
+	}
+
+	v := ExampleMethodValidators[method]
+	for name, authorizer := range authorizers {
+		registry := options.NewMethodRegistry()
+		registry.SetQueryAuthorizer(authorizer)
+		registry.Register(method, options.MustRulesValidator(v.Rules(), func(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection) {
+			r := req.(*testListRequest)
+			return r.filter, r.orderBy, r.fields
+		}))
+
+		for _, test := range tests {
+			f, err := query.ParseFiltering(test.Filter)
+			if err != nil {
+				t.Fatalf("Invalid filtering data: %s", err)
+			}
+			ctx := options.NewPrincipalContext(context.Background(), test.Principal)
+			err = registry.ValidateRequestCtx(ctx, method, &testListRequest{filter: f})
+			if test.Denied {
+				if !options.IsPermissionDenied(err) || !strings.Contains(err.Error(), "only admins may filter by comment") {
+					t.Errorf("%s: expected permission error for %+v, but got %v", name, test, err)
+				}
+			} else if err != nil {
+				t.Errorf("%s: unexpected error for %+v: %s", name, test, err)
+			}
+		}
+	}
+
+	failing := options.QueryAuthorizerFunc(func(context.Context, *options.AuthorizationInput) error {
+		return errors.New("policy store unavailable")
+	})
+	codeTests := []struct {
+		Authorizer options.QueryAuthorizer
+		Filter     string
+		Code       codes.Code
+	}{
+		{authorizers["expr"], `first_name=="Sam"`, codes.OK},
+		{authorizers["expr"], `first_name > "Sam"`, codes.InvalidArgument},
+		{authorizers["expr"], `comment=="x"`, codes.PermissionDenied},
+		{failing, `first_name=="Sam"`, codes.Internal},
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	for _, test := range codeTests {
+		registry := options.NewMethodRegistry()
+		registry.Register(method, options.MustRulesValidator(v.Rules(), func(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection) {
+			return req.(*testListRequest).filter, nil, nil
+		}))
+		registry.SetQueryAuthorizer(test.Authorizer)
+		f, _ := query.ParseFiltering(test.Filter)
+		i := &interceptor.Interceptor{Registry: registry}
+		_, err := i.Unary()(context.Background(), &testListRequest{filter: f}, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		if code := status.Code(err); code != test.Code {
+			t.Errorf("Expected interceptor to fail %q with %s, but got %v", test.Filter, test.Code, err)
+		}
+	}
+
+	undefined := opa.NewAuthorizer(opa.EvaluatorFunc(func(context.Context, map[string]interface{}) (interface{}, error) {
+		return nil, nil
+	}))
+	if err := undefined.AuthorizeQuery(context.Background(), &options.AuthorizationInput{Query: options.NewQueryInput(nil, nil, nil)}); !options.IsPermissionDenied(err) {
+		t.Errorf("Expected undefined decision to deny the query, but got %v", err)
+	}
+	if _, err := expr.NewAuthorizer(expr.Policy{Name: "typo", Expr: `"admin" in principal.role ||`}); err == nil {
+		t.Errorf("Expected error for invalid policy")
+	}
+}
+
+func TestScopeFiltering(t *testing.T) {
+	tests := []struct {
+		Method string
+		Scope  string
+		Filter string
+		Result string
+		Err    bool
+		Denied bool
+	}{
+		{"/example.TestService/ListSites", "acme", ``, `account_id=="acme"`, false, false},
+		{"/example.TestService/ListSites", "acme", `name=="site"`, `account_id=="acme" and name=="site"`, false, false},
+		{"/example.TestService/ListSites", "acme", `name=="a" or name=="b"`, `account_id=="acme" and (name=="a" or name=="b")`, false, false},
+		{"/example.TestService/ListSites", "acme", `not name=="a"`, `account_id=="acme" and not name=="a"`, false, false},
+		{"/example.TestService/ListSites", "acme", `account_id=="other"`, ``, true, true},
+		{"/example.TestService/ListSites", "acme", `name=="site" or account_id!="acme"`, ``, true, true},
+		{"/example.TestService/ListSites", "", `name=="site"`, ``, true, true},
+		{"/example.TestService/ListTargets", "42", `name=="x"`, `tenant_id==42 and name=="x"`, false, false},
+		{"/example.TestService/ListTargets", "acme", `name=="x"`, ``, true, false},
+		{"/example.TestService/List", "acme", `first_name=="Sam"`, ``, true, false},
+		{"/example.TestService/Unknown", "acme", `first_name=="Sam"`, ``, true, false},
+	}
+
+	for _, test := range tests {
+		var f *query.Filtering
+		if test.Filter != "" {
+			var err error
+			if f, err = query.ParseFiltering(test.Filter); err != nil {
+				t.Fatalf("Invalid filtering data: %s", err)
+			}
+		}
+		ctx := options.NewScopeContext(context.Background(), test.Scope)
+		res, err := ExampleScopeFiltering(ctx, test.Method, f)
+		if err != nil {
+			if !test.Err || options.IsPermissionDenied(err) != test.Denied {
+				t.Errorf("Unexpected error for %+v: %s", test, err)
+			}
+			continue
+		} else if test.Err {
+			t.Errorf("Expected error for %+v, but got no error", test)
+			continue
+		}
+
+		expected, err := query.ParseFiltering(test.Result)
+		if err != nil {
+			t.Fatalf("Invalid filtering data: %s", err)
+		}
+		if !reflect.DeepEqual(options.NewQueryInput(res, nil, nil), options.NewQueryInput(expected, nil, nil)) {
+			t.Errorf("Unexpected scoped filter for %+v: %+v", test, options.NewQueryInput(res, nil, nil).Filter)
+		}
+	}
+
+	f, _ := query.ParseFiltering(`name=="site"`)
+	before := options.NewQueryInput(f, nil, nil)
+	ExampleScopeFiltering(options.NewScopeContext(context.Background(), "acme"), "/example.TestService/ListSites", f)
+	if !reflect.DeepEqual(options.NewQueryInput(f, nil, nil), before) {
+		t.Errorf("Unexpected modification of the filter")
+	}
+
+	registry := options.NewMethodRegistry()
+	registry.Register("/example.TestService/ListSites", options.MustRulesValidator(ExampleMethodValidators["/example.TestService/ListSites"].Rules(), nil))
+	registry.SetScopeExtractor(options.ScopeExtractorFunc(func(ctx context.Context) (string, error) {
+		return "from-extractor", nil
+	}))
+	res, err := registry.ScopeFiltering(context.Background(), "/example.TestService/ListSites", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if c, ok := res.GetRoot().(*query.Filtering_StringCondition); !ok || c.StringCondition.GetValue() != "from-extractor" {
+		t.Errorf("Unexpected scoped filter: %+v", res)
+	}
+	for _, method := range []string{"/example.TestService/List", "/example.TestService/Unknown"} {
+		if _, err := registry.ScopeFiltering(context.Background(), method, nil); err == nil {
+			t.Errorf("Expected error for %s", method)
+		} else if _, ok := err.(*options.UnscopedMethodError); !ok {
+			t.Errorf("Expected UnscopedMethodError for %s, but got %v", method, err)
+		}
+	}
+
+	// The interceptor replaces the filter of the requests of the scoped
+	// methods.
+	unary := (&interceptor.Interceptor{}).Unary()
+	interceptorTests := []struct {
+		Method string
+		Scope  string
+		Req    interface{}
+		Result string
+		Code   codes.Code
+	}{
+		{"/example.TestService/ListSites", "acme", &testListRequest{}, `account_id=="acme"`, codes.OK},
+		{"/example.TestService/ListSites", "acme", &testListRequest{filter: mustParseFiltering(t, `name=="a" or name=="b"`)}, `account_id=="acme" and (name=="a" or name=="b")`, codes.OK},
+		{"/example.TestService/ListSites", "acme", &testFilterRequest{Filter: mustParseFiltering(t, `name=="site"`)}, `account_id=="acme" and name=="site"`, codes.OK},
+		{"/example.TestService/ListSites", "acme", &testFilterRequest{Filter: mustParseFiltering(t, `account_id=="other"`)}, ``, codes.PermissionDenied},
+		{"/example.TestService/ListSites", "", &testListRequest{}, ``, codes.PermissionDenied},
+		{"/example.TestService/ListSites", "acme", &struct{}{}, ``, codes.Internal},
+		{"/example.TestService/ListTargets", "acme", &testListRequest{}, ``, codes.Internal},
+		{"/example.TestService/List", "acme", &testListRequest{filter: mustParseFiltering(t, `first_name=="Sam"`)}, `first_name=="Sam"`, codes.OK},
+	}
+	for _, test := range interceptorTests {
+		var f *query.Filtering
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			f = req.(interface{ GetFilter() *query.Filtering }).GetFilter()
+			return nil, nil
+		}
+		ctx := options.NewScopeContext(context.Background(), test.Scope)
+		_, err := unary(ctx, test.Req, &grpc.UnaryServerInfo{FullMethod: test.Method}, handler)
+		if code := status.Code(err); code != test.Code {
+			t.Errorf("Expected %s for %s %+v in %q scope, but got %v", test.Code, test.Method, test.Req, test.Scope, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		expected := mustParseFiltering(t, test.Result)
+		if !reflect.DeepEqual(options.NewQueryInput(f, nil, nil), options.NewQueryInput(expected, nil, nil)) {
+			t.Errorf("Unexpected filter of the %s request: %+v", test.Method, options.NewQueryInput(f, nil, nil).Filter)
+		}
+	}
+}
+
+func mustParseFiltering(t *testing.T, expr string) *query.Filtering {
+	f, err := query.ParseFiltering(expr)
+	if err != nil {
+		t.Fatalf("Invalid filtering data '%s': %s", expr, err)
+	}
+	return f
+}
+
+func TestRegistry(t *testing.T) {
+	for method := range ExampleMethodValidators {
+		if v, ok := options.Registry.Validator(method); !ok || v != ExampleMethodValidators[method] {
+			t.Errorf("Validator of %s is not registered", method)
+		}
+	}
+
+	registry := options.NewMethodRegistry()
+	v := options.MustRulesValidator(ExampleMethodValidators["/example.TestService/List"].Rules(), nil)
+	if err := registry.Register("/example.TestService/List", v); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err := registry.Register("/example.TestService/List", options.MustRulesValidator(options.MethodRules{}, nil))
+	if _, ok := err.(*options.DuplicateMethodError); !ok {
+		t.Errorf("Expected duplicate registration error, but got %v", err)
+	}
+	if registered, _ := registry.Validator("/example.TestService/List"); registered != v {
+		t.Errorf("Expected the validator registered first to be kept")
+	}
+
+	rules, ok := options.Registry.Rules("/example.TestService/Read")
+	if !ok {
+		t.Fatalf("Missing rules for /example.TestService/Read")
+	}
+	if rules.Filtering != nil {
+		t.Errorf("Unexpected filtering rules for /example.TestService/Read: %v", rules.Filtering)
+	}
+	if len(rules.Sorting) != len(ExampleMethodsRequireSortingValidation["/example.TestService/Read"]) {
+		t.Errorf("Unexpected sorting rules for /example.TestService/Read: %v", rules.Sorting)
+	}
+
+	f, err := query.ParseFiltering(`id=="some_id"`)
+	if err != nil {
+		t.Fatalf("Invalid filtering data: %s", err)
+	}
+	if err := options.ValidateRequest("/example.TestService/List", &testListRequest{filter: f}); err == nil {
+		t.Errorf("Expected error for /example.TestService/List request, but got no error")
+	}
+	if err := options.ValidateRequest("/example.TestService/Unknown", &testListRequest{filter: f}); err != nil {
+		t.Errorf("Unexpected error for unregistered method: %s", err)
+	}
+}
+
+func TestValidateFilteringString(t *testing.T) {
+	tests := []struct {
+		Query  string
+		Err    bool
+		Offset int
+	}{
+		{`first_name=="Sam"`, false, 0},
+		{`first_name=="Sam" and weight==1`, false, 0},
+		{`unknown_field=="unk"`, true, 0},
+		{`first_name=="Sam" and id=="some_id"`, true, 22},
+		{`first_name=="Sam" or (weight==1 and first_name<"Jan")`, true, 36},
+		{`not (first_name=="Сэм" or home_address.city~"city")`, true, 26},
+		{`first_name=="Sam" and`, true, 21},
+		{`first_name=="Sam" and weight=1`, true, 28},
+		{`first_name=="Sam`, true, 12},
+		{`first_name=="Sam" AND id=="some_id"`, true, 22},
+		{`first_name=="Sam" And Not weight=1`, true, 32},
+		{`first_name=="Sam" OR id IN ["1"]`, true, 21},
+		{`host.hostname=="h" and net.cidr=="10.0.0.0/8"`, true, 23},
+		{`name=="t" and (user=="u" or group=="g")`, true, 28},
+		{`name=="t" and id=="x"`, true, 14},
+	}
+
+	for _, test := range tests {
+		method := "/example.TestService/List"
+		if strings.HasPrefix(test.Query, "host.") || strings.HasPrefix(test.Query, "name=") {
+			method = "/example.TestService/ListTargets"
+		}
+		err := ExampleValidateFilteringString(method, test.Query)
+		if err == nil {
+			if test.Err {
+				t.Errorf("Expected error for %s query, but got no error", test.Query)
+			}
+			continue
+		}
+		if !test.Err {
+			t.Errorf("Unexpected error for %s query: %s", test.Query, err)
+			continue
+		}
+		exprErr, ok := err.(*options.ExpressionError)
+		if !ok {
+			t.Errorf("Expected *options.ExpressionError for %s query, but got %T", test.Query, err)
+			continue
+		}
+		if exprErr.Offset != test.Offset {
+			t.Errorf("Expected error at position %d for %s query, but got %s", test.Offset, test.Query, err)
+		}
+	}
+
+	for _, method := range []string{"/example.TestService/Read", "/example.TestService/Unknown"} {
+		if err := ExampleValidateFilteringString(method, `id=="some_id"`); err != nil {
+			t.Errorf("Unexpected error for %s: %s", method, err)
+		}
+		err := ExampleValidateFilteringString(method, `id=="some_id" and`)
+		if exprErr, ok := err.(*options.ExpressionError); !ok || exprErr.Offset != 17 {
+			t.Errorf("Expected syntax error at position 17 for %s, but got %v", method, err)
+		}
+	}
+
+	for _, query := range []string{`first_name == `, `first_name ?? 1`, `first_name=="Sam" and id=="some_id"`} {
+		err := ExampleValidateFilteringString("/example.TestService/List", query)
+		if err == nil || strings.Count(err.Error(), strconv.Itoa(err.(*options.ExpressionError).Offset)) != 1 {
+			t.Errorf("Expected the position once in the error for %s query, but got %v", query, err)
+		}
+	}
+}
+
+func TestRevalidateFilters(t *testing.T) {
+	tests := []struct {
+		Query  string
+		Status options.FilterStatus
+		Fixed  string
+	}{
+		{`first_name=="Sam"`, options.FilterValid, ""},
+		{`id=="some_id"`, options.FilterInvalid, ""},
+		{`name=="Sam" and weight==1`, options.FilterFixable, `first_name=="Sam" and weight==1`},
+		{`address.country=="USA"`, options.FilterFixable, `home_address.country=="USA"`},
+		{`address.city~"city"`, options.FilterInvalid, ""},
+		{`name<"Sam"`, options.FilterInvalid, ""},
+		{`name==`, options.FilterInvalid, ""},
+	}
+
+	aliases := map[string]string{"name": "first_name", "address": "home_address"}
+	v := ExampleMethodValidators["/example.TestService/List"]
+	for _, test := range tests {
+		r := v.RevalidateFilters([]string{test.Query}, aliases)[0]
+		if r.Status != test.Status || r.Fixed != test.Fixed {
+			t.Errorf("Expected %s %q for %s query, but got %s %q (%v)", test.Status, test.Fixed, test.Query, r.Status, r.Fixed, r.Err)
+		}
+		if (r.Err == nil) != (test.Status == options.FilterValid) {
+			t.Errorf("Unexpected error for %s query: %v", test.Query, r.Err)
+		}
+	}
+}
+
+func TestCompareRules(t *testing.T) {
+	const method = "/example.TestService/List"
+	base, ok := options.Registry.Rules(method)
+	if !ok {
+		t.Fatalf("Missing rules for %s", method)
+	}
+
+	head := options.MethodRules{Filtering: map[string]options.FilteringOption{}, FieldSelection: base.FieldSelection, Recursions: base.Recursions}
+	for field, rule := range base.Filtering {
+		head.Filtering[field] = rule
+	}
+	head.Recursions.Filtering = map[string]options.Recursion{"custom_type.recur": {Target: "custom_type", MaxDepth: 2}}
+	head.FieldSelectionRequireParent = []string{"home_address.city"}
+	head.Permissions = options.MethodPermissions{
+		Filtering:      map[string][]string{"speciality": {"admin"}, "first_name": {"admin"}, "user_friend.speciality": {"admin", "hr"}},
+		FieldSelection: base.Permissions.FieldSelection,
+	}
+	head.Filtering["first_name"] = options.FilteringOption{
+		ValueType:  options.QueryValidate_STRING,
+		Allowed:    options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IN),
+		AllowedSet: true,
+	}
+	head.Filtering["weight"] = options.FilteringOption{ValueType: options.QueryValidate_STRING, Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}}
+	delete(head.Filtering, "comment")
+	head.Filtering["new_field"] = options.FilteringOption{ValueType: options.QueryValidate_NUMBER}
+	head.Scope = &options.ScopeRule{Field: "company", ValueType: options.QueryValidate_STRING}
+	lastName := head.Filtering["last_name"]
+	lastName.Nullable = false
+	head.Filtering["last_name"] = lastName
+	head.Sensitive = []string{"home_address.country"}
+
+	changes := options.CompareRules(map[string]options.MethodRules{method: base}, map[string]options.MethodRules{method: head})
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	expected := []string{
+		"BREAKING /example.TestService/List field_selection 'home_address.city': Field can only be selected with its parent",
+		"BREAKING /example.TestService/List field_selection 'home_address.country': Field made sensitive",
+		"BREAKING /example.TestService/List filtering 'comment': Filterable field removed",
+		"BREAKING /example.TestService/List filtering 'company': Filtering scoped by the field",
+		"BREAKING /example.TestService/List filtering 'custom_type.recur': Recursion depth limited to 2",
+		"BREAKING /example.TestService/List filtering 'first_name': Operators denied: MATCH",
+		"/example.TestService/List filtering 'first_name': Operators allowed: IN",
+		"BREAKING /example.TestService/List filtering 'first_name': Roles required: admin",
+		"BREAKING /example.TestService/List filtering 'last_name': Field no longer nullable",
+		"/example.TestService/List filtering 'new_field': Filterable field added",
+		"BREAKING /example.TestService/List filtering 'speciality': Roles removed: hr",
+		"BREAKING /example.TestService/List filtering 'user_friend': Recursive field removed",
+		"BREAKING /example.TestService/List filtering 'weight': Value type changed from NUMBER to STRING",
+		"BREAKING /example.TestService/List filtering 'weight': Operators denied: EQ, GT, GE, LT, IN",
+		"BREAKING /example.TestService/List sorting: Parameter removed",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected changes:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+	if !options.HasBreakingChanges(changes) {
+		t.Errorf("Expected breaking changes")
+	}
+
+	if changes := options.CompareRules(map[string]options.MethodRules{method: base}, map[string]options.MethodRules{method: base}); len(changes) != 0 {
+		t.Errorf("Unexpected changes for equal rules: %v", changes)
+	}
+
+	eq := options.NewFilterOperatorMask(options.QueryValidate_EQ, options.QueryValidate_IN)
+	tests := []struct {
+		Base, Head options.FilteringOption
+		Expected   string
+	}{
+		{options.FilteringOption{Allowed: eq, AllowedSet: true}, options.FilteringOption{Allowed: eq, AllowedSet: true, KeyPattern: "[a-z]+"}, "BREAKING /m filtering 'f': Key pattern added: [a-z]+"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, KeyPattern: "[a-z]+"}, options.FilteringOption{Allowed: eq, AllowedSet: true, KeyPattern: "[a-z_]+"}, "BREAKING /m filtering 'f': Key pattern changed from [a-z]+ to [a-z_]+"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, KeyPattern: "[a-z]+"}, options.FilteringOption{Allowed: eq, AllowedSet: true}, "/m filtering 'f': Key pattern removed"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Format: "uuid"}}, "BREAKING /m filtering 'f': Literal constraint added"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Format: "uuid"}}, options.FilteringOption{Allowed: eq, AllowedSet: true}, "/m filtering 'f': Literal constraint removed"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{MaxLen: 16}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{MaxLen: 8}}, "BREAKING /m filtering 'f': Literal constraint tightened"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{MaxLen: 8}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{MaxLen: 16}}, "/m filtering 'f': Literal constraint relaxed"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{In: []string{"a", "b"}}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{In: []string{"a"}}}, "BREAKING /m filtering 'f': Literal constraint tightened"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{In: []string{"a"}}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{In: []string{"b", "a"}}}, "/m filtering 'f': Literal constraint relaxed"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Min: options.Float64(1)}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Min: options.Float64(1), ExclusiveMin: true}}, "BREAKING /m filtering 'f': Literal constraint tightened"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Max: options.Float64(42)}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Max: options.Float64(64)}}, "/m filtering 'f': Literal constraint relaxed"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Pattern: "^a"}}, options.FilteringOption{Allowed: eq, AllowedSet: true, Literal: &options.LiteralConstraint{Pattern: "^b"}}, "BREAKING /m filtering 'f': Literal constraint tightened"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true, Nullable: true}, options.FilteringOption{Allowed: eq, AllowedSet: true}, "BREAKING /m filtering 'f': Field no longer nullable"},
+		{options.FilteringOption{Allowed: eq, AllowedSet: true}, options.FilteringOption{Allowed: eq, AllowedSet: true, Nullable: true}, "/m filtering 'f': Field made nullable"},
+	}
+	for _, test := range tests {
+		changes := options.CompareRules(
+			map[string]options.MethodRules{"/m": {Filtering: map[string]options.FilteringOption{"f": test.Base}}},
+			map[string]options.MethodRules{"/m": {Filtering: map[string]options.FilteringOption{"f": test.Head}}},
+		)
+		if len(changes) != 1 || changes[0].String() != test.Expected {
+			t.Errorf("Expected %q, but got %v", test.Expected, changes)
+		}
+	}
+}
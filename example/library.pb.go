@@ -0,0 +1,362 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: example/library.proto
+
+package example // import "github.com/infobloxopen/protoc-gen-atlas-query-validate/example"
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import query "github.com/infobloxopen/atlas-app-toolkit/query"
+import common "github.com/infobloxopen/protoc-gen-atlas-query-validate/example/common"
+import _ "github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type Shelf struct {
+	Name    string          `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Books   []*Book         `protobuf:"bytes,2,rep,name=books,proto3" json:"books,omitempty"`
+	Address *common.Address `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	// Types that are valid to be assigned to Location:
+	//	*Shelf_Room
+	//	*Shelf_Branch
+	Location             isShelf_Location  `protobuf_oneof:"location"`
+	Labels               map[string]string `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Shelf) Reset()         { *m = Shelf{} }
+func (m *Shelf) String() string { return proto.CompactTextString(m) }
+func (*Shelf) ProtoMessage()    {}
+func (*Shelf) Descriptor() ([]byte, []int) {
+	return fileDescriptor_library_11e274c6933a9e9c, []int{0}
+}
+func (m *Shelf) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Shelf.Unmarshal(m, b)
+}
+func (m *Shelf) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Shelf.Marshal(b, m, deterministic)
+}
+func (dst *Shelf) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Shelf.Merge(dst, src)
+}
+func (m *Shelf) XXX_Size() int {
+	return xxx_messageInfo_Shelf.Size(m)
+}
+func (m *Shelf) XXX_DiscardUnknown() {
+	xxx_messageInfo_Shelf.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Shelf proto.InternalMessageInfo
+
+func (m *Shelf) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Shelf) GetBooks() []*Book {
+	if m != nil {
+		return m.Books
+	}
+	return nil
+}
+
+func (m *Shelf) GetAddress() *common.Address {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+type isShelf_Location interface {
+	isShelf_Location()
+}
+
+type Shelf_Room struct {
+	Room string `protobuf:"bytes,4,opt,name=room,proto3,oneof"`
+}
+
+type Shelf_Branch struct {
+	Branch *common.Address `protobuf:"bytes,5,opt,name=branch,proto3,oneof"`
+}
+
+func (*Shelf_Room) isShelf_Location() {}
+
+func (*Shelf_Branch) isShelf_Location() {}
+
+func (m *Shelf) GetLocation() isShelf_Location {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+func (m *Shelf) GetRoom() string {
+	if x, ok := m.GetLocation().(*Shelf_Room); ok {
+		return x.Room
+	}
+	return ""
+}
+
+func (m *Shelf) GetBranch() *common.Address {
+	if x, ok := m.GetLocation().(*Shelf_Branch); ok {
+		return x.Branch
+	}
+	return nil
+}
+
+func (m *Shelf) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Shelf) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Shelf_OneofMarshaler, _Shelf_OneofUnmarshaler, _Shelf_OneofSizer, []interface{}{
+		(*Shelf_Room)(nil),
+		(*Shelf_Branch)(nil),
+	}
+}
+
+func _Shelf_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Shelf)
+	// location
+	switch x := m.Location.(type) {
+	case *Shelf_Room:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		b.EncodeStringBytes(x.Room)
+	case *Shelf_Branch:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Branch); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("Shelf.Location has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Shelf_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Shelf)
+	switch tag {
+	case 4: // location.room
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Location = &Shelf_Room{x}
+		return true, err
+	case 5: // location.branch
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(common.Address)
+		err := b.DecodeMessage(msg)
+		m.Location = &Shelf_Branch{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Shelf_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Shelf)
+	// location
+	switch x := m.Location.(type) {
+	case *Shelf_Room:
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(len(x.Room)))
+		n += len(x.Room)
+	case *Shelf_Branch:
+		s := proto.Size(x.Branch)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type Book struct {
+	Title                string   `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Isbn                 string   `protobuf:"bytes,2,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Book) Reset()         { *m = Book{} }
+func (m *Book) String() string { return proto.CompactTextString(m) }
+func (*Book) ProtoMessage()    {}
+func (*Book) Descriptor() ([]byte, []int) {
+	return fileDescriptor_library_11e274c6933a9e9c, []int{1}
+}
+func (m *Book) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Book.Unmarshal(m, b)
+}
+func (m *Book) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Book.Marshal(b, m, deterministic)
+}
+func (dst *Book) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Book.Merge(dst, src)
+}
+func (m *Book) XXX_Size() int {
+	return xxx_messageInfo_Book.Size(m)
+}
+func (m *Book) XXX_DiscardUnknown() {
+	xxx_messageInfo_Book.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Book proto.InternalMessageInfo
+
+func (m *Book) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *Book) GetIsbn() string {
+	if m != nil {
+		return m.Isbn
+	}
+	return ""
+}
+
+type ListShelvesRequest struct {
+	Fields               *query.FieldSelection `protobuf:"bytes,1,opt,name=fields,proto3" json:"fields,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ListShelvesRequest) Reset()         { *m = ListShelvesRequest{} }
+func (m *ListShelvesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListShelvesRequest) ProtoMessage()    {}
+func (*ListShelvesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_library_11e274c6933a9e9c, []int{2}
+}
+func (m *ListShelvesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListShelvesRequest.Unmarshal(m, b)
+}
+func (m *ListShelvesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListShelvesRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListShelvesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListShelvesRequest.Merge(dst, src)
+}
+func (m *ListShelvesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListShelvesRequest.Size(m)
+}
+func (m *ListShelvesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListShelvesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListShelvesRequest proto.InternalMessageInfo
+
+func (m *ListShelvesRequest) GetFields() *query.FieldSelection {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+type ListShelvesResponse struct {
+	Results              []*Shelf `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListShelvesResponse) Reset()         { *m = ListShelvesResponse{} }
+func (m *ListShelvesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListShelvesResponse) ProtoMessage()    {}
+func (*ListShelvesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_library_11e274c6933a9e9c, []int{3}
+}
+func (m *ListShelvesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListShelvesResponse.Unmarshal(m, b)
+}
+func (m *ListShelvesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListShelvesResponse.Marshal(b, m, deterministic)
+}
+func (dst *ListShelvesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListShelvesResponse.Merge(dst, src)
+}
+func (m *ListShelvesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListShelvesResponse.Size(m)
+}
+func (m *ListShelvesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListShelvesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListShelvesResponse proto.InternalMessageInfo
+
+func (m *ListShelvesResponse) GetResults() []*Shelf {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Shelf)(nil), "example.Shelf")
+	proto.RegisterMapType((map[string]string)(nil), "example.Shelf.LabelsEntry")
+	proto.RegisterType((*Book)(nil), "example.Book")
+	proto.RegisterType((*ListShelvesRequest)(nil), "example.ListShelvesRequest")
+	proto.RegisterType((*ListShelvesResponse)(nil), "example.ListShelvesResponse")
+}
+
+func init() { proto.RegisterFile("example/library.proto", fileDescriptor_library_11e274c6933a9e9c) }
+
+var fileDescriptor_library_11e274c6933a9e9c = []byte{
+	// 488 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x93, 0xcf, 0x6f, 0xd3, 0x30,
+	0x14, 0xc7, 0xfb, 0x33, 0x85, 0x57, 0x81, 0x90, 0x19, 0x22, 0x74, 0x3b, 0x54, 0xe5, 0xd2, 0x4b,
+	0x13, 0x56, 0x38, 0xc0, 0x38, 0x20, 0x2a, 0x01, 0xa3, 0xea, 0x29, 0x83, 0x0b, 0x97, 0xc9, 0x49,
+	0x5f, 0x57, 0xab, 0x4e, 0x5e, 0x66, 0xbb, 0xd5, 0xfa, 0xa7, 0x71, 0xe2, 0xce, 0x5f, 0x85, 0xec,
+	0x38, 0xd5, 0x26, 0x7e, 0x1c, 0x38, 0xd9, 0x7e, 0xdf, 0xef, 0xfb, 0xd8, 0xfe, 0x3a, 0x81, 0x27,
+	0x78, 0xc3, 0xf3, 0x52, 0x62, 0x2c, 0x45, 0xaa, 0xb8, 0xda, 0x47, 0xa5, 0x22, 0x43, 0xac, 0xe7,
+	0xcb, 0x83, 0x2f, 0x57, 0xc2, 0xac, 0xb7, 0x69, 0x94, 0x51, 0x1e, 0x8b, 0x62, 0x45, 0xa9, 0xa4,
+	0x1b, 0x2a, 0xb1, 0x88, 0x9d, 0x2f, 0x9b, 0x5c, 0x61, 0x31, 0xe1, 0x46, 0x72, 0x3d, 0xb9, 0xde,
+	0xa2, 0xda, 0x4f, 0x76, 0x5c, 0x8a, 0x25, 0x37, 0x18, 0x53, 0x69, 0x04, 0x15, 0x3a, 0x76, 0xe5,
+	0xcb, 0xba, 0x5c, 0xe1, 0x07, 0xf3, 0xbf, 0x51, 0x2b, 0x14, 0x2f, 0xcb, 0x89, 0x21, 0x92, 0x1b,
+	0x61, 0xaa, 0xfe, 0x38, 0x23, 0x29, 0x31, 0xb3, 0xc4, 0x4b, 0x2a, 0x51, 0x71, 0x43, 0x4a, 0x7b,
+	0xd6, 0x71, 0x7d, 0x83, 0x8c, 0xf2, 0x9c, 0x0a, 0x3f, 0x54, 0xe2, 0xe8, 0x47, 0x0b, 0xba, 0x17,
+	0x6b, 0x94, 0x2b, 0xc6, 0xa0, 0x53, 0xf0, 0x1c, 0xc3, 0xe6, 0xb0, 0x39, 0xbe, 0x9f, 0xb8, 0x39,
+	0x7b, 0x0e, 0xdd, 0x94, 0x68, 0xa3, 0xc3, 0xd6, 0xb0, 0x3d, 0xee, 0x4f, 0x1f, 0x44, 0x1e, 0x15,
+	0xcd, 0x88, 0x36, 0x49, 0xa5, 0xb1, 0x53, 0xe8, 0xf1, 0xe5, 0x52, 0xa1, 0xd6, 0x61, 0x7b, 0xd8,
+	0x1c, 0xf7, 0xa7, 0x4f, 0x0f, 0x36, 0xbf, 0xd5, 0xfb, 0x4a, 0x4e, 0x6a, 0x1f, 0x3b, 0x82, 0x8e,
+	0x22, 0xca, 0xc3, 0x8e, 0xdd, 0xeb, 0xbc, 0x91, 0xb8, 0x15, 0x3b, 0x85, 0x20, 0x55, 0xbc, 0xc8,
+	0xd6, 0x61, 0xf7, 0x9f, 0x9c, 0xf3, 0x46, 0xe2, 0x8d, 0x6c, 0x0a, 0x81, 0xe4, 0x29, 0x4a, 0x1d,
+	0x06, 0xee, 0x84, 0x83, 0x43, 0x8b, 0xbb, 0x54, 0xb4, 0x70, 0xe2, 0x87, 0xc2, 0xa8, 0x7d, 0xe2,
+	0x9d, 0x83, 0x37, 0xd0, 0xbf, 0x55, 0x66, 0x8f, 0xa0, 0xbd, 0xc1, 0xbd, 0xbf, 0xb6, 0x9d, 0xb2,
+	0x23, 0xe8, 0xee, 0xb8, 0xdc, 0x62, 0xd8, 0x72, 0xb5, 0x6a, 0x71, 0xd6, 0x7a, 0xdd, 0x3c, 0x0b,
+	0x7e, 0x7e, 0x7f, 0xd6, 0x0a, 0x9b, 0x33, 0x80, 0x7b, 0x92, 0x32, 0x6e, 0xe3, 0x1e, 0xbd, 0x80,
+	0x8e, 0x4d, 0xc3, 0x76, 0x19, 0x61, 0x64, 0x1d, 0x60, 0xb5, 0xb0, 0xa9, 0x0a, 0x9d, 0x16, 0x1e,
+	0xe5, 0xe6, 0xa3, 0x39, 0xb0, 0x85, 0xd0, 0xc6, 0x9e, 0x70, 0x87, 0x3a, 0xc1, 0xeb, 0x2d, 0x6a,
+	0xc3, 0x5e, 0x41, 0xb0, 0x12, 0x28, 0x97, 0xda, 0x01, 0xfa, 0xd3, 0x93, 0xa8, 0x7e, 0xf8, 0x88,
+	0x97, 0x22, 0xfa, 0x68, 0xb5, 0x0b, 0xf4, 0x8f, 0x9c, 0x78, 0xef, 0xe8, 0x1d, 0x3c, 0xbe, 0xc3,
+	0xd2, 0x25, 0x15, 0x1a, 0xd9, 0x18, 0x7a, 0x0a, 0xf5, 0x56, 0x1a, 0x4b, 0xb3, 0xc1, 0x3c, 0xbc,
+	0x1b, 0x4c, 0x52, 0xcb, 0xd3, 0xaf, 0xd0, 0x5b, 0x54, 0x5f, 0x36, 0x9b, 0x43, 0xff, 0x16, 0x8b,
+	0x1d, 0x1f, 0x5a, 0x7e, 0x3f, 0xed, 0xe0, 0xe4, 0xcf, 0x62, 0xb5, 0xfd, 0xa8, 0x31, 0xfb, 0xfc,
+	0xed, 0xd3, 0xff, 0xfe, 0x18, 0x1e, 0xfc, 0xd6, 0x8f, 0x69, 0xe0, 0x1a, 0x5e, 0xfe, 0x1a, 0x00,
+	0x8c, 0x7d, 0x9e, 0x50, 0x8a, 0x03, 0x00, 0x00,
+}
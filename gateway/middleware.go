@@ -0,0 +1,311 @@
+// Package gateway validates collection operators of REST requests served by
+// grpc-gateway before they are translated into gRPC calls.
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// Query parameters carrying collection operators in atlas REST requests.
+const (
+	FilterQueryKey = "_filter"
+	SortQueryKey   = "_order_by"
+	FieldsQueryKey = "_fields"
+)
+
+// ErrorResponse is the body of the response returned for invalid requests.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error describes the validation failure.
+type Error struct {
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Method    string `json:"method"`
+	Parameter string `json:"parameter"`
+	Value     string `json:"value"`
+}
+
+// Middleware validates collection operators of requests matching Routes
+// against the rules of the corresponding gRPC methods.
+type Middleware struct {
+	// Routes defaults to the routes built from the google.api.http bindings
+	// of the methods of Registry on the first request, see RegistryRoutes.
+	Routes *Routes
+	// Registry defaults to options.Registry.
+	Registry *options.MethodRegistry
+
+	once      sync.Once
+	routes    *Routes
+	routesErr error
+}
+
+// NewHandler returns a handler validating collection operators of requests
+// matching routes against the rules registered in options.Registry before
+// passing them to next. If routes is nil, the requests are routed by the
+// google.api.http bindings of the registered methods.
+func NewHandler(routes *Routes, next http.Handler) http.Handler {
+	m := &Middleware{Routes: routes}
+	return m.Handler(next)
+}
+
+// Handler wraps next with the validation. The _fields parameter of valid
+// requests is replaced by the selection returned by AuthorizeFieldSelection, so
+// the fields the caller is not allowed to see are dropped and no selection is
+// narrowed to the default selection of the caller. The _filter parameter of
+// the requests of the methods having a scope rule is replaced by the filter
+// restricted to the scope of the caller, see MethodValidator.ScopeFiltering.
+// The use of deprecated fields by valid requests is reported by Warning
+// headers of the response.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, e := m.validate(r)
+		if e != nil {
+			writeError(w, e)
+			return
+		}
+		for _, warning := range res.warnings {
+			w.Header().Add("Warning", WarningHeader(warning))
+		}
+		set := make(map[string]string)
+		if res.authorized != res.fields {
+			set[FieldsQueryKey] = strings.Join(res.validator.FieldMask(res.authorized).GetPaths(), ",")
+		}
+		if res.scoped {
+			set[FilterQueryKey] = res.filter
+		}
+		if len(set) > 0 {
+			r = withParams(r, set)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withParams returns a shallow copy of the request having the query
+// parameters set to the values.
+func withParams(r *http.Request, set map[string]string) *http.Request {
+	params := r.URL.Query()
+	for k, v := range set {
+		params.Set(k, v)
+	}
+	u := *r.URL
+	u.RawQuery = params.Encode()
+	r = r.WithContext(r.Context())
+	r.URL = &u
+	r.RequestURI = u.RequestURI()
+	return r
+}
+
+// WarningHeader returns the value of the Warning header reporting the
+// warning, e.g. `299 - "Sorting by 'name' is deprecated"`.
+func WarningHeader(w options.Warning) string {
+	return "299 - " + strconv.Quote(w.String())
+}
+
+// Validate returns a non-nil *Error if collection operators of the request
+// do not pass validation or refer to fields the caller extracted from the
+// request context is not allowed to use.
+func (m *Middleware) Validate(r *http.Request) *Error {
+	_, e := m.ValidateWithWarnings(r)
+	return e
+}
+
+// ValidateWithWarnings validates the request like Validate and returns the
+// warnings about the deprecated fields used by the valid request.
+func (m *Middleware) ValidateWithWarnings(r *http.Request) ([]options.Warning, *Error) {
+	res, e := m.validate(r)
+	return res.warnings, e
+}
+
+// validation is the result of the validation of a request.
+type validation struct {
+	validator  *options.MethodValidator
+	fields     *query.FieldSelection
+	authorized *query.FieldSelection
+	warnings   []options.Warning
+	// scoped reports that filter is the _filter parameter restricted to
+	// the scope of the caller.
+	scoped bool
+	filter string
+}
+
+func (m *Middleware) validate(r *http.Request) (validation, *Error) {
+	registry := m.Registry
+	if registry == nil {
+		registry = options.Registry
+	}
+
+	routes := m.Routes
+	if routes == nil {
+		m.once.Do(func() {
+			m.routes, m.routesErr = RegistryRoutes(registry)
+		})
+		if m.routesErr != nil {
+			return validation{}, &Error{
+				Status:  http.StatusInternalServerError,
+				Code:    "INTERNAL",
+				Message: m.routesErr.Error(),
+			}
+		}
+		routes = m.routes
+	}
+	method, ok := routes.Match(r)
+	if !ok {
+		return validation{}, nil
+	}
+	v, ok := registry.Validator(method)
+	if !ok {
+		return validation{}, nil
+	}
+
+	params := r.URL.Query()
+	invalid := func(param string, err error) *Error {
+		if options.IsPermissionDenied(err) {
+			return &Error{
+				Status:    http.StatusForbidden,
+				Code:      "PERMISSION_DENIED",
+				Message:   err.Error(),
+				Method:    method,
+				Parameter: param,
+				Value:     params.Get(param),
+			}
+		}
+		return &Error{
+			Status:    http.StatusBadRequest,
+			Code:      "INVALID_ARGUMENT",
+			Message:   fmt.Sprintf("Invalid %s parameter: %s", param, err),
+			Method:    method,
+			Parameter: param,
+			Value:     params.Get(param),
+		}
+	}
+
+	var (
+		ctx = r.Context()
+		f   *query.Filtering
+		s   *query.Sorting
+		fs  *query.FieldSelection
+	)
+	if raw := params.Get(FilterQueryKey); raw != "" {
+		var err error
+		if f, err = v.ParseFilteringStringCtx(ctx, raw); err != nil {
+			return validation{}, invalid(FilterQueryKey, err)
+		}
+	}
+
+	if raw := params.Get(SortQueryKey); raw != "" {
+		var err error
+		s, err = query.ParseSorting(raw)
+		if err == nil {
+			err = v.ValidateSortingCtx(ctx, s)
+		}
+		if err != nil {
+			return validation{}, invalid(SortQueryKey, err)
+		}
+	}
+
+	if raw := params.Get(FieldsQueryKey); raw != "" {
+		fs = query.ParseFieldSelection(raw)
+	}
+	authorized, err := v.AuthorizeFieldSelection(ctx, fs)
+	if err != nil {
+		return validation{}, invalid(FieldsQueryKey, err)
+	}
+
+	if err := v.AuthorizeQuery(ctx, f, s, authorized); err != nil {
+		if !options.IsPermissionDenied(err) {
+			return validation{}, &Error{
+				Status:  http.StatusInternalServerError,
+				Code:    "INTERNAL",
+				Message: err.Error(),
+				Method:  method,
+			}
+		}
+		return validation{}, invalid(queryKey(err), err)
+	}
+
+	res := validation{validator: v, fields: fs, authorized: authorized, warnings: v.Warnings(f, s, fs)}
+	if v.Rules().Scope != nil {
+		scoped, err := v.ScopeFiltering(ctx, f)
+		if err == nil {
+			res.filter, err = scopedFilter(scoped, params.Get(FilterQueryKey))
+		}
+		if err != nil {
+			if !options.IsPermissionDenied(err) {
+				return validation{}, &Error{
+					Status:  http.StatusInternalServerError,
+					Code:    "INTERNAL",
+					Message: err.Error(),
+					Method:  method,
+				}
+			}
+			return validation{}, invalid(FilterQueryKey, err)
+		}
+		res.scoped = true
+	}
+	return res, nil
+}
+
+// scopedFilter returns the expression of the filter returned by
+// ScopeFiltering for the raw _filter parameter: the scope condition, which
+// ScopeFiltering conjoins at the root, and the raw expression.
+func scopedFilter(scoped *query.Filtering, raw string) (string, error) {
+	root := scoped.GetRoot()
+	if op, ok := root.(*query.Filtering_Operator); ok && raw != "" {
+		switch l := op.Operator.GetLeft().(type) {
+		case *query.LogicalOperator_LeftStringCondition:
+			root = &query.Filtering_StringCondition{StringCondition: l.LeftStringCondition}
+		case *query.LogicalOperator_LeftNumberCondition:
+			root = &query.Filtering_NumberCondition{NumberCondition: l.LeftNumberCondition}
+		}
+	}
+
+	var cond string
+	switch c := root.(type) {
+	case *query.Filtering_StringCondition:
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(c.StringCondition.GetValue())
+		cond = fmt.Sprintf(`%s=="%s"`, strings.Join(c.StringCondition.GetFieldPath(), "."), value)
+	case *query.Filtering_NumberCondition:
+		value := strconv.FormatFloat(c.NumberCondition.GetValue(), 'f', -1, 64)
+		cond = fmt.Sprintf(`%s==%s`, strings.Join(c.NumberCondition.GetFieldPath(), "."), value)
+	default:
+		return "", fmt.Errorf("Unexpected scoped filter %T", root)
+	}
+	if raw == "" {
+		return cond, nil
+	}
+	return cond + " and (" + raw + ")", nil
+}
+
+// queryKey returns the query parameter the authorization error refers to,
+// if any.
+func queryKey(err error) string {
+	if e, ok := err.(*options.PermissionDeniedError); ok {
+		switch e.Parameter {
+		case "filtering":
+			return FilterQueryKey
+		case "sorting":
+			return SortQueryKey
+		case "field_selection":
+			return FieldsQueryKey
+		}
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, e *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(&ErrorResponse{Error: *e})
+}
@@ -0,0 +1,234 @@
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/example"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/gateway"
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func TestHandler(t *testing.T) {
+	routes := &gateway.Routes{}
+	routes.MustAdd("GET", "/v1/users", "/example.TestService/List")
+	routes.MustAdd("GET", "/v1/users/{id}", "/example.TestService/Read")
+
+	tests := []struct {
+		Method    string
+		URL       string
+		Parameter string
+	}{
+		{"GET", `/v1/users?_filter=first_name=="Sam"&_order_by=weight&_fields=first_name`, ""},
+		{"GET", `/v1/users?_filter=id=="some_id"`, gateway.FilterQueryKey},
+		{"GET", `/v1/users?_filter=first_name==`, gateway.FilterQueryKey},
+		{"GET", `/v1/users?_order_by=on_vacation`, gateway.SortQueryKey},
+		{"GET", `/v1/users?_fields=unknown_field`, gateway.FieldsQueryKey},
+		{"GET", `/v1/users/1?_order_by=weight`, ""},
+		{"GET", `/v1/users/1?_order_by=on_vacation`, gateway.SortQueryKey},
+		{"POST", `/v1/users?_order_by=on_vacation`, ""},
+		{"GET", `/v1/groups?_order_by=on_vacation`, ""},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := gateway.NewHandler(routes, next)
+	admin := options.NewPrincipalContext(context.Background(), &options.Principal{Roles: []string{"admin"}})
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.Method, strings.Replace(test.URL, `"`, "%22", -1), nil).WithContext(admin))
+		if test.Parameter == "" {
+			if w.Code != http.StatusOK {
+				t.Errorf("Unexpected status %d for %s %s: %s", w.Code, test.Method, test.URL, w.Body)
+			}
+			continue
+		}
+
+		var resp gateway.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid response body for %s %s: %s", test.Method, test.URL, err)
+		}
+		if w.Code != http.StatusBadRequest || resp.Error.Parameter != test.Parameter {
+			t.Errorf("Expected error for %s parameter of %s %s, but got %d: %s", test.Parameter, test.Method, test.URL, w.Code, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/users?_order_by=weight", nil))
+	var resp gateway.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid response body: %s", err)
+	}
+	if w.Code != http.StatusForbidden || resp.Error.Code != "PERMISSION_DENIED" || resp.Error.Parameter != gateway.SortQueryKey {
+		t.Errorf("Expected permission error, but got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestRegistryRoutes(t *testing.T) {
+	tests := []struct {
+		Method    string
+		URL       string
+		Parameter string
+	}{
+		{"GET", `/v1/users?_filter=first_name=="Sam"`, ""},
+		{"GET", `/v1/users?_filter=id=="some_id"`, gateway.FilterQueryKey},
+		{"GET", `/v1/users/1?_order_by=on_vacation`, gateway.SortQueryKey},
+		{"POST", `/v1/sites:search?_order_by=unknown_field`, gateway.SortQueryKey},
+		{"GET", `/v1/sites?_order_by=unknown_field`, gateway.SortQueryKey},
+		{"GET", `/v1/groups?_order_by=on_vacation`, ""},
+	}
+
+	h := gateway.NewHandler(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	admin := options.NewPrincipalContext(context.Background(), &options.Principal{Roles: []string{"admin"}})
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.Method, strings.Replace(test.URL, `"`, "%22", -1), nil).WithContext(admin))
+		if test.Parameter == "" {
+			if w.Code != http.StatusOK {
+				t.Errorf("Unexpected status %d for %s %s: %s", w.Code, test.Method, test.URL, w.Body)
+			}
+			continue
+		}
+
+		var resp gateway.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid response body for %s %s: %s", test.Method, test.URL, err)
+		}
+		if w.Code != http.StatusBadRequest || resp.Error.Parameter != test.Parameter {
+			t.Errorf("Expected error for %s parameter of %s %s, but got %d: %s", test.Parameter, test.Method, test.URL, w.Code, w.Body)
+		}
+	}
+}
+
+func TestHandlerWarnings(t *testing.T) {
+	const method = "/example.TestService/ListDevices"
+	registry := options.NewMethodRegistry()
+	registry.Register(method, options.MustRulesValidator(example.ExampleMethodValidators[method].Rules(), nil))
+
+	routes := &gateway.Routes{}
+	routes.MustAdd("GET", "/v1/devices", method)
+	m := &gateway.Middleware{Routes: routes, Registry: registry}
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/devices?_order_by=label&_fields=name", nil))
+	if warning := w.Header()["Warning"]; !reflect.DeepEqual(warning, []string{`299 - "Sorting by 'label' is deprecated"`}) {
+		t.Errorf("Unexpected Warning header %q", warning)
+	}
+}
+
+func TestMiddlewareQueryAuthorizer(t *testing.T) {
+	const method = "/example.TestService/List"
+	tests := []struct {
+		Authorizer options.QueryAuthorizer
+		Status     int
+	}{
+		{options.QueryAuthorizerFunc(func(context.Context, *options.AuthorizationInput) error {
+			return nil
+		}), 0},
+		{options.QueryAuthorizerFunc(func(context.Context, *options.AuthorizationInput) error {
+			return &options.PermissionDeniedError{Reason: "only admins may filter by comment"}
+		}), http.StatusForbidden},
+		{options.QueryAuthorizerFunc(func(context.Context, *options.AuthorizationInput) error {
+			return errors.New("policy store unavailable")
+		}), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		registry := options.NewMethodRegistry()
+		registry.Register(method, options.MustRulesValidator(example.ExampleMethodValidators[method].Rules(), nil))
+		registry.SetQueryAuthorizer(test.Authorizer)
+		routes := &gateway.Routes{}
+		routes.MustAdd("GET", "/v1/users", method)
+		m := &gateway.Middleware{Routes: routes, Registry: registry}
+
+		e := m.Validate(httptest.NewRequest("GET", "/v1/users?_filter=comment==%22x%22", nil))
+		if test.Status == 0 && e != nil || test.Status != 0 && (e == nil || e.Status != test.Status) {
+			t.Errorf("Expected status %d, but got %+v", test.Status, e)
+		}
+	}
+}
+
+func TestHandlerAuthorizedFields(t *testing.T) {
+	const method = "/example.TestService/ListTargets"
+	routes := &gateway.Routes{}
+	routes.MustAdd("GET", "/v1/targets", method)
+	tenant := options.NewScopeContext(context.Background(), "42")
+	admin := options.NewPrincipalContext(tenant, &options.Principal{Roles: []string{"admin"}})
+	support := options.NewPrincipalContext(tenant, &options.Principal{Roles: []string{"support"}})
+
+	tests := []struct {
+		Ctx    context.Context
+		URL    string
+		Fields string
+	}{
+		{support, `/v1/targets?_fields=name,host`, "host.hostname,host.mac,host.serial,name"},
+		{support, `/v1/targets?_fields=name,host.ip`, "name"},
+		{support, `/v1/targets`, "group,host.hostname,host.mac,host.serial,name,net.cidr,tenant_id,user"},
+		{admin, `/v1/targets?_fields=name,host.ip`, "name,host.ip"},
+		{admin, `/v1/targets?_fields=name,host`, "host.hostname,host.ip,host.mac,host.serial,name"},
+		{admin, `/v1/targets`, "group,host.hostname,host.ip,host.mac,host.serial,name,net.cidr,tenant_id,user"},
+	}
+
+	for _, test := range tests {
+		var fields string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fields = r.URL.Query().Get(gateway.FieldsQueryKey)
+		})
+		w := httptest.NewRecorder()
+		gateway.NewHandler(routes, next).ServeHTTP(w, httptest.NewRequest("GET", test.URL, nil).WithContext(test.Ctx))
+		if w.Code != http.StatusOK || fields != test.Fields {
+			t.Errorf("Expected %s fields for %s, but got %d %q: %s", test.Fields, test.URL, w.Code, fields, w.Body)
+		}
+	}
+}
+
+func TestHandlerScope(t *testing.T) {
+	tests := []struct {
+		Scope  string
+		URL    string
+		Filter string
+		Status int
+	}{
+		{"acme", `/v1/sites`, `account_id=="acme"`, http.StatusOK},
+		{"acme", `/v1/sites?_filter=name=="a" or name=="b"`, `account_id=="acme" and (name=="a" or name=="b")`, http.StatusOK},
+		{`ac"me`, `/v1/sites?_filter=name=="a"`, `account_id=="ac\"me" and (name=="a")`, http.StatusOK},
+		{"acme", `/v1/sites?_filter=account_id=="other"`, "", http.StatusForbidden},
+		{"acme", `/v1/sites?_filter=name=="a" or account_id!="acme"`, "", http.StatusForbidden},
+		{"", `/v1/sites?_filter=name=="a"`, "", http.StatusForbidden},
+		{"42", `/v1/targets?_filter=name=="x"`, `tenant_id==42 and (name=="x")`, http.StatusOK},
+		{"acme", `/v1/targets`, "", http.StatusInternalServerError},
+		{"acme", `/v1/users?_filter=first_name=="Sam"`, `first_name=="Sam"`, http.StatusOK},
+	}
+
+	admin := &options.Principal{Roles: []string{"admin"}}
+	escape := strings.NewReplacer(`"`, "%22", " ", "%20")
+	for _, test := range tests {
+		var filter string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			filter = r.URL.Query().Get(gateway.FilterQueryKey)
+		})
+		ctx := options.NewPrincipalContext(options.NewScopeContext(context.Background(), test.Scope), admin)
+		w := httptest.NewRecorder()
+		gateway.NewHandler(nil, next).ServeHTTP(w, httptest.NewRequest("GET", escape.Replace(test.URL), nil).WithContext(ctx))
+		if w.Code != test.Status {
+			t.Errorf("Expected status %d for %s in %q scope, but got %d: %s", test.Status, test.URL, test.Scope, w.Code, w.Body)
+			continue
+		}
+		if test.Status != http.StatusOK {
+			continue
+		}
+		if filter != test.Filter {
+			t.Errorf("Expected %s filter for %s, but got %s", test.Filter, test.URL, filter)
+		}
+		if _, err := query.ParseFiltering(filter); err != nil {
+			t.Errorf("Invalid scoped filter %s: %s", filter, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/httprule"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// Routes maps REST routes to full gRPC method names.
+type Routes struct {
+	routes []route
+}
+
+type route struct {
+	httpMethod string
+	pattern    runtime.Pattern
+	fullMethod string
+}
+
+// Add maps requests with the HTTP method and the path matching the pattern to
+// the gRPC method. Patterns are google.api.http path templates, which are
+// compiled and matched the way grpc-gateway does, e.g. "/v1/users/{id}" or
+// "/v1/{name=shelves/*/books/*}:publish".
+func (rs *Routes) Add(httpMethod, pattern, fullMethod string) error {
+	compiler, err := httprule.Parse(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid route pattern %q: %s", pattern, err)
+	}
+	tmpl := compiler.Compile()
+	p, err := runtime.NewPattern(tmpl.Version, tmpl.OpCodes, tmpl.Pool, tmpl.Verb)
+	if err != nil {
+		return fmt.Errorf("invalid route pattern %q: %s", pattern, err)
+	}
+
+	rs.routes = append(rs.routes, route{httpMethod: strings.ToUpper(httpMethod), pattern: p, fullMethod: fullMethod})
+	return nil
+}
+
+// RegistryRoutes returns the routes of the methods registered in the
+// registry, which defaults to options.Registry, built from the google.api.http
+// bindings generated into their rules, see options.MethodRules.HTTPRules.
+func RegistryRoutes(registry *options.MethodRegistry) (*Routes, error) {
+	if registry == nil {
+		registry = options.Registry
+	}
+
+	rs := &Routes{}
+	for _, method := range registry.Methods() {
+		rules, _ := registry.Rules(method)
+		for _, r := range rules.HTTPRules {
+			if err := rs.Add(r.Method, r.Pattern, method); err != nil {
+				return nil, fmt.Errorf("%s: %s", method, err)
+			}
+		}
+	}
+	return rs, nil
+}
+
+// MustAdd is like Add but panics if the pattern is invalid.
+func (rs *Routes) MustAdd(httpMethod, pattern, fullMethod string) {
+	if err := rs.Add(httpMethod, pattern, fullMethod); err != nil {
+		panic(err)
+	}
+}
+
+// Match returns the gRPC method the request is routed to.
+func (rs *Routes) Match(req *http.Request) (string, bool) {
+	path := req.URL.Path
+	if !strings.HasPrefix(path, "/") {
+		return "", false
+	}
+
+	// The verb is split off the last segment like runtime.ServeMux does.
+	components := strings.Split(path[1:], "/")
+	last := len(components) - 1
+	var verb string
+	if i := strings.LastIndex(components[last], ":"); i == 0 {
+		return "", false
+	} else if i > 0 {
+		components[last], verb = components[last][:i], components[last][i+1:]
+	}
+
+	for _, r := range rs.routes {
+		if r.httpMethod != req.Method {
+			continue
+		}
+		if _, err := r.pattern.Match(components, verb); err == nil {
+			return r.fullMethod, true
+		}
+	}
+	return "", false
+}
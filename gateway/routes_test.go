@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	routes := &Routes{}
+	routes.MustAdd("GET", "/v1/users", "/example.TestService/List")
+	routes.MustAdd("GET", "/v1/users/{id}", "/example.TestService/Read")
+	routes.MustAdd("GET", "/v1/{parent=shelves/*}/books", "/example.Library/ListBooks")
+	routes.MustAdd("GET", "/v1/{name=shelves/*/books/*}", "/example.Library/GetBook")
+	routes.MustAdd("POST", "/v1/{name=shelves/*/books/*}:publish", "/example.Library/PublishBook")
+	routes.MustAdd("GET", "/v1/files/{path=**}", "/example.Files/Get")
+
+	tests := []struct {
+		Method string
+		URL    string
+		Match  string
+	}{
+		{"GET", "/v1/users", "/example.TestService/List"},
+		{"GET", "/v1/users/1", "/example.TestService/Read"},
+		{"GET", "/v1/users/1/friends", ""},
+		{"POST", "/v1/users", ""},
+		{"GET", "/v1/shelves/1/books", "/example.Library/ListBooks"},
+		{"GET", "/v1/shelves/1/books/2", "/example.Library/GetBook"},
+		{"GET", "/v1/shelves/1/books/2/pages", ""},
+		{"GET", "/v1/shelves/1/books/2:publish", ""},
+		{"POST", "/v1/shelves/1/books/2:publish", "/example.Library/PublishBook"},
+		{"POST", "/v1/shelves/1/books/2", ""},
+		{"GET", "/v1/files/a/b/c.txt", "/example.Files/Get"},
+		{"GET", "/v1/:publish", ""},
+	}
+
+	for _, test := range tests {
+		method, ok := routes.Match(httptest.NewRequest(test.Method, test.URL, nil))
+		if ok != (test.Match != "") || method != test.Match {
+			t.Errorf("Expected %s %s to match %q, but got %q", test.Method, test.URL, test.Match, method)
+		}
+	}
+
+	for _, pattern := range []string{"v1/users", "/v1/{id", "/v1/{id=shelves/{name}}"} {
+		if err := routes.Add("GET", pattern, "/example.TestService/List"); err == nil {
+			t.Errorf("Expected error for invalid pattern %q", pattern)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package interceptor validates collection operators of gRPC requests against
+// the rules registered by the generated code.
+package interceptor
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// WarningTrailer is the trailer reporting the use of deprecated fields by
+// valid requests, one value per warning.
+const WarningTrailer = "atlas-query-warning"
+
+// Interceptor validates collection operators of requests against the rules
+// of their methods and checks the permissions of the caller.
+type Interceptor struct {
+	// Registry defaults to options.Registry.
+	Registry *options.MethodRegistry
+}
+
+// UnaryServerInterceptor returns an interceptor validating requests against
+// the rules registered in options.Registry.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return (&Interceptor{}).Unary()
+}
+
+// Unary returns the unary server interceptor. Invalid requests fail with
+// InvalidArgument, the requests referring to fields the caller is not allowed
+// to use with PermissionDenied. The errors carrying a status, e.g. returned by
+// a query authorizer, are returned as is, other errors of the authorizer fail
+// the request with Internal. The field selection of the request is replaced
+// by the selection returned by AuthorizeFieldSelection, so the fields the
+// caller is not allowed to see are dropped and no selection is narrowed to
+// the default selection of the caller. The filter of the requests of the
+// methods having a scope rule is replaced by the filter restricted to the
+// scope of the caller, see MethodValidator.ScopeFiltering. The use of
+// deprecated fields by valid requests is reported by the WarningTrailer
+// trailer.
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		registry := i.Registry
+		if registry == nil {
+			registry = options.Registry
+		}
+		v, ok := registry.Validator(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		f, s, fs := v.Query(req)
+		if err := v.ValidateFilteringCtx(ctx, f); err != nil {
+			return nil, statusError(err, codes.InvalidArgument)
+		}
+		if err := v.ValidateSortingCtx(ctx, s); err != nil {
+			return nil, statusError(err, codes.InvalidArgument)
+		}
+		authorized, err := v.AuthorizeFieldSelection(ctx, fs)
+		if err != nil {
+			return nil, statusError(err, codes.InvalidArgument)
+		}
+		if err := v.AuthorizeQuery(ctx, f, s, authorized); err != nil {
+			return nil, statusError(err, codes.Internal)
+		}
+		if authorized != fs && !setFields(req, authorized) {
+			return nil, status.Errorf(codes.Internal, "Cannot set the field selection of %T", req)
+		}
+		if v.Rules().Scope != nil {
+			scoped, err := v.ScopeFiltering(ctx, f)
+			if err != nil {
+				return nil, statusError(err, codes.Internal)
+			}
+			if !setFilter(req, scoped) {
+				return nil, status.Errorf(codes.Internal, "Cannot set the filter of %T", req)
+			}
+		}
+
+		if warnings := v.Warnings(f, s, fs); len(warnings) > 0 {
+			values := make([]string, len(warnings))
+			for i, w := range warnings {
+				values[i] = w.String()
+			}
+			grpc.SetTrailer(ctx, metadata.MD{WarningTrailer: values})
+		}
+		return handler(ctx, req)
+	}
+}
+
+// setFields sets the field selection of the request with its SetFields method
+// or, for the request messages read by the generated query getters with
+// GetFields, by setting the Fields field.
+func setFields(req interface{}, fs *query.FieldSelection) bool {
+	if r, ok := req.(interface{ SetFields(*query.FieldSelection) }); ok {
+		r.SetFields(fs)
+		return true
+	}
+	return setField(req, "Fields", fs)
+}
+
+// setFilter sets the filter of the request with its SetFilter method or by
+// setting the Filter field, like setFields.
+func setFilter(req interface{}, f *query.Filtering) bool {
+	if r, ok := req.(interface{ SetFilter(*query.Filtering) }); ok {
+		r.SetFilter(f)
+		return true
+	}
+	return setField(req, "Filter", f)
+}
+
+// setField sets the named field of the request message to value, which must
+// be of the type of the field.
+func setField(req interface{}, name string, value interface{}) bool {
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	field := v.Elem().FieldByName(name)
+	if !field.CanSet() || field.Type() != reflect.TypeOf(value) {
+		return false
+	}
+	field.Set(reflect.ValueOf(value))
+	return true
+}
+
+// statusError returns the error as is if it carries a status, otherwise the
+// status error having PermissionDenied for permission errors and the code
+// for other errors.
+func statusError(err error, code codes.Code) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if options.IsPermissionDenied(err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(code, err.Error())
+}
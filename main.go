@@ -1,13 +1,29 @@
 package main
 
 import (
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/vanity/command"
 	"github.com/infobloxopen/protoc-gen-atlas-query-validate/plugin"
 )
 
+// supportedFeaturesFieldNumber is the number of the supported_features field
+// of CodeGeneratorResponse, which is newer than the descriptor the generator is
+// built with, and featureProto3Optional tells protoc that proto3 optional
+// fields are supported.
+const (
+	supportedFeaturesFieldNumber = 2
+	featureProto3Optional        = 1
+)
+
 func main() {
 	plugin := &plugin.QueryValidatePlugin{}
 	response := command.GeneratePlugin(command.Read(), plugin, ".pb.atlas.query.validate.go")
 	plugin.CleanFiles(response)
+
+	features := proto.NewBuffer(nil)
+	features.EncodeVarint(uint64(supportedFeaturesFieldNumber<<3 | proto.WireVarint))
+	features.EncodeVarint(featureProto3Optional)
+	response.XXX_unrecognized = append(response.XXX_unrecognized, features.Bytes()...)
+
 	command.Write(response)
 }
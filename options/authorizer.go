@@ -0,0 +1,183 @@
+package options
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// QueryAuthorizer decides whether the caller may run a query, e.g. by
+// evaluating a policy. It is consulted by the context-aware validation methods
+// once the query passes validation and the role checks. A query is denied by
+// returning *PermissionDeniedError, any other error fails the request as is.
+type QueryAuthorizer interface {
+	AuthorizeQuery(ctx context.Context, input *AuthorizationInput) error
+}
+
+// QueryAuthorizerFunc adapts a function to QueryAuthorizer.
+type QueryAuthorizerFunc func(ctx context.Context, input *AuthorizationInput) error
+
+// AuthorizeQuery calls f(ctx, input).
+func (f QueryAuthorizerFunc) AuthorizeQuery(ctx context.Context, input *AuthorizationInput) error {
+	return f(ctx, input)
+}
+
+// AuthorizationInput is the input of a QueryAuthorizer.
+type AuthorizationInput struct {
+	// Method is the full name of the method, e.g. "/example.TestService/List".
+	Method string `json:"method"`
+	// Principal is nil for an anonymous caller.
+	Principal *Principal  `json:"principal"`
+	Query     *QueryInput `json:"query"`
+}
+
+// Document returns the input as a JSON document made of maps, slices, strings,
+// float64 and bool values, which is the form the policies are evaluated on.
+// The principal of an anonymous caller is rendered as a principal having an
+// empty subject and no roles.
+func (in *AuthorizationInput) Document() (map[string]interface{}, error) {
+	normalized := *in
+	principal := Principal{Roles: []string{}}
+	if in.Principal != nil {
+		principal = *in.Principal
+		if principal.Roles == nil {
+			principal.Roles = []string{}
+		}
+	}
+	normalized.Principal = &principal
+
+	data, err := json.Marshal(&normalized)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// QueryInput is the normalized form of the collection operators of a request.
+// The field lists are sorted and never nil, so that policies do not have to
+// check their presence.
+type QueryInput struct {
+	Filter *FilterNode `json:"filter"`
+	// FilterFields are the paths of the fields the filter refers to. The
+	// validators resolve the paths going through recursive fields into the
+	// paths of the fields they stand for, e.g. "user_friend.speciality" into
+	// "speciality", as they do for SortFields and Fields.
+	FilterFields []string    `json:"filter_fields"`
+	Sort         []SortInput `json:"sort"`
+	// SortFields are the paths of the fields the query is sorted by.
+	SortFields []string `json:"sort_fields"`
+	// Fields are the paths of the selected fields, a selected field stands
+	// for its nested fields.
+	Fields []string `json:"fields"`
+}
+
+// FilterNode is a node of a normalized filtering expression. Nested logical
+// operators of the same type are merged, so "a and b and c" is a single
+// node having three operands.
+type FilterNode struct {
+	// Op is "and" or "or" for a logical operator, "null" for a null check,
+	// otherwise the name of the QueryValidate_FilterOperator of the
+	// condition in lower case, e.g. "eq" or "in".
+	Op     string `json:"op"`
+	Negate bool   `json:"negate,omitempty"`
+	// Field is the path of the field of a condition.
+	Field string `json:"field,omitempty"`
+	// Value is a string, a float64 or a slice of them.
+	Value    interface{}   `json:"value,omitempty"`
+	Operands []*FilterNode `json:"operands,omitempty"`
+}
+
+// SortInput is a sorting criterion.
+type SortInput struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// NewQueryInput normalizes the collection operators, any of which may be nil.
+func NewQueryInput(f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) *QueryInput {
+	in := &QueryInput{
+		Filter:       newFilterNode(filteringRoot(f)),
+		FilterFields: []string{},
+		Sort:         []SortInput{},
+		SortFields:   []string{},
+		Fields:       flattenFieldSelection(fs.GetFields()),
+	}
+
+	seen := make(map[string]struct{})
+	walkFiltering(f, func(path []string, _ interface{}) error {
+		field := strings.Join(path, ".")
+		if _, ok := seen[field]; !ok {
+			seen[field] = struct{}{}
+			in.FilterFields = append(in.FilterFields, field)
+		}
+		return nil
+	})
+	sort.Strings(in.FilterFields)
+
+	for _, c := range s.GetCriterias() {
+		in.Sort = append(in.Sort, SortInput{Field: c.GetTag(), Desc: c.GetOrder() == query.SortCriteria_DESC})
+		in.SortFields = append(in.SortFields, c.GetTag())
+	}
+	sort.Strings(in.SortFields)
+
+	if in.Fields == nil {
+		in.Fields = []string{}
+	}
+	sort.Strings(in.Fields)
+	return in
+}
+
+func newFilterNode(node interface{}) *FilterNode {
+	switch c := node.(type) {
+	case *query.LogicalOperator:
+		res := &FilterNode{Op: strings.ToLower(c.GetType().String()), Negate: c.GetIsNegative()}
+		left, right := logicalOperands(c)
+		for _, operand := range []*FilterNode{newFilterNode(left), newFilterNode(right)} {
+			if operand == nil {
+				continue
+			}
+			if operand.Op == res.Op && !operand.Negate {
+				res.Operands = append(res.Operands, operand.Operands...)
+			} else {
+				res.Operands = append(res.Operands, operand)
+			}
+		}
+		return res
+
+	case *query.StringCondition:
+		op, _ := StringConditionOperator(c.GetType())
+		return newConditionNode(op, c.GetFieldPath(), c.GetIsNegative(), c.GetValue())
+
+	case *query.NumberCondition:
+		op, _ := NumberConditionOperator(c.GetType())
+		return newConditionNode(op, c.GetFieldPath(), c.GetIsNegative(), c.GetValue())
+
+	case *query.NullCondition:
+		return &FilterNode{Op: "null", Negate: c.GetIsNegative(), Field: strings.Join(c.GetFieldPath(), ".")}
+
+	case *query.StringArrayCondition:
+		op, _ := StringArrayConditionOperator(c.GetType())
+		return newConditionNode(op, c.GetFieldPath(), c.GetIsNegative(), c.GetValues())
+
+	case *query.NumberArrayCondition:
+		op, _ := NumberArrayConditionOperator(c.GetType())
+		return newConditionNode(op, c.GetFieldPath(), c.GetIsNegative(), c.GetValues())
+	}
+	return nil
+}
+
+func newConditionNode(op QueryValidate_FilterOperator, path []string, negate bool, value interface{}) *FilterNode {
+	return &FilterNode{
+		Op:     strings.ToLower(op.String()),
+		Negate: negate,
+		Field:  strings.Join(path, "."),
+		Value:  value,
+	}
+}
@@ -0,0 +1,56 @@
+package options
+
+import (
+	"strings"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// ColumnPath returns the path of the field with the last segment replaced by
+// the column name of the field if it differs from the name of the field, e.g.
+// "owner.full_name" for "owner.name". Paths below map keys are not mapped.
+func (v *MethodValidator) ColumnPath(path []string) []string {
+	column, ok := v.rules.Columns[strings.Join(path, ".")]
+	if !ok {
+		return path
+	}
+	res := make([]string, len(path))
+	copy(res, path)
+	res[len(res)-1] = column
+	return res
+}
+
+// MapColumns replaces in place the field paths of the validated f and s with
+// the column paths, see ColumnPath. It is meant for the storage layers taking
+// the column names from the paths as is, e.g. a hand-written SQL builder, and
+// is never called by the generated code, the interceptor or the gateway
+// middleware. Do not use it with the gorm layer of atlas-app-toolkit, which
+// resolves the field paths to the columns of the ORM models by itself, the
+// custom column names included, and fails on the mapped paths. Either of f
+// and s may be nil.
+func (v *MethodValidator) MapColumns(f *query.Filtering, s *query.Sorting) {
+	if len(v.rules.Columns) == 0 {
+		return
+	}
+
+	walkFiltering(f, func(path []string, c interface{}) error {
+		mapped := v.ColumnPath(path)
+		switch c := c.(type) {
+		case *query.StringCondition:
+			c.FieldPath = mapped
+		case *query.NumberCondition:
+			c.FieldPath = mapped
+		case *query.NullCondition:
+			c.FieldPath = mapped
+		case *query.StringArrayCondition:
+			c.FieldPath = mapped
+		case *query.NumberArrayCondition:
+			c.FieldPath = mapped
+		}
+		return nil
+	})
+
+	for _, c := range s.GetCriterias() {
+		c.Tag = strings.Join(v.ColumnPath(strings.Split(c.GetTag(), ".")), ".")
+	}
+}
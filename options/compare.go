@@ -0,0 +1,422 @@
+package options
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RuleChange describes a difference between two versions of the rules of a
+// method. Breaking changes make requests accepted by the base rules invalid.
+type RuleChange struct {
+	Method    string
+	Parameter string
+	Field     string
+	Breaking  bool
+	Message   string
+}
+
+func (c RuleChange) String() string {
+	var b strings.Builder
+	if c.Breaking {
+		b.WriteString("BREAKING ")
+	}
+	b.WriteString(c.Method)
+	if c.Parameter != "" {
+		b.WriteString(" " + c.Parameter)
+	}
+	if c.Field != "" {
+		b.WriteString(" '" + c.Field + "'")
+	}
+	b.WriteString(": " + c.Message)
+	return b.String()
+}
+
+const (
+	filteringParameter      = "filtering"
+	sortingParameter        = "sorting"
+	fieldSelectionParameter = "field_selection"
+)
+
+// CompareRules reports differences between the base and head rules of
+// methods keyed by the full method name. Changes are sorted by method,
+// parameter and field.
+func CompareRules(base, head map[string]MethodRules) []RuleChange {
+	var changes []RuleChange
+
+	for method, b := range base {
+		h, ok := head[method]
+		if !ok {
+			changes = append(changes, RuleChange{Method: method, Breaking: true, Message: "Method rules removed"})
+			continue
+		}
+		changes = append(changes, compareFiltering(method, b.Filtering, h.Filtering, h.Recursions.Filtering)...)
+		changes = append(changes, compareFields(method, sortingParameter, b.Sorting, h.Sorting, h.Recursions.Sorting)...)
+		changes = append(changes, compareFields(method, fieldSelectionParameter, b.FieldSelection, h.FieldSelection, h.Recursions.FieldSelection)...)
+		if b.Filtering != nil && h.Filtering != nil {
+			changes = append(changes, compareRecursions(method, filteringParameter, b.Recursions.Filtering, h.Recursions.Filtering)...)
+			changes = append(changes, comparePermissions(method, filteringParameter, b.Permissions.Filtering, h.Permissions.Filtering)...)
+			changes = append(changes, compareScope(method, b.Scope, h.Scope)...)
+		}
+		if b.Sorting != nil && h.Sorting != nil {
+			changes = append(changes, compareRecursions(method, sortingParameter, b.Recursions.Sorting, h.Recursions.Sorting)...)
+			changes = append(changes, comparePermissions(method, sortingParameter, b.Permissions.Sorting, h.Permissions.Sorting)...)
+		}
+		if b.FieldSelection != nil && h.FieldSelection != nil {
+			changes = append(changes, compareRequireParent(method, b.FieldSelectionRequireParent, h.FieldSelectionRequireParent)...)
+			changes = append(changes, compareSensitive(method, b.Sensitive, h.Sensitive)...)
+			changes = append(changes, compareRecursions(method, fieldSelectionParameter, b.Recursions.FieldSelection, h.Recursions.FieldSelection)...)
+			changes = append(changes, comparePermissions(method, fieldSelectionParameter, b.Permissions.FieldSelection, h.Permissions.FieldSelection)...)
+			switch {
+			case b.DropUnauthorizedFields && !h.DropUnauthorizedFields:
+				changes = append(changes, RuleChange{method, fieldSelectionParameter, "", true, "Unauthorized fields rejected"})
+			case !b.DropUnauthorizedFields && h.DropUnauthorizedFields:
+				changes = append(changes, RuleChange{method, fieldSelectionParameter, "", false, "Unauthorized fields dropped"})
+			}
+		}
+	}
+	for method := range head {
+		if _, ok := base[method]; !ok {
+			changes = append(changes, RuleChange{Method: method, Message: "Method rules added"})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Method != changes[j].Method {
+			return changes[i].Method < changes[j].Method
+		}
+		if changes[i].Parameter != changes[j].Parameter {
+			return changes[i].Parameter < changes[j].Parameter
+		}
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// compareScope compares the scope rules of a method. Scoping a method is
+// breaking as the filters referring to the scope field are rejected.
+func compareScope(method string, base, head *ScopeRule) []RuleChange {
+	switch {
+	case base == nil && head == nil:
+		return nil
+	case base == nil:
+		return []RuleChange{{method, filteringParameter, head.Field, true, "Filtering scoped by the field"}}
+	case head == nil:
+		return []RuleChange{{method, filteringParameter, base.Field, false, "Filtering no longer scoped by the field"}}
+	case base.Field != head.Field:
+		return []RuleChange{
+			{method, filteringParameter, base.Field, false, "Filtering no longer scoped by the field"},
+			{method, filteringParameter, head.Field, true, "Filtering scoped by the field"},
+		}
+	case base.ValueType != head.ValueType:
+		return []RuleChange{{method, filteringParameter, head.Field, true, fmt.Sprintf("Scope type changed from %s to %s", base.ValueType, head.ValueType)}}
+	}
+	return nil
+}
+
+// compareFiltering compares filtering rules of a method. Fields missing in
+// head are looked up through the recursive fields of head as well. Besides
+// the operators and the value type, a new or changed key pattern, a tighter
+// literal constraint and the loss of nullability are breaking.
+func compareFiltering(method string, base, head map[string]FilteringOption, headRecursions map[string]Recursion) []RuleChange {
+	switch {
+	case base == nil && head == nil:
+		return nil
+	case head == nil:
+		return []RuleChange{{Method: method, Parameter: filteringParameter, Breaking: true, Message: "Filtering removed"}}
+	case base == nil:
+		return []RuleChange{{Method: method, Parameter: filteringParameter, Message: "Filtering added"}}
+	}
+
+	var changes []RuleChange
+	for field, b := range base {
+		bOps := b.AllowedOperators()
+		h, ok := head[field]
+		if !ok {
+			if resolved, err := resolveRecursion(headRecursions, strings.Split(field, ".")); err == nil {
+				h, ok = head[strings.Join(resolved, ".")]
+			}
+		}
+		if !ok {
+			if bOps != 0 {
+				changes = append(changes, RuleChange{method, filteringParameter, field, true, "Filterable field removed"})
+			}
+			continue
+		}
+
+		hOps := h.AllowedOperators()
+		if b.ValueType != h.ValueType && bOps != 0 {
+			changes = append(changes, RuleChange{method, filteringParameter, field, true,
+				fmt.Sprintf("Value type changed from %s to %s", b.ValueType, h.ValueType)})
+		}
+		if denied := bOps &^ hOps; denied != 0 {
+			changes = append(changes, RuleChange{method, filteringParameter, field, true,
+				fmt.Sprintf("Operators denied: %s", joinOperators(denied.Operators()))})
+		}
+		if allowed := hOps &^ bOps; allowed != 0 {
+			changes = append(changes, RuleChange{method, filteringParameter, field, false,
+				fmt.Sprintf("Operators allowed: %s", joinOperators(allowed.Operators()))})
+		}
+		if bOps == 0 || hOps == 0 {
+			continue
+		}
+		switch {
+		case b.KeyPattern == h.KeyPattern:
+		case b.KeyPattern == "":
+			changes = append(changes, RuleChange{method, filteringParameter, field, true, fmt.Sprintf("Key pattern added: %s", h.KeyPattern)})
+		case h.KeyPattern == "":
+			changes = append(changes, RuleChange{method, filteringParameter, field, false, "Key pattern removed"})
+		default:
+			changes = append(changes, RuleChange{method, filteringParameter, field, true,
+				fmt.Sprintf("Key pattern changed from %s to %s", b.KeyPattern, h.KeyPattern)})
+		}
+		switch {
+		case reflect.DeepEqual(b.Literal, h.Literal):
+		case b.Literal == nil:
+			changes = append(changes, RuleChange{method, filteringParameter, field, true, "Literal constraint added"})
+		case h.Literal == nil:
+			changes = append(changes, RuleChange{method, filteringParameter, field, false, "Literal constraint removed"})
+		case relaxesLiteral(b.Literal, h.Literal):
+			changes = append(changes, RuleChange{method, filteringParameter, field, false, "Literal constraint relaxed"})
+		default:
+			changes = append(changes, RuleChange{method, filteringParameter, field, true, "Literal constraint tightened"})
+		}
+		switch {
+		case b.Nullable && !h.Nullable:
+			changes = append(changes, RuleChange{method, filteringParameter, field, true, "Field no longer nullable"})
+		case !b.Nullable && h.Nullable:
+			changes = append(changes, RuleChange{method, filteringParameter, field, false, "Field made nullable"})
+		}
+	}
+	for field, h := range head {
+		if _, ok := base[field]; !ok && h.AllowedOperators() != 0 {
+			changes = append(changes, RuleChange{method, filteringParameter, field, false, "Filterable field added"})
+		}
+	}
+	return changes
+}
+
+// relaxesLiteral reports whether every literal accepted by base is accepted
+// by head. Constraints which can't be compared, e.g. different patterns, are
+// not considered relaxed.
+func relaxesLiteral(base, head *LiteralConstraint) bool {
+	switch {
+	case head.Format != "" && head.Format != base.Format:
+		return false
+	case head.Pattern != "" && head.Pattern != base.Pattern:
+		return false
+	case head.MinLen > base.MinLen:
+		return false
+	case head.MaxLen != 0 && (base.MaxLen == 0 || head.MaxLen < base.MaxLen):
+		return false
+	case len(head.In) > 0 && (len(base.In) == 0 || !containsAll(head.In, base.In)):
+		return false
+	case !containsAll(base.NotIn, head.NotIn):
+		return false
+	case len(head.NumberIn) > 0 && (len(base.NumberIn) == 0 || !containsAllNumbers(head.NumberIn, base.NumberIn)):
+		return false
+	case !containsAllNumbers(base.NumberNotIn, head.NumberNotIn):
+		return false
+	case base.IgnoreEmpty && !head.IgnoreEmpty:
+		return false
+	}
+	return relaxesBound(base.Min, head.Min, base.ExclusiveMin, head.ExclusiveMin, false) &&
+		relaxesBound(base.Max, head.Max, base.ExclusiveMax, head.ExclusiveMax, true)
+}
+
+// relaxesBound reports whether the head bound accepts every number the base
+// bound does, upper tells the maximum from the minimum.
+func relaxesBound(base, head *float64, baseExclusive, headExclusive, upper bool) bool {
+	switch {
+	case head == nil:
+		return true
+	case base == nil:
+		return false
+	case *head == *base:
+		return baseExclusive || !headExclusive
+	case upper:
+		return *head > *base
+	}
+	return *head < *base
+}
+
+// containsAll reports whether a contains all the strings of b.
+func containsAll(a, b []string) bool {
+	return len(subtractRoles(b, a)) == 0
+}
+
+// containsAllNumbers reports whether a contains all the numbers of b.
+func containsAllNumbers(a, b []float64) bool {
+	for _, n := range b {
+		found := false
+		for _, o := range a {
+			if n == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// compareSensitive reports the fields made sensitive, which are no longer
+// selectable, as breaking changes.
+func compareSensitive(method string, base, head []string) []RuleChange {
+	var changes []RuleChange
+	for _, f := range subtractRoles(head, base) {
+		changes = append(changes, RuleChange{method, fieldSelectionParameter, f, true, "Field made sensitive"})
+	}
+	for _, f := range subtractRoles(base, head) {
+		changes = append(changes, RuleChange{method, fieldSelectionParameter, f, false, "Field no longer sensitive"})
+	}
+	return changes
+}
+
+func compareFields(method, parameter string, base, head []string, headRecursions map[string]Recursion) []RuleChange {
+	switch {
+	case base == nil && head == nil:
+		return nil
+	case head == nil:
+		return []RuleChange{{Method: method, Parameter: parameter, Breaking: true, Message: "Parameter removed"}}
+	case base == nil:
+		return []RuleChange{{Method: method, Parameter: parameter, Message: "Parameter added"}}
+	}
+
+	inBase := make(map[string]struct{}, len(base))
+	for _, f := range base {
+		inBase[f] = struct{}{}
+	}
+	inHead := make(map[string]struct{}, len(head))
+	for _, f := range head {
+		inHead[f] = struct{}{}
+	}
+
+	var changes []RuleChange
+	for f := range inBase {
+		if _, ok := inHead[f]; ok {
+			continue
+		}
+		if resolved, err := resolveRecursion(headRecursions, strings.Split(f, ".")); err == nil {
+			if _, ok := inHead[strings.Join(resolved, ".")]; ok {
+				continue
+			}
+		}
+		changes = append(changes, RuleChange{method, parameter, f, true, "Field removed"})
+	}
+	for f := range inHead {
+		if _, ok := inBase[f]; !ok {
+			changes = append(changes, RuleChange{method, parameter, f, false, "Field added"})
+		}
+	}
+	return changes
+}
+
+func compareRequireParent(method string, base, head []string) []RuleChange {
+	inBase := make(map[string]struct{}, len(base))
+	for _, f := range base {
+		inBase[f] = struct{}{}
+	}
+	inHead := make(map[string]struct{}, len(head))
+	for _, f := range head {
+		inHead[f] = struct{}{}
+	}
+
+	var changes []RuleChange
+	for f := range inHead {
+		if _, ok := inBase[f]; !ok {
+			changes = append(changes, RuleChange{method, fieldSelectionParameter, f, true, "Field can only be selected with its parent"})
+		}
+	}
+	for f := range inBase {
+		if _, ok := inHead[f]; !ok {
+			changes = append(changes, RuleChange{method, fieldSelectionParameter, f, false, "Field can be selected without its parent"})
+		}
+	}
+	return changes
+}
+
+func compareRecursions(method, parameter string, base, head map[string]Recursion) []RuleChange {
+	var changes []RuleChange
+	for f, b := range base {
+		h, ok := head[f]
+		switch {
+		case !ok:
+			changes = append(changes, RuleChange{method, parameter, f, true, "Recursive field removed"})
+		case h.MaxDepth != 0 && (b.MaxDepth == 0 || h.MaxDepth < b.MaxDepth):
+			changes = append(changes, RuleChange{method, parameter, f, true, fmt.Sprintf("Recursion depth limited to %d", h.MaxDepth)})
+		case h.MaxDepth != b.MaxDepth:
+			changes = append(changes, RuleChange{method, parameter, f, false, "Recursion depth limit raised"})
+		}
+	}
+	for f := range head {
+		if _, ok := base[f]; !ok {
+			changes = append(changes, RuleChange{method, parameter, f, false, "Recursive field added"})
+		}
+	}
+	return changes
+}
+
+// comparePermissions reports roles no longer granting the use of a field as
+// breaking changes.
+func comparePermissions(method, parameter string, base, head map[string][]string) []RuleChange {
+	var changes []RuleChange
+	for f, h := range head {
+		b, ok := base[f]
+		if !ok {
+			changes = append(changes, RuleChange{method, parameter, f, true, fmt.Sprintf("Roles required: %s", strings.Join(h, ", "))})
+			continue
+		}
+		if removed := subtractRoles(b, h); len(removed) > 0 {
+			changes = append(changes, RuleChange{method, parameter, f, true, fmt.Sprintf("Roles removed: %s", strings.Join(removed, ", "))})
+		}
+		if added := subtractRoles(h, b); len(added) > 0 {
+			changes = append(changes, RuleChange{method, parameter, f, false, fmt.Sprintf("Roles added: %s", strings.Join(added, ", "))})
+		}
+	}
+	for f := range base {
+		if _, ok := head[f]; !ok {
+			changes = append(changes, RuleChange{method, parameter, f, false, "Roles no longer required"})
+		}
+	}
+	return changes
+}
+
+// subtractRoles returns the roles of a missing in b.
+func subtractRoles(a, b []string) []string {
+	var res []string
+	for _, r := range a {
+		found := false
+		for _, o := range b {
+			if r == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+func joinOperators(ops []QueryValidate_FilterOperator) string {
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// HasBreakingChanges reports whether any of the changes is breaking.
+func HasBreakingChanges(changes []RuleChange) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// Warning reports the use of a deprecated field by a valid query.
+type Warning struct {
+	// Parameter is the collection operator using the field: "filtering",
+	// "sorting" or "field_selection".
+	Parameter string
+	// Field is the path used by the query.
+	Field string
+	// Deprecated is the path of the deprecated field, which is Field or a
+	// parent of it.
+	Deprecated string
+}
+
+func (w Warning) String() string {
+	var use string
+	switch w.Parameter {
+	case filteringParameter:
+		use = fmt.Sprintf("Filtering by '%s'", w.Field)
+	case sortingParameter:
+		use = fmt.Sprintf("Sorting by '%s'", w.Field)
+	default:
+		use = fmt.Sprintf("Selecting '%s'", w.Field)
+	}
+	if w.Deprecated != w.Field {
+		return fmt.Sprintf("%s is deprecated as '%s' is deprecated", use, w.Deprecated)
+	}
+	return use + " is deprecated"
+}
+
+// deprecation counts the uses of the deprecated fields of a method.
+type deprecation map[string]*uint64
+
+func newDeprecation(fields []string) deprecation {
+	if len(fields) == 0 {
+		return nil
+	}
+	d := make(deprecation, len(fields))
+	for _, f := range fields {
+		d[f] = new(uint64)
+	}
+	return d
+}
+
+// field returns the deprecated field the path is or is nested in.
+func (d deprecation) field(path []string) (string, bool) {
+	for i := 1; i <= len(path); i++ {
+		prefix := strings.Join(path[:i], ".")
+		if _, ok := d[prefix]; ok {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// resolvedField returns the deprecated field the path resolved through the
+// recursive fields is or is nested in and the path of the field as used by
+// the query, which is the prefix of the path standing for it if there is one.
+func (d deprecation) resolvedField(recursions map[string]Recursion, path []string) (string, string, bool) {
+	if f, ok := d.field(path); ok {
+		return f, f, true
+	}
+	resolved, err := resolveRecursion(recursions, path)
+	if err != nil {
+		return "", "", false
+	}
+	f, ok := d.field(resolved)
+	if !ok {
+		return "", "", false
+	}
+	// The resolved path shares the segments below the last recursive field
+	// with the path.
+	k := strings.Count(f, ".") + 1
+	if n := len(path) - len(resolved) + k; n > 0 && path[n-1] == resolved[k-1] {
+		return f, strings.Join(path[:n], "."), true
+	}
+	return f, f, true
+}
+
+// Warnings returns the warnings about the deprecated fields used by the query,
+// any part of which may be nil, in the order of the collection operators. The
+// paths going through recursive fields are matched as the paths they resolve
+// to. The query is expected to be valid. The uses are counted, see
+// DeprecationCounts.
+func (v *MethodValidator) Warnings(f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) []Warning {
+	if v.deprecation == nil {
+		return nil
+	}
+
+	var res []Warning
+	add := func(parameter string, recursions map[string]Recursion, field string) {
+		if deprecated, used, ok := v.deprecation.resolvedField(recursions, strings.Split(field, ".")); ok {
+			atomic.AddUint64(v.deprecation[deprecated], 1)
+			res = append(res, Warning{Parameter: parameter, Field: field, Deprecated: used})
+		}
+	}
+
+	seen := make(map[string]struct{})
+	walkFiltering(f, func(path []string, _ interface{}) error {
+		field := strings.Join(path, ".")
+		if _, ok := seen[field]; !ok {
+			seen[field] = struct{}{}
+			add(filteringParameter, v.rules.Recursions.Filtering, field)
+		}
+		return nil
+	})
+
+	for _, c := range s.GetCriterias() {
+		add(sortingParameter, v.rules.Recursions.Sorting, c.GetTag())
+	}
+
+	fields := flattenFieldSelection(fs.GetFields())
+	sort.Strings(fields)
+	for _, field := range fields {
+		add(fieldSelectionParameter, v.rules.Recursions.FieldSelection, field)
+	}
+	return res
+}
+
+// ValidateWithWarnings validates the request like Validate and returns the
+// warnings about the deprecated fields used by the valid request.
+func (v *MethodValidator) ValidateWithWarnings(req interface{}) ([]Warning, error) {
+	if err := v.Validate(req); err != nil || v.getQuery == nil {
+		return nil, err
+	}
+	return v.Warnings(v.getQuery(req)), nil
+}
+
+// ValidateCtxWithWarnings validates the request like ValidateCtx and returns
+// the warnings about the deprecated fields used by the valid request.
+func (v *MethodValidator) ValidateCtxWithWarnings(ctx context.Context, req interface{}) ([]Warning, error) {
+	if err := v.ValidateCtx(ctx, req); err != nil || v.getQuery == nil {
+		return nil, err
+	}
+	return v.Warnings(v.getQuery(req)), nil
+}
+
+// DeprecationCounts returns the number of the uses of each deprecated field
+// reported by Warnings keyed by the path of the field, e.g. to export them as
+// metrics.
+func (v *MethodValidator) DeprecationCounts() map[string]uint64 {
+	res := make(map[string]uint64, len(v.deprecation))
+	for f, n := range v.deprecation {
+		res[f] = atomic.LoadUint64(n)
+	}
+	return res
+}
+
+// ValidateRequestCtxWithWarnings validates the request of the method like
+// ValidateRequestCtx and returns the warnings about the deprecated fields used
+// by the valid request.
+func (r *MethodRegistry) ValidateRequestCtxWithWarnings(ctx context.Context, method string, req interface{}) ([]Warning, error) {
+	v, ok := r.Validator(method)
+	if !ok {
+		return nil, nil
+	}
+	return v.ValidateCtxWithWarnings(ctx, req)
+}
+
+// DeprecationCounts returns the counts of the uses of the deprecated fields of
+// the registered methods having some keyed by the method name, see
+// MethodValidator.DeprecationCounts.
+func (r *MethodRegistry) DeprecationCounts() map[string]map[string]uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make(map[string]map[string]uint64)
+	for method, v := range r.validators {
+		if v.deprecation != nil {
+			res[method] = v.DeprecationCounts()
+		}
+	}
+	return res
+}
@@ -0,0 +1,320 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// ExpressionError describes a failure to parse or validate a filtering
+// expression. Offset is the position of the offending character in the
+// expression counted in characters, or -1 if it is unknown.
+type ExpressionError struct {
+	Expr   string
+	Offset int
+	Err    error
+}
+
+func (e *ExpressionError) Error() string {
+	msg := e.Err.Error()
+	// The errors of the toolkit parser may already end with the position,
+	// e.g. "Unexpected token value at 14".
+	if e.Offset < 0 || strings.HasSuffix(msg, fmt.Sprintf(" at %d", e.Offset)) || strings.HasSuffix(msg, fmt.Sprintf(" in position %d", e.Offset)) {
+		return msg
+	}
+	return fmt.Sprintf("%s at position %d", msg, e.Offset)
+}
+
+// ValidateFilteringString parses the filtering expression and validates it
+// against messageInfo. Parse and validation errors are reported as *ExpressionError.
+func ValidateFilteringString(expr string, messageInfo map[string]FilteringOption) error {
+	_, err := validateFilteringString(expr, messageConditionValidator(messageInfo), nil)
+	return err
+}
+
+// ParseFilteringString parses the filtering expression reporting syntax errors
+// as *ExpressionError.
+func ParseFilteringString(expr string) (*query.Filtering, error) {
+	f, err := query.ParseFiltering(expr)
+	if err != nil {
+		offset := locateSyntaxError(expr)
+		if e, ok := err.(*query.UnexpectedSymbolError); ok {
+			offset = e.Pos
+		}
+		return nil, &ExpressionError{Expr: expr, Offset: offset, Err: err}
+	}
+	return f, nil
+}
+
+// validateFilteringString calls fn for every condition of the parsed
+// expression and then check, if not nil, for the whole filtering tree. It
+// returns the parsed filtering.
+func validateFilteringString(expr string, fn func(path []string, c interface{}, negated bool) error, check func(*query.Filtering) error) (*query.Filtering, error) {
+	f, err := ParseFilteringString(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, _ := scanExpression(expr)
+	var fields []exprToken
+	for _, t := range tokens {
+		if t.kind == exprField {
+			fields = append(fields, t)
+		}
+	}
+	conditionOffset := func(n int) int {
+		if n < len(fields) {
+			return fields[n].offset
+		}
+		return -1
+	}
+
+	var n int
+	err = walkConditions(f, func(path []string, c interface{}, negated bool) error {
+		if err := fn(path, c, negated); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, &ExpressionError{Expr: expr, Offset: conditionOffset(n), Err: err}
+	}
+	if check != nil {
+		if err := check(f); err != nil {
+			offset := -1
+			if e, ok := err.(*oneofError); ok {
+				offset = conditionOffset(e.cond)
+			}
+			return nil, &ExpressionError{Expr: expr, Offset: offset, Err: err}
+		}
+	}
+	return f, nil
+}
+
+type exprTokenKind int
+
+const (
+	exprEOF exprTokenKind = iota
+	exprField
+	exprString
+	exprNumber
+	exprNull
+	exprAnd
+	exprOr
+	exprNot
+	exprIn
+	exprComparison
+	exprLparen
+	exprRparen
+	exprLbracket
+	exprRbracket
+	exprComma
+)
+
+type exprToken struct {
+	kind   exprTokenKind
+	offset int
+	text   string
+}
+
+var exprKeywords = map[string]exprTokenKind{
+	"and":     exprAnd,
+	"or":      exprOr,
+	"not":     exprNot,
+	"in":      exprIn,
+	"null":    exprNull,
+	"eq":      exprComparison,
+	"ne":      exprComparison,
+	"match":   exprComparison,
+	"nomatch": exprComparison,
+	"gt":      exprComparison,
+	"ge":      exprComparison,
+	"lt":      exprComparison,
+	"le":      exprComparison,
+	"ieq":     exprComparison,
+}
+
+// scanExpression splits the filtering expression into tokens following the
+// atlas-app-toolkit filtering syntax, whose keywords are case-insensitive. On
+// a lexical error it returns the tokens scanned so far and the offset of the
+// unexpected character.
+func scanExpression(expr string) ([]exprToken, int) {
+	var (
+		tokens []exprToken
+		rs     = []rune(expr)
+	)
+
+	next := func(i int) rune {
+		if i+1 < len(rs) {
+			return rs[i+1]
+		}
+		return 0
+	}
+
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, exprToken{kind: exprLparen, offset: i})
+		case c == ')':
+			tokens = append(tokens, exprToken{kind: exprRparen, offset: i})
+		case c == '[':
+			tokens = append(tokens, exprToken{kind: exprLbracket, offset: i})
+		case c == ']':
+			tokens = append(tokens, exprToken{kind: exprRbracket, offset: i})
+		case c == ',':
+			tokens = append(tokens, exprToken{kind: exprComma, offset: i})
+		case c == '"' || c == '\'':
+			start := i
+			for i++; i < len(rs) && rs[i] != c; i++ {
+				if rs[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(rs) {
+				return tokens, start
+			}
+			tokens = append(tokens, exprToken{kind: exprString, offset: start})
+		case c == '=' || c == ':':
+			if next(i) != '=' {
+				return tokens, i
+			}
+			tokens = append(tokens, exprToken{kind: exprComparison, offset: i})
+			i++
+		case c == '!':
+			if n := next(i); n == '=' || n == '~' {
+				tokens = append(tokens, exprToken{kind: exprComparison, offset: i})
+				i++
+			} else {
+				tokens = append(tokens, exprToken{kind: exprNot, offset: i})
+			}
+		case c == '<' || c == '>':
+			tokens = append(tokens, exprToken{kind: exprComparison, offset: i})
+			if next(i) == '=' {
+				i++
+			}
+		case c == '~':
+			tokens = append(tokens, exprToken{kind: exprComparison, offset: i})
+		case unicode.IsDigit(c) || c == '-' || c == '.':
+			start := i
+			for i+1 < len(rs) && (unicode.IsDigit(rs[i+1]) || rs[i+1] == '.' || rs[i+1] == 'e' || rs[i+1] == 'E') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprNumber, offset: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i+1 < len(rs) && (unicode.IsLetter(rs[i+1]) || unicode.IsDigit(rs[i+1]) || rs[i+1] == '_' || rs[i+1] == '.' || rs[i+1] == '-') {
+				i++
+			}
+			text := string(rs[start : i+1])
+			kind, ok := exprKeywords[strings.ToLower(text)]
+			if !ok {
+				kind = exprField
+			}
+			tokens = append(tokens, exprToken{kind: kind, offset: start, text: text})
+		default:
+			return tokens, i
+		}
+		i++
+	}
+
+	return append(tokens, exprToken{kind: exprEOF, offset: len(rs)}), -1
+}
+
+// locateSyntaxError returns the offset of the first character of the
+// filtering expression violating the filtering syntax or -1 if it is unknown.
+func locateSyntaxError(expr string) int {
+	tokens, offset := scanExpression(expr)
+	if offset >= 0 {
+		return offset
+	}
+
+	r := &exprRecognizer{tokens: tokens}
+	if !r.expr() {
+		return r.peek().offset
+	}
+	if r.peek().kind != exprEOF {
+		return r.peek().offset
+	}
+	return -1
+}
+
+// exprRecognizer checks the filtering expression grammar:
+//
+//	expr      := term { "or" term }
+//	term      := factor { "and" factor }
+//	factor    := "not" factor | "(" expr ")" | condition
+//	condition := field comparison value | field "in" "[" value { "," value } "]"
+type exprRecognizer struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (r *exprRecognizer) peek() exprToken {
+	return r.tokens[r.pos]
+}
+
+func (r *exprRecognizer) accept(kinds ...exprTokenKind) bool {
+	for _, k := range kinds {
+		if r.peek().kind == k {
+			if k != exprEOF {
+				r.pos++
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (r *exprRecognizer) expr() bool {
+	if !r.term() {
+		return false
+	}
+	for r.accept(exprOr) {
+		if !r.term() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *exprRecognizer) term() bool {
+	if !r.factor() {
+		return false
+	}
+	for r.accept(exprAnd) {
+		if !r.factor() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *exprRecognizer) factor() bool {
+	switch {
+	case r.accept(exprNot):
+		return r.factor()
+	case r.accept(exprLparen):
+		return r.expr() && r.accept(exprRparen)
+	case r.accept(exprField):
+		if r.accept(exprComparison) {
+			return r.accept(exprString, exprNumber, exprNull)
+		}
+		if !r.accept(exprIn) || !r.accept(exprLbracket) || !r.accept(exprString, exprNumber) {
+			return false
+		}
+		for r.accept(exprComma) {
+			if !r.accept(exprString, exprNumber) {
+				return false
+			}
+		}
+		return r.accept(exprRbracket)
+	}
+	return false
+}
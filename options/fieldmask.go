@@ -0,0 +1,78 @@
+package options
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// FieldSelectionToFieldMask converts fs validated against the allowed fields
+// into a field mask. Wildcards are replaced by the allowed fields they match.
+// A nil mask is returned if no fields are selected, meaning that the whole
+// response is returned.
+func FieldSelectionToFieldMask(fs *query.FieldSelection, allowed []string) *types.FieldMask {
+	if len(fs.GetFields()) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	mask := &types.FieldMask{}
+	for _, f := range leafFieldSelection(fs.GetFields()) {
+		paths := []string{f}
+		if path := strings.Split(f, "."); isFieldPattern(path) {
+			paths = matchFieldPattern(path, allowed)
+		}
+		for _, p := range paths {
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				mask.Paths = append(mask.Paths, p)
+			}
+		}
+	}
+	sort.Strings(mask.Paths)
+	return mask
+}
+
+// FieldMask converts fs validated against the field selection rules of the
+// method into a field mask, see FieldSelectionToFieldMask. Oneof names are
+// replaced by the selectable members of the oneof.
+func (v *MethodValidator) FieldMask(fs *query.FieldSelection) *types.FieldMask {
+	return FieldSelectionToFieldMask(v.ExpandFieldSelection(fs), v.rules.FieldSelection)
+}
+
+// FieldTree is a tree of the names of the fields selected by a field mask,
+// which is passed to the generated pruners. A field mapped to a nil tree is
+// selected with all its subfields, a nil tree selects all fields.
+type FieldTree map[string]FieldTree
+
+// NewFieldTree returns the tree of the fields selected by mask.
+func NewFieldTree(mask *types.FieldMask) FieldTree {
+	if len(mask.GetPaths()) == 0 {
+		return nil
+	}
+
+	tree := make(FieldTree)
+	for _, p := range mask.GetPaths() {
+		t := tree
+		segments := strings.Split(p, ".")
+		for i, s := range segments {
+			sub, ok := t[s]
+			if ok && sub == nil {
+				// The field is selected as a whole.
+				break
+			}
+			if i == len(segments)-1 {
+				t[s] = nil
+				break
+			}
+			if !ok {
+				sub = make(FieldTree)
+				t[s] = sub
+			}
+			t = sub
+		}
+	}
+	return tree
+}
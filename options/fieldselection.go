@@ -0,0 +1,200 @@
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// fieldSelectionWildcard stands for any field in a selected path, e.g.
+// "address.*" selects all fields of address.
+const fieldSelectionWildcard = "*"
+
+func isFieldPattern(path []string) bool {
+	for _, s := range path {
+		if s == fieldSelectionWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFieldPattern returns the allowed paths matching the selected path,
+// segments of which may be wildcards.
+func matchFieldPattern(pattern []string, allowed []string) []string {
+	var res []string
+	for _, a := range allowed {
+		segments := strings.Split(a, ".")
+		if len(segments) != len(pattern) {
+			continue
+		}
+		ok := true
+		for i, s := range pattern {
+			if s != fieldSelectionWildcard && s != segments[i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+func (v *MethodValidator) validateSelectedField(f string, path []string) error {
+	matches := []string{strings.Join(path, ".")}
+	if isFieldPattern(path) {
+		matches = matchFieldPattern(path, v.rules.FieldSelection)
+		if len(matches) == 0 {
+			return fmt.Errorf("Unknown field: '%s'", f)
+		}
+	}
+
+	for _, m := range matches {
+		if _, ok := v.fieldSelection[m]; !ok {
+			return fmt.Errorf("Unknown field: '%s'", f)
+		}
+		// A wildcard selects the parent as a whole.
+		if _, ok := v.requireParent[m]; ok && path[len(path)-1] != fieldSelectionWildcard {
+			return fmt.Errorf("Field '%s' can only be selected as a part of its parent", f)
+		}
+	}
+	return nil
+}
+
+// ExpandFieldSelection returns a copy of fs with wildcards replaced by the
+// fields they match and oneof names, which are selectable as pseudo-fields,
+// replaced by the selectable members of the oneof.
+func (v *MethodValidator) ExpandFieldSelection(fs *query.FieldSelection) *query.FieldSelection {
+	if fs == nil {
+		return nil
+	}
+
+	res := &query.FieldSelection{}
+	for _, f := range leafFieldSelection(fs.GetFields()) {
+		for _, p := range v.expandSelectedField(f, false) {
+			res.Add(p)
+		}
+	}
+	return res
+}
+
+// FieldSelectionPaths returns the sorted dotted paths of all fields selected
+// by fs, selecting a field implies selecting all its subfields allowed by the
+// rules. The paths may be used as a mask of the response. A nil result means
+// that no fields are selected explicitly and the whole response is returned.
+func (v *MethodValidator) FieldSelectionPaths(fs *query.FieldSelection) []string {
+	if len(fs.GetFields()) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var res []string
+	for _, f := range leafFieldSelection(fs.GetFields()) {
+		for _, p := range v.expandSelectedField(f, true) {
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				res = append(res, p)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// expandSelectedField returns the concrete paths the selected field stands
+// for and, if descendants is set, the paths of their allowed subfields.
+func (v *MethodValidator) expandSelectedField(f string, descendants bool) []string {
+	r, err := v.resolveSelectedField(f)
+	if err != nil {
+		return nil
+	}
+
+	var res []string
+	for _, field := range v.concreteFields(r.path) {
+		res = append(res, r.unresolve(field))
+		if !descendants {
+			continue
+		}
+		for _, a := range v.rules.FieldSelection {
+			if _, ok := v.rules.Oneofs[a]; !ok && strings.HasPrefix(a, field+".") {
+				res = append(res, r.unresolve(a))
+			}
+		}
+	}
+	return res
+}
+
+// resolvedField is a selected field path looked up through recursive fields.
+type resolvedField struct {
+	orig []string
+	path []string
+	// prefixLen is the length of the prefix of path rewritten by recursion.
+	prefixLen int
+}
+
+func (v *MethodValidator) resolveSelectedField(f string) (resolvedField, error) {
+	orig := strings.Split(f, ".")
+	path, err := resolveRecursion(v.rules.Recursions.FieldSelection, orig)
+	if err != nil {
+		return resolvedField{}, err
+	}
+
+	// Recursion rewrites a prefix of the path only.
+	tail := 0
+	for tail < len(orig) && tail < len(path) && orig[len(orig)-1-tail] == path[len(path)-1-tail] {
+		tail++
+	}
+	return resolvedField{orig: orig, path: path, prefixLen: len(path) - tail}, nil
+}
+
+// unresolve returns the path relative to the selected path of the path p
+// relative to the resolved one.
+func (r resolvedField) unresolve(p string) string {
+	prefix := r.orig[:len(r.orig)-(len(r.path)-r.prefixLen)]
+	return strings.Join(append(prefix[:len(prefix):len(prefix)], strings.Split(p, ".")[r.prefixLen:]...), ".")
+}
+
+// concreteFields returns the allowed fields matching the resolved path with
+// oneof names replaced by the selectable members of the oneof.
+func (v *MethodValidator) concreteFields(path []string) []string {
+	matches := []string{strings.Join(path, ".")}
+	if isFieldPattern(path) {
+		matches = matchFieldPattern(path, v.rules.FieldSelection)
+	}
+
+	var res []string
+	for _, m := range matches {
+		rule, ok := v.rules.Oneofs[m]
+		if !ok {
+			res = append(res, m)
+			continue
+		}
+		for _, member := range rule.Members {
+			if _, ok := v.fieldSelection[member]; ok || v.fieldSelection == nil {
+				res = append(res, member)
+			}
+		}
+	}
+	return res
+}
+
+// leafFieldSelection returns dotted paths of the selected fields having no
+// selected subfields.
+func leafFieldSelection(fields map[string]*query.Field) []string {
+	var leaves []string
+	for _, v := range fields {
+		if len(v.GetSubs()) == 0 {
+			leaves = append(leaves, v.GetName())
+			continue
+		}
+		for _, sub := range leafFieldSelection(v.GetSubs()) {
+			leaves = append(leaves, v.GetName()+"."+sub)
+		}
+	}
+	sort.Strings(leaves)
+	return leaves
+}
@@ -0,0 +1,232 @@
+package options
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// LiteralConstraint restricts the literals of the conditions on a field, so
+// that the conditions no value of the field may satisfy are rejected, e.g. a
+// condition comparing a UUID field to a malformed UUID. The literals of the
+// equality and IN conditions are checked, the literals of the other
+// operators, e.g. range bounds and regular expressions, and of the negated
+// conditions, which any value but the literals satisfies, are not.
+type LiteralConstraint struct {
+	// Format is the well-known format of string literals: "uuid", "email",
+	// "hostname", "ip", "ipv4", "ipv6", "uri", "uri_ref" or "address".
+	Format string
+	// Pattern is a regular expression string literals must match.
+	Pattern string
+	// MinLen and MaxLen bound the number of characters of string literals,
+	// a zero MaxLen means no limit.
+	MinLen, MaxLen int
+	// In lists the allowed string literals, e.g. the names of the values of
+	// an enum, NotIn lists the denied ones.
+	In, NotIn []string
+	// NumberIn lists the allowed number literals, NumberNotIn the denied ones.
+	NumberIn, NumberNotIn []float64
+	// Min and Max bound number literals, they are inclusive unless
+	// ExclusiveMin or ExclusiveMax is set.
+	Min, Max                   *float64
+	ExclusiveMin, ExclusiveMax bool
+	// IgnoreEmpty accepts the empty string and zero literals whatever the
+	// other constraints, like the ignore_empty rule of protoc-gen-validate.
+	IgnoreEmpty bool
+}
+
+// Float64 returns a pointer to v, it is used to set the bounds of
+// LiteralConstraint.
+func Float64(v float64) *float64 {
+	return &v
+}
+
+type literalChecker struct {
+	*LiteralConstraint
+	pattern *regexp.Regexp
+}
+
+// literalPatterns caches the compiled literal patterns, so that the rules
+// passed to the package level validation functions on every call are
+// compiled once.
+var literalPatterns sync.Map
+
+// newLiteralChecker returns the checker of the constraint, an error if its
+// pattern is not a valid regular expression.
+func newLiteralChecker(c *LiteralConstraint) (*literalChecker, error) {
+	if c == nil {
+		return nil, nil
+	}
+	res := &literalChecker{LiteralConstraint: c}
+	if c.Pattern == "" {
+		return res, nil
+	}
+	if re, ok := literalPatterns.Load(c.Pattern); ok {
+		res.pattern = re.(*regexp.Regexp)
+		return res, nil
+	}
+	re, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	literalPatterns.Store(c.Pattern, re)
+	res.pattern = re
+	return res, nil
+}
+
+// validate checks the literals of the condition c on the field unless the
+// condition is negated, see walkConditions.
+func (l *literalChecker) validate(fieldTag string, c interface{}, negated bool) error {
+	if l == nil || negated {
+		return nil
+	}
+
+	switch x := c.(type) {
+	case *query.StringCondition:
+		if x.GetType() == query.StringCondition_EQ || x.GetType() == query.StringCondition_IEQ {
+			if msg := l.checkString(x.GetValue(), x.GetType() == query.StringCondition_IEQ); msg != "" {
+				return fmt.Errorf("Got invalid literal for field %q, %s", fieldTag, msg)
+			}
+		}
+	case *query.StringArrayCondition:
+		for i, s := range x.GetValues() {
+			if msg := l.checkString(s, false); msg != "" {
+				return fmt.Errorf("Got invalid literal for field %q at position %d, %s", fieldTag, i, msg)
+			}
+		}
+	case *query.NumberCondition:
+		if x.GetType() == query.NumberCondition_EQ {
+			if msg := l.checkNumber(x.GetValue()); msg != "" {
+				return fmt.Errorf("Got invalid literal for field %q, %s", fieldTag, msg)
+			}
+		}
+	case *query.NumberArrayCondition:
+		for i, n := range x.GetValues() {
+			if msg := l.checkNumber(n); msg != "" {
+				return fmt.Errorf("Got invalid literal for field %q at position %d, %s", fieldTag, i, msg)
+			}
+		}
+	}
+	return nil
+}
+
+// checkString returns the expectation the literal s does not meet, if any.
+// The case of an ignore-case literal is ignored by the list checks.
+func (l *literalChecker) checkString(s string, ignoreCase bool) string {
+	if l.IgnoreEmpty && s == "" {
+		return ""
+	}
+	equal := func(a, b string) bool {
+		return a == b || ignoreCase && strings.EqualFold(a, b)
+	}
+
+	if len(l.In) > 0 {
+		var found bool
+		for _, v := range l.In {
+			if found = equal(s, v); found {
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("expect one of '%s'", strings.Join(l.In, "', '"))
+		}
+	}
+	for _, v := range l.NotIn {
+		if equal(s, v) {
+			return fmt.Sprintf("expect none of '%s'", strings.Join(l.NotIn, "', '"))
+		}
+	}
+
+	if n := utf8.RuneCountInString(s); n < l.MinLen {
+		return fmt.Sprintf("expect at least %d characters", l.MinLen)
+	} else if l.MaxLen > 0 && n > l.MaxLen {
+		return fmt.Sprintf("expect at most %d characters", l.MaxLen)
+	}
+
+	if l.pattern != nil && !l.pattern.MatchString(s) {
+		return fmt.Sprintf("expect a match of '%s'", l.Pattern)
+	}
+
+	if l.Format != "" && !isFormatted(l.Format, s) {
+		return fmt.Sprintf("expect %s", l.Format)
+	}
+	return ""
+}
+
+// checkNumber returns the expectation the literal n does not meet, if any.
+func (l *literalChecker) checkNumber(n float64) string {
+	if l.IgnoreEmpty && n == 0 {
+		return ""
+	}
+	if len(l.NumberIn) > 0 {
+		var found bool
+		for _, v := range l.NumberIn {
+			if found = n == v; found {
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("expect one of %v", l.NumberIn)
+		}
+	}
+	for _, v := range l.NumberNotIn {
+		if n == v {
+			return fmt.Sprintf("expect none of %v", l.NumberNotIn)
+		}
+	}
+
+	if l.Min != nil {
+		if l.ExclusiveMin && n <= *l.Min {
+			return fmt.Sprintf("expect greater than %v", *l.Min)
+		} else if n < *l.Min {
+			return fmt.Sprintf("expect greater than or equal to %v", *l.Min)
+		}
+	}
+	if l.Max != nil {
+		if l.ExclusiveMax && n >= *l.Max {
+			return fmt.Sprintf("expect less than %v", *l.Max)
+		} else if n > *l.Max {
+			return fmt.Sprintf("expect less than or equal to %v", *l.Max)
+		}
+	}
+	return ""
+}
+
+var (
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`)
+)
+
+func isFormatted(format, s string) bool {
+	switch format {
+	case "uuid":
+		return uuidRegexp.MatchString(s)
+	case "email":
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Name == "" && a.Address == s
+	case "hostname":
+		return len(s) <= 253 && hostnameRegexp.MatchString(s)
+	case "ip":
+		return net.ParseIP(s) != nil
+	case "ipv4":
+		return net.ParseIP(s) != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "uri_ref":
+		_, err := url.Parse(s)
+		return err == nil
+	case "address":
+		return net.ParseIP(s) != nil || isFormatted("hostname", s)
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// OneofRule describes a oneof of the result message or of a message nested
+// in it. Members are dotted paths of the fields of the oneof.
+type OneofRule struct {
+	Members []string
+	// SingleBranch restricts filtering to a single member of the oneof per query.
+	SingleBranch bool
+}
+
+// oneofError is a oneof violation caused by the condition at the index in the
+// order the conditions of the filter are walked.
+type oneofError struct {
+	err  error
+	cond int
+}
+
+func (e *oneofError) Error() string {
+	return e.err.Error()
+}
+
+// branch is a oneof member a node requires to be set and the index of the
+// first condition of the node referring to it.
+type branch struct {
+	member string
+	cond   int
+}
+
+// oneofReferences collects the oneof members referenced by the conditions of
+// a filter along with the index of the first condition referring to each.
+type oneofReferences struct {
+	conds      int
+	referenced map[string]map[string]int
+}
+
+// validateOneofs rejects filtering conditions on different members of a
+// oneof combined with AND, as such a filter never matches, and conditions on
+// more than one member of a oneof having SingleBranch set. The errors are
+// reported as *oneofError.
+func (v *MethodValidator) validateOneofs(f *query.Filtering) error {
+	if v.oneofMembers == nil {
+		return nil
+	}
+
+	refs := &oneofReferences{referenced: make(map[string]map[string]int)}
+	if _, err := v.requiredBranches(filteringRoot(f), refs); err != nil {
+		return err
+	}
+
+	oneofs := make([]string, 0, len(refs.referenced))
+	for oneof := range refs.referenced {
+		oneofs = append(oneofs, oneof)
+	}
+	sort.Strings(oneofs)
+
+	for _, oneof := range oneofs {
+		referenced := refs.referenced[oneof]
+		if !v.rules.Oneofs[oneof].SingleBranch || len(referenced) < 2 {
+			continue
+		}
+		members := make([]string, 0, len(referenced))
+		for m := range referenced {
+			members = append(members, m)
+		}
+		sort.Slice(members, func(i, j int) bool { return referenced[members[i]] < referenced[members[j]] })
+		return &oneofError{
+			err:  fmt.Errorf("Filtering is allowed on a single member of oneof '%s', got '%s' and '%s'", oneof, members[0], members[1]),
+			cond: referenced[members[1]],
+		}
+	}
+	return nil
+}
+
+// requiredBranches returns the oneof members, keyed by the oneof path, which
+// must be set for the node to match. Members referenced by conditions are
+// collected into refs.
+func (v *MethodValidator) requiredBranches(node interface{}, refs *oneofReferences) (map[string]branch, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+
+	case *query.LogicalOperator:
+		left, right := logicalOperands(n)
+		lb, err := v.requiredBranches(left, refs)
+		if err != nil {
+			return nil, err
+		}
+		rb, err := v.requiredBranches(right, refs)
+		if err != nil {
+			return nil, err
+		}
+		var branches map[string]branch
+		if n.GetType() == query.LogicalOperator_OR {
+			branches = intersectBranches(lb, rb)
+		} else if branches, err = mergeBranches(lb, rb); err != nil {
+			// A negated contradiction always matches, which is a mistake as well.
+			return nil, err
+		}
+		if n.GetIsNegative() {
+			return nil, nil
+		}
+		return branches, nil
+
+	case *query.NullCondition:
+		v.oneofBranches(n.GetFieldPath(), refs)
+		return nil, nil
+
+	case interface {
+		GetFieldPath() []string
+		GetIsNegative() bool
+	}:
+		branches := v.oneofBranches(n.GetFieldPath(), refs)
+		if n.GetIsNegative() {
+			return nil, nil
+		}
+		return branches, nil
+	}
+	return nil, nil
+}
+
+// oneofBranches returns the oneof members the field path of the next
+// condition goes through keyed by the oneof path.
+func (v *MethodValidator) oneofBranches(path []string, refs *oneofReferences) map[string]branch {
+	cond := refs.conds
+	refs.conds++
+
+	var branches map[string]branch
+	for i := 1; i <= len(path); i++ {
+		member := strings.Join(path[:i], ".")
+		oneof, ok := v.oneofMembers[member]
+		if !ok {
+			continue
+		}
+		if branches == nil {
+			branches = make(map[string]branch)
+		}
+		branches[oneof] = branch{member: member, cond: cond}
+		if refs.referenced[oneof] == nil {
+			refs.referenced[oneof] = make(map[string]int)
+		}
+		if _, ok := refs.referenced[oneof][member]; !ok {
+			refs.referenced[oneof][member] = cond
+		}
+	}
+	return branches
+}
+
+func mergeBranches(left, right map[string]branch) (map[string]branch, error) {
+	oneofs := make([]string, 0, len(right))
+	for oneof := range right {
+		oneofs = append(oneofs, oneof)
+	}
+	sort.Strings(oneofs)
+
+	res := make(map[string]branch, len(left)+len(right))
+	for oneof, b := range left {
+		res[oneof] = b
+	}
+	for _, oneof := range oneofs {
+		if b, ok := res[oneof]; ok && b.member != right[oneof].member {
+			return nil, &oneofError{
+				err:  fmt.Errorf("Conditions on '%s' and '%s' of oneof '%s' cannot be combined with AND", b.member, right[oneof].member, oneof),
+				cond: right[oneof].cond,
+			}
+		}
+		if _, ok := res[oneof]; !ok {
+			res[oneof] = right[oneof]
+		}
+	}
+	return res, nil
+}
+
+func intersectBranches(left, right map[string]branch) map[string]branch {
+	var res map[string]branch
+	for oneof, b := range left {
+		if right[oneof].member != b.member {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]branch)
+		}
+		res[oneof] = b
+	}
+	return res
+}
+
+func filteringRoot(f *query.Filtering) interface{} {
+	switch val := f.GetRoot().(type) {
+	case *query.Filtering_Operator:
+		return val.Operator
+	case *query.Filtering_StringCondition:
+		return val.StringCondition
+	case *query.Filtering_NumberCondition:
+		return val.NumberCondition
+	case *query.Filtering_NullCondition:
+		return val.NullCondition
+	case *query.Filtering_StringArrayCondition:
+		return val.StringArrayCondition
+	case *query.Filtering_NumberArrayCondition:
+		return val.NumberArrayCondition
+	}
+	return nil
+}
+
+func logicalOperands(op *query.LogicalOperator) (left, right interface{}) {
+	switch val := op.GetLeft().(type) {
+	case *query.LogicalOperator_LeftOperator:
+		left = val.LeftOperator
+	case *query.LogicalOperator_LeftStringCondition:
+		left = val.LeftStringCondition
+	case *query.LogicalOperator_LeftNumberCondition:
+		left = val.LeftNumberCondition
+	case *query.LogicalOperator_LeftNullCondition:
+		left = val.LeftNullCondition
+	case *query.LogicalOperator_LeftStringArrayCondition:
+		left = val.LeftStringArrayCondition
+	case *query.LogicalOperator_LeftNumberArrayCondition:
+		left = val.LeftNumberArrayCondition
+	}
+
+	switch val := op.GetRight().(type) {
+	case *query.LogicalOperator_RightOperator:
+		right = val.RightOperator
+	case *query.LogicalOperator_RightStringCondition:
+		right = val.RightStringCondition
+	case *query.LogicalOperator_RightNumberCondition:
+		right = val.RightNumberCondition
+	case *query.LogicalOperator_RightNullCondition:
+		right = val.RightNullCondition
+	case *query.LogicalOperator_RightStringArrayCondition:
+		right = val.RightStringArrayCondition
+	case *query.LogicalOperator_RightNumberArrayCondition:
+		right = val.RightNumberArrayCondition
+	}
+	return left, right
+}
@@ -0,0 +1,160 @@
+package options
+
+import (
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// FilterOperatorMask is a bitmask of filtering operators.
+type FilterOperatorMask uint32
+
+// NewFilterOperatorMask returns a mask containing the given operators.
+func NewFilterOperatorMask(ops ...QueryValidate_FilterOperator) FilterOperatorMask {
+	var m FilterOperatorMask
+	for _, op := range ops {
+		m |= 1 << uint(op)
+	}
+	return m
+}
+
+// Has reports whether op belongs to the mask.
+func (m FilterOperatorMask) Has(op QueryValidate_FilterOperator) bool {
+	return m&(1<<uint(op)) != 0
+}
+
+// Operators returns the operators of the mask in the order of their values.
+func (m FilterOperatorMask) Operators() []QueryValidate_FilterOperator {
+	var ops []QueryValidate_FilterOperator
+	for op := int32(0); op < 32; op++ {
+		if _, ok := QueryValidate_FilterOperator_name[op]; ok && m.Has(QueryValidate_FilterOperator(op)) {
+			ops = append(ops, QueryValidate_FilterOperator(op))
+		}
+	}
+	return ops
+}
+
+var (
+	stringFilterOperators = NewFilterOperatorMask(
+		QueryValidate_EQ,
+		QueryValidate_MATCH,
+		QueryValidate_GT,
+		QueryValidate_GE,
+		QueryValidate_LT,
+		QueryValidate_LE,
+		QueryValidate_IN,
+		QueryValidate_IEQ,
+	)
+	numberFilterOperators = NewFilterOperatorMask(
+		QueryValidate_EQ,
+		QueryValidate_GT,
+		QueryValidate_GE,
+		QueryValidate_LT,
+		QueryValidate_LE,
+		QueryValidate_IN,
+	)
+	boolFilterOperators = NewFilterOperatorMask(
+		QueryValidate_EQ,
+		QueryValidate_IN,
+	)
+)
+
+// SupportedFilterOperators returns the operators applicable to fields of the
+// value type. IS_NULL is applicable to nullable fields only, see
+// FilteringOption.SupportedOperators.
+func SupportedFilterOperators(valueType QueryValidate_ValueType) FilterOperatorMask {
+	switch valueType {
+	case QueryValidate_STRING:
+		return stringFilterOperators
+	case QueryValidate_NUMBER:
+		return numberFilterOperators
+	case QueryValidate_BOOL:
+		return boolFilterOperators
+	default:
+		return 0
+	}
+}
+
+// SupportedOperators returns the operators applicable to the field described
+// by the option: the operators supported for its value type and IS_NULL if
+// it is nullable.
+func (o FilteringOption) SupportedOperators() FilterOperatorMask {
+	supported := SupportedFilterOperators(o.ValueType)
+	if o.Nullable && supported != 0 {
+		supported |= NewFilterOperatorMask(QueryValidate_IS_NULL)
+	}
+	return supported
+}
+
+// AllowedOperators returns the operators allowed by the option, which is the
+// Allowed mask if AllowedSet is true. Options lacking the flag, e.g. produced
+// by an older plugin version or written by hand, get the mask computed from
+// the value type, the nullability and the Deny list if Allowed is empty.
+func (o FilteringOption) AllowedOperators() FilterOperatorMask {
+	if o.AllowedSet || o.Allowed != 0 {
+		return o.Allowed
+	}
+
+	allowed := o.SupportedOperators()
+	for _, op := range o.Deny {
+		if op == QueryValidate_ALL {
+			return 0
+		}
+		allowed &^= NewFilterOperatorMask(op)
+	}
+	return allowed
+}
+
+// StringConditionOperator maps a string condition type to the filtering operator.
+func StringConditionOperator(t query.StringCondition_Type) (QueryValidate_FilterOperator, bool) {
+	switch t {
+	case query.StringCondition_EQ:
+		return QueryValidate_EQ, true
+	case query.StringCondition_MATCH:
+		return QueryValidate_MATCH, true
+	case query.StringCondition_GT:
+		return QueryValidate_GT, true
+	case query.StringCondition_GE:
+		return QueryValidate_GE, true
+	case query.StringCondition_LT:
+		return QueryValidate_LT, true
+	case query.StringCondition_LE:
+		return QueryValidate_LE, true
+	case query.StringCondition_IEQ:
+		return QueryValidate_IEQ, true
+	}
+	return 0, false
+}
+
+// NumberConditionOperator maps a number condition type to the filtering operator.
+func NumberConditionOperator(t query.NumberCondition_Type) (QueryValidate_FilterOperator, bool) {
+	switch t {
+	case query.NumberCondition_EQ:
+		return QueryValidate_EQ, true
+	case query.NumberCondition_GT:
+		return QueryValidate_GT, true
+	case query.NumberCondition_GE:
+		return QueryValidate_GE, true
+	case query.NumberCondition_LT:
+		return QueryValidate_LT, true
+	case query.NumberCondition_LE:
+		return QueryValidate_LE, true
+	}
+	return 0, false
+}
+
+// StringArrayConditionOperator maps a string array condition type to the filtering operator.
+func StringArrayConditionOperator(t query.StringArrayCondition_Type) (QueryValidate_FilterOperator, bool) {
+	switch t {
+	case query.StringArrayCondition_IN:
+		return QueryValidate_IN, true
+	}
+	return 0, false
+}
+
+// NumberArrayConditionOperator maps a number array condition type to the filtering operator.
+func NumberArrayConditionOperator(t query.NumberArrayCondition_Type) (QueryValidate_FilterOperator, bool) {
+	switch t {
+	case query.NumberArrayCondition_IN:
+		return QueryValidate_IN, true
+	}
+	return 0, false
+}
@@ -0,0 +1,120 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Principal describes the caller of a method.
+type Principal struct {
+	Subject string `json:"subject"`
+	// Roles are the roles or scopes granted to the caller.
+	Roles []string `json:"roles"`
+	// Attributes are the claims of the caller a QueryAuthorizer may rely
+	// on, e.g. the tenant or the department. The values must be JSON
+	// serializable.
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// HasAnyRole reports whether the principal is granted any of the roles.
+func (p *Principal) HasAnyRole(roles []string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range roles {
+		for _, granted := range p.Roles {
+			if r == granted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrincipalExtractor returns the principal of the caller from the context of
+// a request. A nil principal means an anonymous caller.
+type PrincipalExtractor interface {
+	ExtractPrincipal(ctx context.Context) (*Principal, error)
+}
+
+// PrincipalExtractorFunc adapts a function to PrincipalExtractor.
+type PrincipalExtractorFunc func(ctx context.Context) (*Principal, error)
+
+// ExtractPrincipal calls f(ctx).
+func (f PrincipalExtractorFunc) ExtractPrincipal(ctx context.Context) (*Principal, error) {
+	return f(ctx)
+}
+
+type principalKey struct{}
+
+// NewPrincipalContext returns a copy of ctx carrying the principal.
+func NewPrincipalContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal carried by ctx.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// ContextPrincipalExtractor returns the principal stored in the context by
+// NewPrincipalContext. It is used by validators having no extractor set.
+var ContextPrincipalExtractor PrincipalExtractor = PrincipalExtractorFunc(func(ctx context.Context) (*Principal, error) {
+	p, _ := PrincipalFromContext(ctx)
+	return p, nil
+})
+
+// PermissionDeniedError is returned by the context-aware validators when a
+// query refers to a known field the caller is not allowed to use or when a
+// QueryAuthorizer denies the query, in which case Parameter and Field may be
+// empty and Reason explains the decision.
+type PermissionDeniedError struct {
+	Parameter string
+	Field     string
+	Reason    string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("Permission denied: %s", e.Reason)
+	}
+	switch e.Parameter {
+	case filteringParameter:
+		return fmt.Sprintf("Permission denied to filter by '%s'", e.Field)
+	case sortingParameter:
+		return fmt.Sprintf("Permission denied to sort by '%s'", e.Field)
+	default:
+		return fmt.Sprintf("Permission denied to select '%s'", e.Field)
+	}
+}
+
+// IsPermissionDenied reports whether err is a *PermissionDeniedError.
+func IsPermissionDenied(err error) bool {
+	_, ok := err.(*PermissionDeniedError)
+	return ok
+}
+
+// MethodPermissions are the roles any of which is required to use a field
+// in the query parameters of a method, keyed by the path of the field. The
+// roles of a field apply to its nested fields as well.
+type MethodPermissions struct {
+	Filtering      map[string][]string
+	Sorting        map[string][]string
+	FieldSelection map[string][]string
+}
+
+// checkPermissions returns an error if the principal is not granted the
+// roles required by any of the fields on the paths.
+func checkPermissions(permissions map[string][]string, principal *Principal, parameter, field string, paths ...[]string) error {
+	for _, path := range paths {
+		for i := 1; i <= len(path); i++ {
+			roles, ok := permissions[strings.Join(path[:i], ".")]
+			if ok && !principal.HasAnyRole(roles) {
+				return &PermissionDeniedError{Parameter: parameter, Field: field}
+			}
+		}
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ It is generated from these files:
 It has these top-level messages:
 	QueryValidate
 	MessageQueryValidate
+	OneofQueryValidate
+	MethodQueryValidate
 */
 package options
 
@@ -41,6 +43,8 @@ const (
 	QueryValidate_ALL   QueryValidate_FilterOperator = 6
 	QueryValidate_IEQ   QueryValidate_FilterOperator = 7
 	QueryValidate_IN    QueryValidate_FilterOperator = 8
+	// Null checks, e.g. "name == null", allowed on nullable fields only.
+	QueryValidate_IS_NULL QueryValidate_FilterOperator = 9
 )
 
 var QueryValidate_FilterOperator_name = map[int32]string{
@@ -53,17 +57,19 @@ var QueryValidate_FilterOperator_name = map[int32]string{
 	6: "ALL",
 	7: "IEQ",
 	8: "IN",
+	9: "IS_NULL",
 }
 var QueryValidate_FilterOperator_value = map[string]int32{
-	"EQ":    0,
-	"MATCH": 1,
-	"GT":    2,
-	"GE":    3,
-	"LT":    4,
-	"LE":    5,
-	"ALL":   6,
-	"IEQ":   7,
-	"IN":    8,
+	"EQ":      0,
+	"MATCH":   1,
+	"GT":      2,
+	"GE":      3,
+	"LT":      4,
+	"LE":      5,
+	"ALL":     6,
+	"IEQ":     7,
+	"IN":      8,
+	"IS_NULL": 9,
 }
 
 func (x QueryValidate_FilterOperator) String() string {
@@ -102,14 +108,78 @@ func (QueryValidate_ValueType) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptorQueryValidate, []int{0, 1}
 }
 
+// Semantics of filtering conditions on repeated fields: a condition matches
+// if any (contains) or all of the elements match it.
+type QueryValidate_RepeatedSemantics int32
+
+const (
+	QueryValidate_REPEATED_NONE     QueryValidate_RepeatedSemantics = 0
+	QueryValidate_REPEATED_ANY      QueryValidate_RepeatedSemantics = 1
+	QueryValidate_REPEATED_CONTAINS QueryValidate_RepeatedSemantics = 1
+	QueryValidate_REPEATED_ALL      QueryValidate_RepeatedSemantics = 2
+)
+
+var QueryValidate_RepeatedSemantics_name = map[int32]string{
+	0: "REPEATED_NONE",
+	1: "REPEATED_ANY",
+	// Duplicate value: 1: "REPEATED_CONTAINS",
+	2: "REPEATED_ALL",
+}
+var QueryValidate_RepeatedSemantics_value = map[string]int32{
+	"REPEATED_NONE":     0,
+	"REPEATED_ANY":      1,
+	"REPEATED_CONTAINS": 1,
+	"REPEATED_ALL":      2,
+}
+
+func (x QueryValidate_RepeatedSemantics) String() string {
+	return proto.EnumName(QueryValidate_RepeatedSemantics_name, int32(x))
+}
+func (QueryValidate_RepeatedSemantics) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptorQueryValidate, []int{0, 2}
+}
+
+// Handling of the selected fields the caller is not allowed to see, see
+// QueryValidate.Permissions: the request is rejected or the fields are dropped.
+type MessageQueryValidate_UnauthorizedFields int32
+
+const (
+	MessageQueryValidate_REJECT MessageQueryValidate_UnauthorizedFields = 0
+	MessageQueryValidate_DROP   MessageQueryValidate_UnauthorizedFields = 1
+)
+
+var MessageQueryValidate_UnauthorizedFields_name = map[int32]string{
+	0: "REJECT",
+	1: "DROP",
+}
+var MessageQueryValidate_UnauthorizedFields_value = map[string]int32{
+	"REJECT": 0,
+	"DROP":   1,
+}
+
+func (x MessageQueryValidate_UnauthorizedFields) String() string {
+	return proto.EnumName(MessageQueryValidate_UnauthorizedFields_name, int32(x))
+}
+func (MessageQueryValidate_UnauthorizedFields) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptorQueryValidate, []int{1, 0}
+}
+
 type QueryValidate struct {
-	Filtering          *QueryValidate_Filtering      `protobuf:"bytes,1,opt,name=filtering" json:"filtering,omitempty"`
-	Sorting            *QueryValidate_Sorting        `protobuf:"bytes,2,opt,name=sorting" json:"sorting,omitempty"`
-	FieldSelection     *QueryValidate_FieldSelection `protobuf:"bytes,3,opt,name=field_selection,json=fieldSelection" json:"field_selection,omitempty"`
-	ValueType          QueryValidate_ValueType       `protobuf:"varint,4,opt,name=value_type,json=valueType,proto3,enum=atlas.query.QueryValidate_ValueType" json:"value_type,omitempty"`
-	ValueTypeUrl       string                        `protobuf:"bytes,5,opt,name=value_type_url,json=valueTypeUrl,proto3" json:"value_type_url,omitempty"`
-	EnableNestedFields bool                          `protobuf:"varint,6,opt,name=enable_nested_fields,json=enableNestedFields,proto3" json:"enable_nested_fields,omitempty"`
-	NestedFields       []string                      `protobuf:"bytes,7,rep,name=nested_fields,json=nestedFields" json:"nested_fields,omitempty"`
+	Filtering          *QueryValidate_Filtering        `protobuf:"bytes,1,opt,name=filtering" json:"filtering,omitempty"`
+	Sorting            *QueryValidate_Sorting          `protobuf:"bytes,2,opt,name=sorting" json:"sorting,omitempty"`
+	FieldSelection     *QueryValidate_FieldSelection   `protobuf:"bytes,3,opt,name=field_selection,json=fieldSelection" json:"field_selection,omitempty"`
+	ValueType          QueryValidate_ValueType         `protobuf:"varint,4,opt,name=value_type,json=valueType,proto3,enum=atlas.query.QueryValidate_ValueType" json:"value_type,omitempty"`
+	ValueTypeUrl       string                          `protobuf:"bytes,5,opt,name=value_type_url,json=valueTypeUrl,proto3" json:"value_type_url,omitempty"`
+	EnableNestedFields bool                            `protobuf:"varint,6,opt,name=enable_nested_fields,json=enableNestedFields,proto3" json:"enable_nested_fields,omitempty"`
+	NestedFields       []string                        `protobuf:"bytes,7,rep,name=nested_fields,json=nestedFields" json:"nested_fields,omitempty"`
+	Keys               []*QueryValidate_KeyRule        `protobuf:"bytes,8,rep,name=keys" json:"keys,omitempty"`
+	KeyRegex           string                          `protobuf:"bytes,9,opt,name=key_regex,json=keyRegex,proto3" json:"key_regex,omitempty"`
+	RepeatedSemantics  QueryValidate_RepeatedSemantics `protobuf:"varint,10,opt,name=repeated_semantics,json=repeatedSemantics,proto3,enum=atlas.query.QueryValidate_RepeatedSemantics" json:"repeated_semantics,omitempty"`
+	MaxDepth           int32                           `protobuf:"varint,11,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	Permissions        *QueryValidate_Permissions      `protobuf:"bytes,12,opt,name=permissions" json:"permissions,omitempty"`
+	Sensitive          bool                            `protobuf:"varint,13,opt,name=sensitive,proto3" json:"sensitive,omitempty"`
+	SensitiveOverride  bool                            `protobuf:"varint,14,opt,name=sensitive_override,json=sensitiveOverride,proto3" json:"sensitive_override,omitempty"`
+	Deprecated         bool                            `protobuf:"varint,15,opt,name=deprecated,proto3" json:"deprecated,omitempty"`
 }
 
 func (m *QueryValidate) Reset()                    { *m = QueryValidate{} }
@@ -166,6 +236,62 @@ func (m *QueryValidate) GetNestedFields() []string {
 	return nil
 }
 
+func (m *QueryValidate) GetKeys() []*QueryValidate_KeyRule {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *QueryValidate) GetKeyRegex() string {
+	if m != nil {
+		return m.KeyRegex
+	}
+	return ""
+}
+
+func (m *QueryValidate) GetRepeatedSemantics() QueryValidate_RepeatedSemantics {
+	if m != nil {
+		return m.RepeatedSemantics
+	}
+	return QueryValidate_REPEATED_NONE
+}
+
+func (m *QueryValidate) GetMaxDepth() int32 {
+	if m != nil {
+		return m.MaxDepth
+	}
+	return 0
+}
+
+func (m *QueryValidate) GetPermissions() *QueryValidate_Permissions {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+func (m *QueryValidate) GetSensitive() bool {
+	if m != nil {
+		return m.Sensitive
+	}
+	return false
+}
+
+func (m *QueryValidate) GetSensitiveOverride() bool {
+	if m != nil {
+		return m.SensitiveOverride
+	}
+	return false
+}
+
+func (m *QueryValidate) GetDeprecated() bool {
+	if m != nil {
+		return m.Deprecated
+	}
+	return false
+}
+
 type QueryValidate_Filtering struct {
 	Allow []QueryValidate_FilterOperator `protobuf:"varint,1,rep,packed,name=allow,enum=atlas.query.QueryValidate_FilterOperator" json:"allow,omitempty"`
 	Deny  []QueryValidate_FilterOperator `protobuf:"varint,2,rep,packed,name=deny,enum=atlas.query.QueryValidate_FilterOperator" json:"deny,omitempty"`
@@ -211,7 +337,8 @@ func (m *QueryValidate_Sorting) GetDisable() bool {
 }
 
 type QueryValidate_FieldSelection struct {
-	Disable bool `protobuf:"varint,1,opt,name=disable,proto3" json:"disable,omitempty"`
+	Disable       bool `protobuf:"varint,1,opt,name=disable,proto3" json:"disable,omitempty"`
+	RequireParent bool `protobuf:"varint,2,opt,name=require_parent,json=requireParent,proto3" json:"require_parent,omitempty"`
 }
 
 func (m *QueryValidate_FieldSelection) Reset()         { *m = QueryValidate_FieldSelection{} }
@@ -228,10 +355,94 @@ func (m *QueryValidate_FieldSelection) GetDisable() bool {
 	return false
 }
 
+func (m *QueryValidate_FieldSelection) GetRequireParent() bool {
+	if m != nil {
+		return m.RequireParent
+	}
+	return false
+}
+
+// Rules for keys of map and JSON fields. The pattern is a key, e.g. "env",
+// or a key prefix followed by "*", e.g. "a.*" or "*".
+type QueryValidate_KeyRule struct {
+	Pattern   string                   `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	ValueType QueryValidate_ValueType  `protobuf:"varint,2,opt,name=value_type,json=valueType,proto3,enum=atlas.query.QueryValidate_ValueType" json:"value_type,omitempty"`
+	Filtering *QueryValidate_Filtering `protobuf:"bytes,3,opt,name=filtering" json:"filtering,omitempty"`
+}
+
+func (m *QueryValidate_KeyRule) Reset()         { *m = QueryValidate_KeyRule{} }
+func (m *QueryValidate_KeyRule) String() string { return proto.CompactTextString(m) }
+func (*QueryValidate_KeyRule) ProtoMessage()    {}
+func (*QueryValidate_KeyRule) Descriptor() ([]byte, []int) {
+	return fileDescriptorQueryValidate, []int{0, 3}
+}
+
+func (m *QueryValidate_KeyRule) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *QueryValidate_KeyRule) GetValueType() QueryValidate_ValueType {
+	if m != nil {
+		return m.ValueType
+	}
+	return QueryValidate_DEFAULT
+}
+
+func (m *QueryValidate_KeyRule) GetFiltering() *QueryValidate_Filtering {
+	if m != nil {
+		return m.Filtering
+	}
+	return nil
+}
+
+// Roles or scopes of the caller any of which is required to filter, sort
+// or select the field and its nested fields.
+type QueryValidate_Permissions struct {
+	Filtering      []string `protobuf:"bytes,1,rep,name=filtering" json:"filtering,omitempty"`
+	Sorting        []string `protobuf:"bytes,2,rep,name=sorting" json:"sorting,omitempty"`
+	FieldSelection []string `protobuf:"bytes,3,rep,name=field_selection,json=fieldSelection" json:"field_selection,omitempty"`
+}
+
+func (m *QueryValidate_Permissions) Reset()         { *m = QueryValidate_Permissions{} }
+func (m *QueryValidate_Permissions) String() string { return proto.CompactTextString(m) }
+func (*QueryValidate_Permissions) ProtoMessage()    {}
+func (*QueryValidate_Permissions) Descriptor() ([]byte, []int) {
+	return fileDescriptorQueryValidate, []int{0, 4}
+}
+
+func (m *QueryValidate_Permissions) GetFiltering() []string {
+	if m != nil {
+		return m.Filtering
+	}
+	return nil
+}
+
+func (m *QueryValidate_Permissions) GetSorting() []string {
+	if m != nil {
+		return m.Sorting
+	}
+	return nil
+}
+
+func (m *QueryValidate_Permissions) GetFieldSelection() []string {
+	if m != nil {
+		return m.FieldSelection
+	}
+	return nil
+}
+
 type MessageQueryValidate struct {
 	Validate              []*MessageQueryValidate_QueryValidateEntry `protobuf:"bytes,1,rep,name=validate" json:"validate,omitempty"`
 	NestedFieldDepthLimit int32                                      `protobuf:"varint,2,opt,name=nested_field_depth_limit,json=nestedFieldDepthLimit,proto3" json:"nested_field_depth_limit,omitempty"`
 	EnableNestedFields    bool                                       `protobuf:"varint,3,opt,name=enable_nested_fields,json=enableNestedFields,proto3" json:"enable_nested_fields,omitempty"`
+	UnauthorizedFields    MessageQueryValidate_UnauthorizedFields    `protobuf:"varint,4,opt,name=unauthorized_fields,json=unauthorizedFields,proto3,enum=atlas.query.MessageQueryValidate_UnauthorizedFields" json:"unauthorized_fields,omitempty"`
+	// Path of the field restricting the rows the caller may query, e.g. "account_id".
+	// The condition matching the scope of the caller is added to the filter of the
+	// methods returning the message, which may not refer to the field.
+	ScopeField string `protobuf:"bytes,5,opt,name=scope_field,json=scopeField,proto3" json:"scope_field,omitempty"`
 }
 
 func (m *MessageQueryValidate) Reset()         { *m = MessageQueryValidate{} }
@@ -262,6 +473,20 @@ func (m *MessageQueryValidate) GetEnableNestedFields() bool {
 	return false
 }
 
+func (m *MessageQueryValidate) GetUnauthorizedFields() MessageQueryValidate_UnauthorizedFields {
+	if m != nil {
+		return m.UnauthorizedFields
+	}
+	return MessageQueryValidate_REJECT
+}
+
+func (m *MessageQueryValidate) GetScopeField() string {
+	if m != nil {
+		return m.ScopeField
+	}
+	return ""
+}
+
 type MessageQueryValidate_QueryValidateEntry struct {
 	Name  string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Value *QueryValidate `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
@@ -290,6 +515,42 @@ func (m *MessageQueryValidate_QueryValidateEntry) GetValue() *QueryValidate {
 	return nil
 }
 
+type OneofQueryValidate struct {
+	// Restricts filtering to a single member of the oneof per query.
+	SingleBranch bool `protobuf:"varint,1,opt,name=single_branch,json=singleBranch,proto3" json:"single_branch,omitempty"`
+}
+
+func (m *OneofQueryValidate) Reset()                    { *m = OneofQueryValidate{} }
+func (m *OneofQueryValidate) String() string            { return proto.CompactTextString(m) }
+func (*OneofQueryValidate) ProtoMessage()               {}
+func (*OneofQueryValidate) Descriptor() ([]byte, []int) { return fileDescriptorQueryValidate, []int{2} }
+
+func (m *OneofQueryValidate) GetSingleBranch() bool {
+	if m != nil {
+		return m.SingleBranch
+	}
+	return false
+}
+
+type MethodQueryValidate struct {
+	// Overrides the scope_field of the result message of the method.
+	ScopeField string `protobuf:"bytes,1,opt,name=scope_field,json=scopeField,proto3" json:"scope_field,omitempty"`
+}
+
+func (m *MethodQueryValidate) Reset()         { *m = MethodQueryValidate{} }
+func (m *MethodQueryValidate) String() string { return proto.CompactTextString(m) }
+func (*MethodQueryValidate) ProtoMessage()    {}
+func (*MethodQueryValidate) Descriptor() ([]byte, []int) {
+	return fileDescriptorQueryValidate, []int{3}
+}
+
+func (m *MethodQueryValidate) GetScopeField() string {
+	if m != nil {
+		return m.ScopeField
+	}
+	return ""
+}
+
 var E_Validate = &proto.ExtensionDesc{
 	ExtendedType:  (*google_protobuf.FieldOptions)(nil),
 	ExtensionType: (*QueryValidate)(nil),
@@ -308,64 +569,119 @@ var E_Message = &proto.ExtensionDesc{
 	Filename:      "options/query_validate.proto",
 }
 
+var E_Oneof = &proto.ExtensionDesc{
+	ExtendedType:  (*google_protobuf.OneofOptions)(nil),
+	ExtensionType: (*OneofQueryValidate)(nil),
+	Field:         52121,
+	Name:          "atlas.query.oneof",
+	Tag:           "bytes,52121,opt,name=oneof",
+	Filename:      "options/query_validate.proto",
+}
+
+var E_Method = &proto.ExtensionDesc{
+	ExtendedType:  (*google_protobuf.MethodOptions)(nil),
+	ExtensionType: (*MethodQueryValidate)(nil),
+	Field:         52121,
+	Name:          "atlas.query.method",
+	Tag:           "bytes,52121,opt,name=method",
+	Filename:      "options/query_validate.proto",
+}
+
 func init() {
 	proto.RegisterType((*QueryValidate)(nil), "atlas.query.QueryValidate")
 	proto.RegisterType((*QueryValidate_Filtering)(nil), "atlas.query.QueryValidate.Filtering")
 	proto.RegisterType((*QueryValidate_Sorting)(nil), "atlas.query.QueryValidate.Sorting")
 	proto.RegisterType((*QueryValidate_FieldSelection)(nil), "atlas.query.QueryValidate.FieldSelection")
+	proto.RegisterType((*QueryValidate_KeyRule)(nil), "atlas.query.QueryValidate.KeyRule")
+	proto.RegisterType((*QueryValidate_Permissions)(nil), "atlas.query.QueryValidate.Permissions")
 	proto.RegisterType((*MessageQueryValidate)(nil), "atlas.query.MessageQueryValidate")
 	proto.RegisterType((*MessageQueryValidate_QueryValidateEntry)(nil), "atlas.query.MessageQueryValidate.QueryValidateEntry")
+	proto.RegisterType((*OneofQueryValidate)(nil), "atlas.query.OneofQueryValidate")
+	proto.RegisterType((*MethodQueryValidate)(nil), "atlas.query.MethodQueryValidate")
 	proto.RegisterEnum("atlas.query.QueryValidate_FilterOperator", QueryValidate_FilterOperator_name, QueryValidate_FilterOperator_value)
 	proto.RegisterEnum("atlas.query.QueryValidate_ValueType", QueryValidate_ValueType_name, QueryValidate_ValueType_value)
+	proto.RegisterEnum("atlas.query.QueryValidate_RepeatedSemantics", QueryValidate_RepeatedSemantics_name, QueryValidate_RepeatedSemantics_value)
+	proto.RegisterEnum("atlas.query.MessageQueryValidate_UnauthorizedFields", MessageQueryValidate_UnauthorizedFields_name, MessageQueryValidate_UnauthorizedFields_value)
 	proto.RegisterExtension(E_Validate)
 	proto.RegisterExtension(E_Message)
+	proto.RegisterExtension(E_Oneof)
+	proto.RegisterExtension(E_Method)
 }
 
 func init() { proto.RegisterFile("options/query_validate.proto", fileDescriptorQueryValidate) }
 
 var fileDescriptorQueryValidate = []byte{
-	// 675 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xdd, 0x6e, 0xda, 0x48,
-	0x14, 0x8e, 0x31, 0x60, 0x38, 0x6c, 0x58, 0x6b, 0x94, 0x95, 0x2c, 0xb4, 0xab, 0x75, 0x49, 0x2e,
-	0x68, 0x25, 0x4c, 0x94, 0x56, 0xaa, 0x94, 0xb6, 0xaa, 0x42, 0xe2, 0xa4, 0x48, 0x04, 0x9a, 0x09,
-	0x49, 0xa5, 0x48, 0xad, 0x65, 0xf0, 0x40, 0x2c, 0x0d, 0x1e, 0xd7, 0x1e, 0xd2, 0xf2, 0x0c, 0x7d,
-	0x80, 0xaa, 0x97, 0x7d, 0x82, 0xbe, 0x62, 0x35, 0x63, 0x4c, 0x70, 0x53, 0x12, 0xf5, 0xea, 0x1c,
-	0x73, 0xbe, 0xef, 0xe3, 0xcc, 0xf9, 0x83, 0x7f, 0x59, 0xc8, 0x7d, 0x16, 0xc4, 0xad, 0x8f, 0x33,
-	0x12, 0xcd, 0x9d, 0x1b, 0x97, 0xfa, 0x9e, 0xcb, 0x89, 0x15, 0x46, 0x8c, 0x33, 0x54, 0x71, 0x39,
-	0x75, 0x63, 0x4b, 0xc6, 0x6a, 0xe6, 0x84, 0xb1, 0x09, 0x25, 0x2d, 0x19, 0x1a, 0xce, 0xc6, 0x2d,
-	0x8f, 0xc4, 0xa3, 0xc8, 0x0f, 0x39, 0x8b, 0x12, 0x78, 0xfd, 0x7b, 0x11, 0x36, 0xcf, 0x04, 0xf6,
-	0x72, 0x21, 0x83, 0xda, 0x50, 0x1e, 0xfb, 0x94, 0x93, 0xc8, 0x0f, 0x26, 0x86, 0x62, 0x2a, 0x8d,
-	0xca, 0xde, 0x8e, 0xb5, 0x22, 0x6a, 0x65, 0xe0, 0xd6, 0x71, 0x8a, 0xc5, 0xb7, 0x34, 0xf4, 0x12,
-	0xb4, 0x98, 0x45, 0x5c, 0x28, 0xe4, 0xa4, 0x42, 0xfd, 0x1e, 0x85, 0xf3, 0x04, 0x89, 0x53, 0x0a,
-	0xc2, 0xf0, 0xf7, 0xd8, 0x27, 0xd4, 0x73, 0x62, 0x42, 0xc9, 0x48, 0xbc, 0xd5, 0x50, 0xa5, 0xca,
-	0xe3, 0x7b, 0xf3, 0x20, 0xd4, 0x3b, 0x4f, 0x09, 0xb8, 0x3a, 0xce, 0x7c, 0xa3, 0x43, 0x80, 0x1b,
-	0x97, 0xce, 0x88, 0xc3, 0xe7, 0x21, 0x31, 0xf2, 0xa6, 0xd2, 0xa8, 0xde, 0xfb, 0xac, 0x4b, 0x01,
-	0x1e, 0xcc, 0x43, 0x82, 0xcb, 0x37, 0xa9, 0x8b, 0x76, 0xa0, 0x7a, 0x2b, 0xe2, 0xcc, 0x22, 0x6a,
-	0x14, 0x4c, 0xa5, 0x51, 0xc6, 0x7f, 0x2d, 0x21, 0x17, 0x11, 0x45, 0xbb, 0xb0, 0x45, 0x02, 0x77,
-	0x48, 0x89, 0x13, 0x90, 0x98, 0x13, 0xcf, 0x91, 0xa9, 0xc4, 0x46, 0xd1, 0x54, 0x1a, 0x25, 0x8c,
-	0x92, 0x58, 0x4f, 0x86, 0x64, 0xd2, 0x31, 0xda, 0x86, 0xcd, 0x2c, 0x54, 0x33, 0x55, 0x21, 0x1b,
-	0xac, 0x80, 0x6a, 0x5f, 0x14, 0x28, 0x2f, 0x8b, 0x8d, 0x5e, 0x43, 0xc1, 0xa5, 0x94, 0x7d, 0x32,
-	0x14, 0x53, 0x6d, 0x54, 0x1f, 0xa8, 0x8c, 0x20, 0xf5, 0x43, 0x12, 0xb9, 0x9c, 0x45, 0x38, 0xe1,
-	0xa1, 0x57, 0x90, 0xf7, 0x48, 0x30, 0x37, 0x72, 0x7f, 0xca, 0x97, 0xb4, 0xda, 0x36, 0x68, 0x8b,
-	0xbe, 0x21, 0x03, 0x34, 0xcf, 0x8f, 0xc5, 0xa3, 0xe4, 0xb8, 0x94, 0x70, 0xfa, 0x59, 0x7b, 0x02,
-	0xd5, 0x6c, 0x5b, 0xd6, 0x63, 0xeb, 0xef, 0x05, 0x76, 0xf5, 0x8f, 0x50, 0x11, 0x72, 0xf6, 0x99,
-	0xbe, 0x81, 0xca, 0x50, 0x38, 0x3d, 0x18, 0x1c, 0xbe, 0xd1, 0x15, 0xf1, 0xd3, 0xc9, 0x40, 0xcf,
-	0x49, 0x6b, 0xeb, 0xaa, 0xb0, 0xdd, 0x81, 0x9e, 0x97, 0xd6, 0xd6, 0x0b, 0x48, 0x03, 0xf5, 0xa0,
-	0xdb, 0xd5, 0x8b, 0xc2, 0xe9, 0xd8, 0x67, 0xba, 0x26, 0x22, 0x9d, 0x9e, 0x5e, 0xaa, 0xef, 0x43,
-	0x79, 0xd9, 0x52, 0x54, 0x01, 0xed, 0xc8, 0x3e, 0x3e, 0xb8, 0xe8, 0x0e, 0xf4, 0x0d, 0x04, 0x50,
-	0x3c, 0x1f, 0xe0, 0x4e, 0xef, 0x44, 0x57, 0x84, 0xdf, 0xbb, 0x38, 0x6d, 0xdb, 0x58, 0xcf, 0xa1,
-	0x12, 0xe4, 0xdb, 0xfd, 0x7e, 0x57, 0x57, 0xeb, 0x3f, 0x72, 0xb0, 0x75, 0x4a, 0xe2, 0xd8, 0x9d,
-	0x90, 0xec, 0xaa, 0xbc, 0x85, 0x52, 0xba, 0x7d, 0xb2, 0x0f, 0x95, 0xbd, 0x67, 0x99, 0x3a, 0xfe,
-	0x8e, 0x94, 0x2d, 0xae, 0x1d, 0xf0, 0x68, 0x8e, 0x97, 0x2a, 0xe8, 0x39, 0x18, 0xab, 0x93, 0xe0,
-	0x78, 0x24, 0xe4, 0xd7, 0x0e, 0xf5, 0xa7, 0x3e, 0x97, 0x9b, 0x54, 0xc0, 0xff, 0xac, 0x0c, 0xc5,
-	0x91, 0x88, 0x76, 0x45, 0x70, 0xed, 0xd0, 0xa9, 0xeb, 0x86, 0xae, 0x76, 0x05, 0xe8, 0x6e, 0x2a,
-	0x08, 0x41, 0x3e, 0x70, 0xa7, 0x49, 0x77, 0xca, 0x58, 0xfa, 0x68, 0x17, 0x0a, 0x72, 0xc0, 0x17,
-	0xbb, 0x5c, 0x5b, 0x3f, 0x2b, 0x38, 0x01, 0xee, 0xbf, 0xbb, 0x2d, 0x0c, 0xfa, 0xcf, 0x4a, 0x8e,
-	0x90, 0x95, 0x1e, 0xa1, 0x64, 0x55, 0xfb, 0xc9, 0x11, 0x33, 0xbe, 0x7d, 0x55, 0x1f, 0x54, 0x5d,
-	0x8a, 0xed, 0x7f, 0x00, 0x6d, 0x9a, 0x14, 0x15, 0xfd, 0x7f, 0x47, 0x77, 0x51, 0xee, 0x5f, 0x95,
-	0x1f, 0x3d, 0xd8, 0x13, 0x9c, 0x8a, 0xb6, 0x3b, 0x57, 0x27, 0x13, 0x9f, 0x5f, 0xcf, 0x86, 0xd6,
-	0x88, 0x4d, 0x5b, 0x7e, 0x30, 0x66, 0x43, 0xca, 0x3e, 0xb3, 0x90, 0x04, 0xc9, 0x0d, 0x1d, 0x35,
-	0x27, 0x24, 0x68, 0x4a, 0xbd, 0xa6, 0xd4, 0x6b, 0xa6, 0xa9, 0xb5, 0x16, 0x57, 0xf9, 0xc5, 0xc2,
-	0x0e, 0x8b, 0x92, 0xf0, 0xf4, 0x67, 0x00, 0x00, 0x00, 0xff, 0xff, 0x82, 0x8e, 0xe2, 0xb1, 0xaf,
-	0x05, 0x00, 0x00,
+	// 1137 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0xfb, 0x6e, 0xe3, 0x44,
+	0x17, 0x5f, 0xe7, 0x9e, 0x93, 0x26, 0xeb, 0xce, 0xee, 0x4a, 0x56, 0xbe, 0xfd, 0xb6, 0x21, 0x5d,
+	0x20, 0x20, 0x9a, 0xae, 0x16, 0xb4, 0x88, 0x02, 0x42, 0x69, 0xeb, 0xed, 0x06, 0xd2, 0x24, 0x9d,
+	0xa4, 0x45, 0x14, 0x09, 0xcb, 0x89, 0x4f, 0x52, 0x53, 0xc7, 0xf6, 0x8e, 0x9d, 0xd2, 0xf0, 0x0a,
+	0x3c, 0x00, 0xe2, 0x1d, 0x78, 0x29, 0xc4, 0x8b, 0xa0, 0x19, 0xdb, 0x49, 0x9c, 0xf4, 0x02, 0xfb,
+	0x97, 0x3d, 0xe7, 0xfc, 0xce, 0x6f, 0xe6, 0x5c, 0x67, 0xe0, 0xa9, 0xe3, 0xfa, 0xa6, 0x63, 0x7b,
+	0xbb, 0x6f, 0xa7, 0xc8, 0x66, 0xda, 0x95, 0x6e, 0x99, 0x86, 0xee, 0x63, 0xdd, 0x65, 0x8e, 0xef,
+	0x90, 0x82, 0xee, 0x5b, 0xba, 0x57, 0x17, 0xba, 0x72, 0x65, 0xec, 0x38, 0x63, 0x0b, 0x77, 0x85,
+	0x6a, 0x30, 0x1d, 0xed, 0x1a, 0xe8, 0x0d, 0x99, 0xe9, 0xfa, 0x0e, 0x0b, 0xe0, 0xd5, 0xbf, 0x36,
+	0xa0, 0x78, 0xc2, 0xb1, 0x67, 0x21, 0x0d, 0xd9, 0x87, 0xfc, 0xc8, 0xb4, 0x7c, 0x64, 0xa6, 0x3d,
+	0x56, 0xa4, 0x8a, 0x54, 0x2b, 0xbc, 0x7c, 0x5e, 0x5f, 0x22, 0xad, 0xc7, 0xe0, 0xf5, 0xd7, 0x11,
+	0x96, 0x2e, 0xcc, 0xc8, 0x57, 0x90, 0xf5, 0x1c, 0xe6, 0x73, 0x86, 0x84, 0x60, 0xa8, 0xde, 0xc1,
+	0xd0, 0x0b, 0x90, 0x34, 0x32, 0x21, 0x14, 0x1e, 0x8e, 0x4c, 0xb4, 0x0c, 0xcd, 0x43, 0x0b, 0x87,
+	0xdc, 0x57, 0x25, 0x29, 0x58, 0x3e, 0xba, 0xf3, 0x1c, 0x68, 0x19, 0xbd, 0xc8, 0x80, 0x96, 0x46,
+	0xb1, 0x35, 0x39, 0x00, 0xb8, 0xd2, 0xad, 0x29, 0x6a, 0xfe, 0xcc, 0x45, 0x25, 0x55, 0x91, 0x6a,
+	0xa5, 0x3b, 0xdd, 0x3a, 0xe3, 0xe0, 0xfe, 0xcc, 0x45, 0x9a, 0xbf, 0x8a, 0x7e, 0xc9, 0x73, 0x28,
+	0x2d, 0x48, 0xb4, 0x29, 0xb3, 0x94, 0x74, 0x45, 0xaa, 0xe5, 0xe9, 0xc6, 0x1c, 0x72, 0xca, 0x2c,
+	0xf2, 0x02, 0x1e, 0xa3, 0xad, 0x0f, 0x2c, 0xd4, 0x6c, 0xf4, 0x7c, 0x34, 0x34, 0x71, 0x14, 0x4f,
+	0xc9, 0x54, 0xa4, 0x5a, 0x8e, 0x92, 0x40, 0xd7, 0x16, 0x2a, 0x71, 0x68, 0x8f, 0x6c, 0x43, 0x31,
+	0x0e, 0xcd, 0x56, 0x92, 0x9c, 0xd6, 0x5e, 0x06, 0xbd, 0x82, 0xd4, 0x25, 0xce, 0x3c, 0x25, 0x57,
+	0x49, 0xde, 0x13, 0xd0, 0xef, 0x70, 0x46, 0xa7, 0x16, 0x52, 0x81, 0x27, 0xff, 0x83, 0xfc, 0x25,
+	0xce, 0x34, 0x86, 0x63, 0xbc, 0x56, 0xf2, 0xe2, 0xbc, 0xb9, 0x4b, 0x9c, 0x51, 0xbe, 0x26, 0x3f,
+	0x02, 0x61, 0xe8, 0xa2, 0xce, 0xf7, 0xf6, 0x70, 0xa2, 0xdb, 0xbe, 0x39, 0xf4, 0x14, 0x10, 0xe1,
+	0xf9, 0xe4, 0x8e, 0x2d, 0x68, 0x68, 0xd4, 0x8b, 0x6c, 0xe8, 0x26, 0x5b, 0x15, 0xf1, 0x9d, 0x27,
+	0xfa, 0xb5, 0x66, 0xa0, 0xeb, 0x5f, 0x28, 0x85, 0x8a, 0x54, 0x4b, 0xd3, 0xdc, 0x44, 0xbf, 0x3e,
+	0xe4, 0x6b, 0xf2, 0x06, 0x0a, 0x2e, 0xb2, 0x89, 0xe9, 0x79, 0xbc, 0x96, 0x95, 0x0d, 0x91, 0xe0,
+	0x0f, 0xee, 0xd8, 0xb2, 0xbb, 0x40, 0xd3, 0x65, 0x53, 0xf2, 0x14, 0xf2, 0x1e, 0xda, 0x9e, 0xe9,
+	0x9b, 0x57, 0xa8, 0x14, 0x45, 0x90, 0x17, 0x02, 0xb2, 0x03, 0x64, 0xbe, 0xd0, 0x9c, 0x2b, 0x64,
+	0xcc, 0x34, 0x50, 0x29, 0x09, 0xd8, 0xe6, 0x5c, 0xd3, 0x09, 0x15, 0xe4, 0x19, 0x80, 0x81, 0x2e,
+	0xc3, 0x21, 0x77, 0x45, 0x79, 0x28, 0x60, 0x4b, 0x92, 0xf2, 0x6f, 0x12, 0xe4, 0xe7, 0x25, 0x4f,
+	0xbe, 0x81, 0xb4, 0x6e, 0x59, 0xce, 0x2f, 0x8a, 0x54, 0x49, 0xd6, 0x4a, 0xf7, 0xd4, 0x27, 0x37,
+	0xea, 0xb8, 0xc8, 0x74, 0xdf, 0x61, 0x34, 0xb0, 0x23, 0x5f, 0x43, 0xca, 0x40, 0x7b, 0xa6, 0x24,
+	0xfe, 0xab, 0xbd, 0x30, 0x2b, 0x6f, 0x43, 0x36, 0xec, 0x1e, 0xa2, 0x40, 0xd6, 0x30, 0x3d, 0x5e,
+	0x5a, 0xa2, 0x69, 0x73, 0x34, 0x5a, 0x96, 0x4f, 0xa0, 0x14, 0x6f, 0x8e, 0xdb, 0xb1, 0xe4, 0x7d,
+	0x28, 0x31, 0x7c, 0x3b, 0x35, 0x19, 0x6a, 0xae, 0xce, 0xd0, 0xf6, 0x45, 0xff, 0xe6, 0x68, 0x31,
+	0x94, 0x76, 0x85, 0xb0, 0xfc, 0xa7, 0x04, 0xd9, 0xb0, 0xca, 0x38, 0x99, 0xab, 0xfb, 0x3e, 0x32,
+	0x5b, 0x90, 0xe5, 0x69, 0xb4, 0x5c, 0xe9, 0xb9, 0xc4, 0xbb, 0xf5, 0x5c, 0x6c, 0x1c, 0x25, 0xdf,
+	0x69, 0x1c, 0x95, 0x6d, 0x28, 0x74, 0xe3, 0x05, 0xb3, 0x3c, 0xe1, 0x78, 0xab, 0x2d, 0x04, 0xdc,
+	0x9f, 0xc5, 0xec, 0xe2, 0xba, 0x68, 0x49, 0x3e, 0xbc, 0x69, 0x2e, 0x71, 0xc4, 0xca, 0xb0, 0xa9,
+	0xfe, 0xcc, 0x23, 0xbe, 0x9c, 0x2e, 0x92, 0x81, 0x84, 0x7a, 0x22, 0x3f, 0x20, 0x79, 0x48, 0x1f,
+	0x37, 0xfa, 0x07, 0x6f, 0x64, 0x89, 0x8b, 0x8e, 0xfa, 0x72, 0x42, 0x7c, 0x55, 0x39, 0xc9, 0xbf,
+	0xad, 0xbe, 0x9c, 0x12, 0x5f, 0x55, 0x4e, 0x93, 0x2c, 0x24, 0x1b, 0xad, 0x96, 0x9c, 0xe1, 0x3f,
+	0x4d, 0xf5, 0x44, 0xce, 0x72, 0x4d, 0xb3, 0x2d, 0xe7, 0x48, 0x01, 0xb2, 0xcd, 0x9e, 0xd6, 0x3e,
+	0x6d, 0xb5, 0xe4, 0x7c, 0x75, 0x0f, 0xf2, 0xf3, 0xb8, 0x71, 0xcd, 0xa1, 0xfa, 0xba, 0x71, 0xda,
+	0xea, 0xcb, 0x0f, 0x08, 0x40, 0xa6, 0xd7, 0xa7, 0xcd, 0xf6, 0x91, 0x2c, 0xf1, 0xff, 0xf6, 0xe9,
+	0xf1, 0xbe, 0x4a, 0xe5, 0x04, 0xc9, 0x41, 0x6a, 0xbf, 0xd3, 0x69, 0xc9, 0xc9, 0x2a, 0xc2, 0xe6,
+	0x5a, 0x23, 0x93, 0x4d, 0x28, 0x52, 0xb5, 0xab, 0x36, 0xfa, 0xea, 0xa1, 0xd6, 0xee, 0xb4, 0x55,
+	0xf9, 0x01, 0x91, 0x61, 0x63, 0x2e, 0x6a, 0xb4, 0x7f, 0x90, 0x25, 0xf2, 0x04, 0x36, 0xe7, 0x92,
+	0x83, 0x4e, 0xbb, 0xdf, 0x68, 0xb6, 0x7b, 0xb2, 0x14, 0x07, 0xb6, 0x5a, 0x72, 0xa2, 0x9c, 0x90,
+	0xa5, 0xea, 0xdf, 0x49, 0x78, 0x7c, 0x8c, 0x9e, 0xa7, 0x8f, 0x31, 0x7e, 0xd5, 0x74, 0x21, 0x17,
+	0xdd, 0x5e, 0x22, 0x0f, 0x85, 0x97, 0x9f, 0xc5, 0x52, 0x7b, 0x93, 0x51, 0x3c, 0xdf, 0xaa, 0xed,
+	0xb3, 0x19, 0x9d, 0xb3, 0x90, 0xcf, 0x41, 0x59, 0x9e, 0xa4, 0xc1, 0xec, 0xd1, 0x2c, 0x73, 0x62,
+	0x06, 0x95, 0x9c, 0xa6, 0x4f, 0x96, 0x86, 0xaa, 0x98, 0x44, 0x2d, 0xae, 0xbc, 0x75, 0x68, 0x27,
+	0x6f, 0x1d, 0xda, 0x08, 0x8f, 0xa6, 0xb6, 0x3e, 0xf5, 0x2f, 0x1c, 0x66, 0xfe, 0xba, 0x30, 0x08,
+	0xae, 0x96, 0x7f, 0xe1, 0xc7, 0xe9, 0x92, 0x71, 0x40, 0x49, 0xc9, 0x74, 0x4d, 0x46, 0xb6, 0xa0,
+	0xe0, 0x0d, 0x1d, 0x17, 0x03, 0xfe, 0xf0, 0xc2, 0x01, 0x21, 0x12, 0x88, 0xf2, 0x39, 0x90, 0xf5,
+	0x90, 0x10, 0x02, 0x29, 0x5b, 0x9f, 0x60, 0xd8, 0x92, 0xe2, 0x9f, 0xbc, 0x80, 0xb4, 0xe8, 0xab,
+	0xf0, 0x4e, 0x2e, 0xdf, 0xde, 0x46, 0x34, 0x00, 0x56, 0x3f, 0x06, 0xb2, 0x7e, 0x4c, 0x5e, 0x4c,
+	0x54, 0xfd, 0x56, 0x3d, 0xe0, 0x45, 0x96, 0x83, 0xd4, 0x21, 0xed, 0x74, 0x65, 0xa9, 0xfa, 0x05,
+	0x90, 0x8e, 0x8d, 0xce, 0x28, 0x9e, 0xe2, 0x6d, 0x28, 0x7a, 0xa6, 0x3d, 0xb6, 0x50, 0x1b, 0x30,
+	0xdd, 0x1e, 0x5e, 0x84, 0x03, 0x67, 0x23, 0x10, 0xee, 0x0b, 0x59, 0xf5, 0x15, 0x3c, 0x3a, 0x46,
+	0xff, 0xc2, 0x31, 0xe2, 0xb6, 0x2b, 0xae, 0x4b, 0xab, 0xae, 0xef, 0x7d, 0xbf, 0xa8, 0x1f, 0xf2,
+	0xff, 0x7a, 0xf0, 0xd6, 0xa9, 0x47, 0x6f, 0x9d, 0xe0, 0x45, 0xd0, 0x09, 0xde, 0x4a, 0xca, 0x1f,
+	0xbf, 0x27, 0xef, 0x75, 0x7a, 0x4e, 0xb6, 0xf7, 0x13, 0x64, 0x27, 0x41, 0xce, 0xc8, 0xd6, 0x1a,
+	0x6f, 0x98, 0xcd, 0x55, 0xe6, 0xf7, 0xee, 0x4d, 0x39, 0x8d, 0x48, 0xf7, 0xce, 0x20, 0xed, 0xf0,
+	0x58, 0xdd, 0x70, 0x6a, 0x11, 0xc3, 0x55, 0xee, 0xad, 0x18, 0xf7, 0x7a, 0x98, 0x69, 0x40, 0xb7,
+	0x77, 0x0e, 0x99, 0x89, 0x08, 0x24, 0x79, 0x76, 0xc3, 0xb1, 0xb9, 0x62, 0x95, 0xb9, 0xb2, 0x72,
+	0xea, 0xb5, 0x2c, 0xd0, 0x90, 0x71, 0xbf, 0x79, 0x7e, 0x34, 0x36, 0xfd, 0x8b, 0xe9, 0xa0, 0x3e,
+	0x74, 0x26, 0xbb, 0xa6, 0x3d, 0x72, 0x06, 0x96, 0x73, 0xed, 0xb8, 0x68, 0x07, 0xcf, 0xcb, 0xe1,
+	0xce, 0x18, 0xed, 0x1d, 0xc1, 0xb6, 0x23, 0xd8, 0x76, 0xa2, 0x70, 0xee, 0x86, 0x0f, 0xd6, 0x2f,
+	0xc3, 0xef, 0x20, 0x23, 0x0c, 0x3e, 0xfd, 0x67, 0x00, 0x90, 0x39, 0x47, 0x83, 0xca, 0x0a, 0x00,
+	0x00,
 }
@@ -0,0 +1,81 @@
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Recursion describes a field of a recursive message type. Instead of
+// materializing the paths below the field, they are validated as the same
+// paths below Target, the path of the enclosing field of the same type or an
+// empty string for the result message itself.
+type Recursion struct {
+	Target string
+	// MaxDepth limits the number of path segments below the field, 0 means
+	// no limit.
+	MaxDepth int
+}
+
+// MethodRecursions holds recursive fields of a method keyed by the field
+// path for every collection operator.
+type MethodRecursions struct {
+	Filtering      map[string]Recursion
+	Sorting        map[string]Recursion
+	FieldSelection map[string]Recursion
+}
+
+// resolveRecursion rewrites the path going through recursive fields into the
+// path of the materialized field it stands for.
+func resolveRecursion(recursions map[string]Recursion, path []string) ([]string, error) {
+	if len(recursions) == 0 {
+		return path, nil
+	}
+
+	orig := strings.Join(path, ".")
+	for {
+		i := len(path) - 1
+		for ; i >= 1; i-- {
+			if _, ok := recursions[strings.Join(path[:i], ".")]; ok {
+				break
+			}
+		}
+		if i < 1 {
+			return path, nil
+		}
+
+		r := recursions[strings.Join(path[:i], ".")]
+		if r.MaxDepth > 0 && len(path)-i > r.MaxDepth {
+			return nil, fmt.Errorf("Field '%s' exceeds the maximum depth %d of '%s'", orig, r.MaxDepth, strings.Join(path[:i], "."))
+		}
+
+		var resolved []string
+		if r.Target != "" {
+			resolved = strings.Split(r.Target, ".")
+		}
+		path = append(resolved, path[i:]...)
+	}
+}
+
+// resolveFields returns the sorted distinct paths of the fields with the paths
+// going through recursive fields resolved. A path that cannot be resolved is
+// kept as is.
+func resolveFields(recursions map[string]Recursion, fields []string) []string {
+	if len(recursions) == 0 {
+		return fields
+	}
+
+	seen := make(map[string]struct{}, len(fields))
+	res := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if path, err := resolveRecursion(recursions, strings.Split(f, ".")); err == nil {
+			f = strings.Join(path, ".")
+		}
+		if _, ok := seen[f]; !ok {
+			seen[f] = struct{}{}
+			res = append(res, f)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
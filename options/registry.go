@@ -0,0 +1,224 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// MethodRules describes query validation rules of a method.
+type MethodRules struct {
+	Filtering      map[string]FilteringOption
+	Sorting        []string
+	FieldSelection []string
+	// FieldSelectionRequireParent are the fields that can only be selected
+	// as a part of their parent.
+	FieldSelectionRequireParent []string
+	// Oneofs are keyed by the dotted path of the oneof, e.g. "target".
+	Oneofs map[string]OneofRule
+	// Recursions are the recursive fields, paths below which are resolved
+	// at run time.
+	Recursions MethodRecursions
+	// Permissions are the roles required to use the fields in queries, they
+	// are checked by the context-aware validation methods only.
+	Permissions MethodPermissions
+	// DropUnauthorizedFields makes AuthorizeFieldSelection drop the selected
+	// fields the caller is not allowed to see rather than reject the request.
+	DropUnauthorizedFields bool
+	// Scope restricts the filter of the method to the scope of the caller,
+	// see MethodValidator.ScopeFiltering.
+	Scope *ScopeRule
+	// Columns are the database column names of the filterable and sortable
+	// fields differing from the names of the fields, keyed by the path of the
+	// field. They are informational unless MethodValidator.MapColumns is
+	// called explicitly.
+	Columns map[string]string
+	// Deprecated are the paths of the deprecated fields, the valid queries
+	// using them or the fields nested in them are reported by Warnings.
+	Deprecated []string
+	// Sensitive are the paths of the sensitive fields of the result message
+	// and of the selectable fields having selectable nested fields. They are
+	// never selectable, AuthorizeFieldSelection replaces a selected parent
+	// of a sensitive field by its other nested fields and leaves them out of
+	// the default selection.
+	Sensitive []string
+	// HTTPRules are the google.api.http bindings of the method, including
+	// the additional ones, which the gateway package routes REST requests by.
+	HTTPRules []HTTPRule
+}
+
+// HTTPRule is a google.api.http binding of a method, e.g. GET "/v1/users/{id}".
+// Method is the HTTP method, or the kind of a custom binding.
+type HTTPRule struct {
+	Method  string
+	Pattern string
+}
+
+// MethodRegistry keeps validators of methods keyed by the full method name,
+// e.g. "/example.TestService/List".
+type MethodRegistry struct {
+	mu         sync.RWMutex
+	validators map[string]*MethodValidator
+	principals PrincipalExtractor
+	authorizer QueryAuthorizer
+	scopes     ScopeExtractor
+}
+
+// Registry is populated by the generated code with validators of all methods
+// having query validation rules.
+var Registry = NewMethodRegistry()
+
+// NewMethodRegistry returns an empty registry.
+func NewMethodRegistry() *MethodRegistry {
+	return &MethodRegistry{validators: make(map[string]*MethodValidator)}
+}
+
+// DuplicateMethodError is returned by Register for a method already having a
+// validator, e.g. when the code generated from the same proto file is linked
+// into a binary twice.
+type DuplicateMethodError struct {
+	Method string
+}
+
+func (e *DuplicateMethodError) Error() string {
+	return fmt.Sprintf("atlas.query: duplicate validator registered for method %s", e.Method)
+}
+
+// Register adds the validator of the method to the registry. The validator
+// registered first is kept if the method already has one, in which case
+// *DuplicateMethodError is returned.
+func (r *MethodRegistry) Register(method string, v *MethodValidator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.validators[method]; ok {
+		return &DuplicateMethodError{Method: method}
+	}
+	if r.principals != nil {
+		v.SetPrincipalExtractor(r.principals)
+	}
+	if r.authorizer != nil {
+		v.SetQueryAuthorizer(r.authorizer)
+	}
+	if r.scopes != nil {
+		v.SetScopeExtractor(r.scopes)
+	}
+	v.setMethod(method)
+	r.validators[method] = v
+	return nil
+}
+
+// SetPrincipalExtractor sets the extractor of the caller of the validators
+// registered and to be registered, see MethodValidator.SetPrincipalExtractor.
+func (r *MethodRegistry) SetPrincipalExtractor(e PrincipalExtractor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.principals = e
+	for _, v := range r.validators {
+		v.SetPrincipalExtractor(e)
+	}
+}
+
+// SetQueryAuthorizer sets the query authorizer of the validators registered
+// and to be registered, see MethodValidator.SetQueryAuthorizer.
+func (r *MethodRegistry) SetQueryAuthorizer(a QueryAuthorizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.authorizer = a
+	for _, v := range r.validators {
+		v.SetQueryAuthorizer(a)
+	}
+}
+
+// SetScopeExtractor sets the extractor of the scope of the caller of the
+// validators registered and to be registered, see
+// MethodValidator.SetScopeExtractor.
+func (r *MethodRegistry) SetScopeExtractor(e ScopeExtractor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scopes = e
+	for _, v := range r.validators {
+		v.SetScopeExtractor(e)
+	}
+}
+
+// Validator returns the validator of the method.
+func (r *MethodRegistry) Validator(method string) (*MethodValidator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.validators[method]
+	return v, ok
+}
+
+// Methods returns sorted names of the registered methods.
+func (r *MethodRegistry) Methods() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	methods := make([]string, 0, len(r.validators))
+	for m := range r.validators {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Rules returns the rules the validator of the method was built from.
+func (r *MethodRegistry) Rules(method string) (MethodRules, bool) {
+	v, ok := r.Validator(method)
+	if !ok {
+		return MethodRules{}, false
+	}
+	return v.Rules(), true
+}
+
+// ValidateRequest validates collection operators of the request of the method.
+// Requests of methods missing in the registry are considered valid.
+func (r *MethodRegistry) ValidateRequest(method string, req interface{}) error {
+	v, ok := r.Validator(method)
+	if !ok {
+		return nil
+	}
+	return v.Validate(req)
+}
+
+// ValidateRequest validates collection operators of the request of the method
+// registered in Registry.
+func ValidateRequest(fullMethod string, req interface{}) error {
+	return Registry.ValidateRequest(fullMethod, req)
+}
+
+// ValidateRequestCtx validates collection operators of the request of the
+// method and checks the permissions of the caller extracted from ctx.
+// Requests of methods missing in the registry are considered valid.
+func (r *MethodRegistry) ValidateRequestCtx(ctx context.Context, method string, req interface{}) error {
+	v, ok := r.Validator(method)
+	if !ok {
+		return nil
+	}
+	return v.ValidateCtx(ctx, req)
+}
+
+// ValidateRequestCtx validates collection operators of the request of the
+// method registered in Registry and checks the permissions of the caller.
+func ValidateRequestCtx(ctx context.Context, fullMethod string, req interface{}) error {
+	return Registry.ValidateRequestCtx(ctx, fullMethod, req)
+}
+
+// ScopeFiltering restricts the filter of the request of the method to the
+// scope of the caller, see MethodValidator.ScopeFiltering. Methods missing in
+// the registry fail with *UnscopedMethodError.
+func (r *MethodRegistry) ScopeFiltering(ctx context.Context, method string, f *query.Filtering) (*query.Filtering, error) {
+	v, ok := r.Validator(method)
+	if !ok {
+		return nil, &UnscopedMethodError{Method: method}
+	}
+	return v.ScopeFiltering(ctx, f)
+}
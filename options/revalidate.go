@@ -0,0 +1,121 @@
+package options
+
+import (
+	"strings"
+)
+
+// FilterStatus is the result of revalidation of a stored filtering expression.
+type FilterStatus int
+
+const (
+	// FilterValid means the expression is still valid.
+	FilterValid FilterStatus = iota
+	// FilterInvalid means the expression is no longer valid.
+	FilterInvalid
+	// FilterFixable means the expression is invalid but becomes valid
+	// once renamed fields are replaced according to the aliases.
+	FilterFixable
+)
+
+var filterStatusName = map[FilterStatus]string{
+	FilterValid:   "VALID",
+	FilterInvalid: "INVALID",
+	FilterFixable: "FIXABLE",
+}
+
+func (s FilterStatus) String() string {
+	return filterStatusName[s]
+}
+
+// FilterReport describes a revalidated filtering expression. Err is the
+// validation error of the original expression and Fixed is the rewritten
+// expression of a fixable one.
+type FilterReport struct {
+	Expr   string
+	Status FilterStatus
+	Err    error
+	Fixed  string
+}
+
+// RevalidateFilters validates stored filtering expressions against messageInfo
+// and classifies them. aliases maps old field paths to the new ones; an alias
+// of a message field also applies to its nested fields.
+func RevalidateFilters(exprs []string, messageInfo map[string]FilteringOption, aliases map[string]string) []FilterReport {
+	return revalidateFilters(exprs, aliases, func(expr string) error {
+		return ValidateFilteringString(expr, messageInfo)
+	})
+}
+
+func revalidateFilters(exprs []string, aliases map[string]string, validate func(string) error) []FilterReport {
+	reports := make([]FilterReport, 0, len(exprs))
+	for _, expr := range exprs {
+		reports = append(reports, revalidateFilter(expr, aliases, validate))
+	}
+	return reports
+}
+
+func revalidateFilter(expr string, aliases map[string]string, validate func(string) error) FilterReport {
+	err := validate(expr)
+	if err == nil {
+		return FilterReport{Expr: expr, Status: FilterValid}
+	}
+
+	report := FilterReport{Expr: expr, Status: FilterInvalid, Err: err}
+	fixed, ok := applyFieldAliases(expr, aliases)
+	if ok && validate(fixed) == nil {
+		report.Status = FilterFixable
+		report.Fixed = fixed
+	}
+	return report
+}
+
+// applyFieldAliases replaces field paths of the expression according to
+// aliases and reports whether any of them was replaced.
+func applyFieldAliases(expr string, aliases map[string]string) (string, bool) {
+	if len(aliases) == 0 {
+		return expr, false
+	}
+
+	tokens, errOffset := scanExpression(expr)
+	if errOffset >= 0 {
+		return expr, false
+	}
+
+	var (
+		rs       = []rune(expr)
+		out      []rune
+		last     int
+		replaced bool
+	)
+	for _, t := range tokens {
+		if t.kind != exprField {
+			continue
+		}
+		alias, ok := lookupFieldAlias(t.text, aliases)
+		if !ok {
+			continue
+		}
+		out = append(out, rs[last:t.offset]...)
+		out = append(out, []rune(alias)...)
+		last = t.offset + len([]rune(t.text))
+		replaced = true
+	}
+	if !replaced {
+		return expr, false
+	}
+	return string(append(out, rs[last:]...)), true
+}
+
+// lookupFieldAlias returns the new path of the field, trying the full path
+// first and then its parent paths from the longest one.
+func lookupFieldAlias(path string, aliases map[string]string) (string, bool) {
+	if alias, ok := aliases[path]; ok {
+		return alias, true
+	}
+	for i := strings.LastIndex(path, "."); i > 0; i = strings.LastIndex(path[:i], ".") {
+		if alias, ok := aliases[path[:i]]; ok {
+			return alias + path[i:], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,157 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// ScopeRule restricts the queries of a method to the rows in the scope of
+// the caller, e.g. to the rows of the account of the caller.
+type ScopeRule struct {
+	// Field is the path of the field holding the scope, e.g. "account_id".
+	Field     string
+	ValueType QueryValidate_ValueType
+}
+
+// ScopeExtractor returns the scope of the caller from the context of a
+// request, e.g. the account identifier. An empty scope means the caller has
+// no scope.
+type ScopeExtractor interface {
+	ExtractScope(ctx context.Context) (string, error)
+}
+
+// ScopeExtractorFunc adapts a function to ScopeExtractor.
+type ScopeExtractorFunc func(ctx context.Context) (string, error)
+
+// ExtractScope calls f(ctx).
+func (f ScopeExtractorFunc) ExtractScope(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// UnscopedMethodError is returned by ScopeFiltering for a method having no
+// scope rule, or missing in the registry, so that a handler relying on the
+// scope of a method which lost its scope_field option fails rather than
+// returns the rows of all scopes.
+type UnscopedMethodError struct {
+	Method string
+}
+
+func (e *UnscopedMethodError) Error() string {
+	return fmt.Sprintf("atlas.query: no scope rule for method %s", e.Method)
+}
+
+type scopeKey struct{}
+
+// NewScopeContext returns a copy of ctx carrying the scope.
+func NewScopeContext(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope carried by ctx.
+func ScopeFromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(scopeKey{}).(string)
+	return s, ok
+}
+
+// ContextScopeExtractor returns the scope stored in the context by
+// NewScopeContext. It is used by validators having no extractor set.
+var ContextScopeExtractor ScopeExtractor = ScopeExtractorFunc(func(ctx context.Context) (string, error) {
+	s, _ := ScopeFromContext(ctx)
+	return s, nil
+})
+
+// SetScopeExtractor sets the extractor of the scope of the caller used by
+// ScopeFiltering, ContextScopeExtractor by default.
+func (v *MethodValidator) SetScopeExtractor(e ScopeExtractor) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.scopes = e
+}
+
+// ScopeFiltering returns the validated filter f restricted to the scope of
+// the caller extracted from ctx: the condition matching the scope is
+// conjoined with f at the root, so that no part of f can widen the result.
+// The filter may not refer to the scope field, otherwise the request is
+// rejected with *PermissionDeniedError, as is the request of a caller having
+// no scope. The method must have a scope rule, otherwise
+// *UnscopedMethodError is returned. f is not modified, the result shares its
+// conditions.
+func (v *MethodValidator) ScopeFiltering(ctx context.Context, f *query.Filtering) (*query.Filtering, error) {
+	rule := v.rules.Scope
+	if rule == nil {
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+		return nil, &UnscopedMethodError{Method: v.method}
+	}
+
+	if err := walkFiltering(f, func(path []string, _ interface{}) error {
+		field := strings.Join(path, ".")
+		if field == rule.Field || strings.HasPrefix(field, rule.Field+".") {
+			return &PermissionDeniedError{Parameter: filteringParameter, Field: field}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	extractor := v.scopes
+	v.mu.RUnlock()
+	if extractor == nil {
+		extractor = ContextScopeExtractor
+	}
+	scope, err := extractor.ExtractScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if scope == "" {
+		return nil, &PermissionDeniedError{Reason: fmt.Sprintf("no scope for '%s'", rule.Field)}
+	}
+
+	return conjoinScope(rule, scope, f)
+}
+
+func conjoinScope(rule *ScopeRule, scope string, f *query.Filtering) (*query.Filtering, error) {
+	path := strings.Split(rule.Field, ".")
+	op := &query.LogicalOperator{Type: query.LogicalOperator_AND}
+	res := &query.Filtering{}
+
+	if rule.ValueType == QueryValidate_NUMBER {
+		value, err := strconv.ParseFloat(scope, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid scope '%s' for '%s', expect a number", scope, rule.Field)
+		}
+		c := &query.NumberCondition{FieldPath: path, Value: value, Type: query.NumberCondition_EQ}
+		res.Root = &query.Filtering_NumberCondition{NumberCondition: c}
+		op.Left = &query.LogicalOperator_LeftNumberCondition{LeftNumberCondition: c}
+	} else {
+		c := &query.StringCondition{FieldPath: path, Value: scope, Type: query.StringCondition_EQ}
+		res.Root = &query.Filtering_StringCondition{StringCondition: c}
+		op.Left = &query.LogicalOperator_LeftStringCondition{LeftStringCondition: c}
+	}
+
+	switch val := f.GetRoot().(type) {
+	case *query.Filtering_Operator:
+		op.Right = &query.LogicalOperator_RightOperator{RightOperator: val.Operator}
+	case *query.Filtering_StringCondition:
+		op.Right = &query.LogicalOperator_RightStringCondition{RightStringCondition: val.StringCondition}
+	case *query.Filtering_NumberCondition:
+		op.Right = &query.LogicalOperator_RightNumberCondition{RightNumberCondition: val.NumberCondition}
+	case *query.Filtering_NullCondition:
+		op.Right = &query.LogicalOperator_RightNullCondition{RightNullCondition: val.NullCondition}
+	case *query.Filtering_StringArrayCondition:
+		op.Right = &query.LogicalOperator_RightStringArrayCondition{RightStringArrayCondition: val.StringArrayCondition}
+	case *query.Filtering_NumberArrayCondition:
+		op.Right = &query.LogicalOperator_RightNumberArrayCondition{RightNumberArrayCondition: val.NumberArrayCondition}
+	default:
+		// An empty filter is replaced by the scope condition.
+		return res, nil
+	}
+	res.Root = &query.Filtering_Operator{Operator: op}
+	return res, nil
+}
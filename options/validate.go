@@ -2,8 +2,10 @@ package options
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/infobloxopen/atlas-app-toolkit/query"
 )
@@ -16,179 +18,275 @@ type FilteringOptions struct {
 type FilteringOption struct {
 	ValueType QueryValidate_ValueType
 	Deny      []QueryValidate_FilterOperator
+	Allowed   FilterOperatorMask
+	// AllowedSet reports that Allowed is the complete mask of the allowed
+	// operators, so that an empty mask denies all of them, see
+	// AllowedOperators.
+	AllowedSet bool
+	// KeyPattern is a regular expression the keys of map and JSON fields
+	// matched by a wildcard rule, e.g. "labels.*", must match.
+	KeyPattern string
+	// Repeated is the semantics of conditions on a repeated field or on a
+	// field nested in a repeated message, whose ValueType is the element type.
+	Repeated QueryValidate_RepeatedSemantics
+	// Literal restricts the literals of the conditions on the field.
+	Literal *LiteralConstraint
+	// Nullable reports whether the field has presence, e.g. a message, a
+	// oneof member or an optional field, so that it may be checked for null.
+	Nullable bool
 }
 
 func getFieldInfo(path []string, messageInfo map[string]FilteringOption) (FilteringOption, error) {
+	fieldTag, key, ok := matchFieldTag(path, func(tag string) bool {
+		_, ok := messageInfo[tag]
+		return ok
+	})
+	if !ok {
+		return FilteringOption{}, fmt.Errorf("Unknown field: %s", fieldTag)
+	}
+
+	fieldInfo := messageInfo[fieldTag]
+	if key != "" && fieldInfo.KeyPattern != "" {
+		re, err := compileKeyPattern(fieldInfo.KeyPattern)
+		if err != nil {
+			return FilteringOption{}, fmt.Errorf("Invalid key pattern for '%s': %s", strings.TrimSuffix(fieldTag, ".*"), err)
+		}
+		if !re.MatchString(key) {
+			return FilteringOption{}, fmt.Errorf("Invalid key '%s' for '%s'", key, strings.TrimSuffix(fieldTag, ".*"))
+		}
+	}
+	return fieldInfo, nil
+}
+
+// matchFieldTag returns the rule key matching the field path, which is either
+// the full dotted path or the longest prefix of it followed by ".*". For a
+// wildcard rule it also returns the part of the path matched by the wildcard.
+func matchFieldTag(path []string, has func(string) bool) (string, string, bool) {
 	fieldTag := strings.Join(path, ".")
-	if fieldInfo, ok := messageInfo[fieldTag]; ok {
-		return fieldInfo, nil
+	if has(fieldTag) {
+		return fieldTag, "", true
 	}
 
-	if len(path) >= 1 {
-		fdPrefixTag := strings.Join(path[:1], ".") + ".*"
-		if fieldInfo, ok := messageInfo[fdPrefixTag]; ok {
-			return fieldInfo, nil
+	for i := len(path) - 1; i >= 1; i-- {
+		if fdPrefixTag := strings.Join(path[:i], ".") + ".*"; has(fdPrefixTag) {
+			return fdPrefixTag, strings.Join(path[i:], "."), true
 		}
 	}
 
-	return FilteringOption{}, fmt.Errorf("Unknown field: %s", fieldTag)
+	return fieldTag, "", false
+}
+
+// keyPatterns caches the compiled key patterns, so that the rules passed to
+// the package level validation functions on every call are compiled once.
+var keyPatterns sync.Map
+
+// compileKeyPattern compiles the key pattern to match whole keys only.
+func compileKeyPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := keyPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	keyPatterns.Store(pattern, re)
+	return re, nil
 }
 
 func ValidateFiltering(f *query.Filtering, messageInfo map[string]FilteringOption) error {
-	var getOperator func(interface{}) error
-	var fieldInfo FilteringOption
+	return walkConditions(f, messageConditionValidator(messageInfo))
+}
 
-	validate := func(path []string, f interface{}) error {
-		var err error
-		fieldTag := strings.Join(path, ".")
-		fieldInfo, err = getFieldInfo(path, messageInfo)
+// messageConditionValidator returns the function validating a condition
+// against messageInfo for the package level validation functions.
+func messageConditionValidator(messageInfo map[string]FilteringOption) func(path []string, c interface{}, negated bool) error {
+	return func(path []string, c interface{}, negated bool) error {
+		fieldInfo, err := getFieldInfo(path, messageInfo)
 		if err != nil {
 			return err
 		}
-
-		if fieldInfo.ValueType == QueryValidate_DEFAULT {
-			return fmt.Errorf("Filtering is not supported for field %s", fieldTag)
+		if err := validateCondition(strings.Join(path, "."), fieldInfo.ValueType, fieldInfo.AllowedOperators(), c); err != nil {
+			return err
+		}
+		literal, err := newLiteralChecker(fieldInfo.Literal)
+		if err != nil {
+			return fmt.Errorf("Invalid literal pattern for '%s': %s", strings.Join(path, "."), err)
 		}
+		return literal.validate(strings.Join(path, "."), c, negated)
+	}
+}
 
-		tp := ""
+func validateCondition(fieldTag string, valueType QueryValidate_ValueType, allowed FilterOperatorMask, f interface{}) error {
+	if valueType == QueryValidate_DEFAULT {
+		return fmt.Errorf("Filtering is not supported for field %s", fieldTag)
+	}
 
-		switch x := f.(type) {
-		case *query.StringCondition:
-			if fieldInfo.ValueType != QueryValidate_STRING && fieldInfo.ValueType != QueryValidate_BOOL {
-				return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, fieldInfo.ValueType)
-			}
+	var (
+		tp string
+		op QueryValidate_FilterOperator
+		ok bool
+	)
 
-			if fieldInfo.ValueType == QueryValidate_BOOL {
+	switch x := f.(type) {
+	case *query.StringCondition:
+		if valueType != QueryValidate_STRING && valueType != QueryValidate_BOOL {
+			return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, valueType)
+		}
 
-				if x.Type != query.StringCondition_EQ {
-					return fmt.Errorf("Operation %s is not allowed for %q", query.StringCondition_Type_name[int32(x.Type)], fieldTag)
-				}
+		if valueType == QueryValidate_BOOL {
 
-				if _, err := strconv.ParseBool(x.Value); err != nil {
-					return fmt.Errorf("Got invalid literal for field %q of type %s, expect 'true' or 'false'", fieldTag, fieldInfo.ValueType)
-				}
+			if x.Type != query.StringCondition_EQ {
+				return fmt.Errorf("Operation %s is not allowed for %q", query.StringCondition_Type_name[int32(x.Type)], fieldTag)
 			}
 
-			sc := &query.Filtering_StringCondition{x}
-			tp = query.StringCondition_Type_name[int32(sc.StringCondition.Type)]
-		case *query.NumberCondition:
-			if fieldInfo.ValueType != QueryValidate_NUMBER {
-				return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, fieldInfo.ValueType)
-			}
-			nc := &query.Filtering_NumberCondition{x}
-			tp = query.NumberCondition_Type_name[int32(nc.NumberCondition.Type)]
-		case *query.StringArrayCondition:
-			if fieldInfo.ValueType != QueryValidate_STRING && fieldInfo.ValueType != QueryValidate_BOOL {
-				return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, fieldInfo.ValueType)
+			if _, err := strconv.ParseBool(x.Value); err != nil {
+				return fmt.Errorf("Got invalid literal for field %q of type %s, expect 'true' or 'false'", fieldTag, valueType)
 			}
+		}
 
-			if fieldInfo.ValueType == QueryValidate_BOOL {
-				for i, xv := range x.Values {
-					if _, err := strconv.ParseBool(xv); err != nil {
-						return fmt.Errorf("Got invalid literal for field %q of type %s at position %d, expect 'true' or 'false'", fieldTag, fieldInfo.ValueType, i)
-					}
-				}
-			}
+		tp = query.StringCondition_Type_name[int32(x.Type)]
+		op, ok = StringConditionOperator(x.Type)
+	case *query.NumberCondition:
+		if valueType != QueryValidate_NUMBER {
+			return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, valueType)
+		}
+		tp = query.NumberCondition_Type_name[int32(x.Type)]
+		op, ok = NumberConditionOperator(x.Type)
+	case *query.StringArrayCondition:
+		if valueType != QueryValidate_STRING && valueType != QueryValidate_BOOL {
+			return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, valueType)
+		}
 
-			nc := &query.Filtering_StringArrayCondition{x}
-			tp = query.StringArrayCondition_Type_name[int32(nc.StringArrayCondition.Type)]
-		case *query.NumberArrayCondition:
-			if fieldInfo.ValueType != QueryValidate_NUMBER {
-				return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, fieldInfo.ValueType)
+		if valueType == QueryValidate_BOOL {
+			for i, xv := range x.Values {
+				if _, err := strconv.ParseBool(xv); err != nil {
+					return fmt.Errorf("Got invalid literal for field %q of type %s at position %d, expect 'true' or 'false'", fieldTag, valueType, i)
+				}
 			}
-			nc := &query.Filtering_NumberArrayCondition{x}
-			tp = query.NumberArrayCondition_Type_name[int32(nc.NumberArrayCondition.Type)]
-		default:
-
-			return nil
 		}
-		for _, val := range fieldInfo.Deny {
-			if val == QueryValidate_ALL {
-				return fmt.Errorf("Operation %s is not allowed for '%s'", tp, fieldTag)
-			}
-			if val.String() == tp {
-				return fmt.Errorf("Operation %s is not allowed for '%s'", tp, fieldTag)
-			}
+
+		tp = query.StringArrayCondition_Type_name[int32(x.Type)]
+		op, ok = StringArrayConditionOperator(x.Type)
+	case *query.NumberArrayCondition:
+		if valueType != QueryValidate_NUMBER {
+			return fmt.Errorf("Got invalid literal type for %s, expect %s", fieldTag, valueType)
 		}
+		tp = query.NumberArrayCondition_Type_name[int32(x.Type)]
+		op, ok = NumberArrayConditionOperator(x.Type)
+	case *query.NullCondition:
+		tp, op, ok = QueryValidate_IS_NULL.String(), QueryValidate_IS_NULL, true
+	default:
+
 		return nil
 	}
 
-	var vres error
+	if !ok || !allowed.Has(op) {
+		return fmt.Errorf("Operation %s is not allowed for '%s'", tp, fieldTag)
+	}
+	return nil
+}
 
-	getOperator = func(f interface{}) error {
-		val := f.(*query.LogicalOperator)
-		var vres error
-		left := val.GetLeft()
-		switch leftVal := left.(type) {
-		case *query.LogicalOperator_LeftOperator:
-			vres = getOperator(leftVal.LeftOperator)
+// walkConditions calls fn for the conditions of f from left to right, like
+// walkFiltering, telling whether a condition is negated an odd number of
+// times by itself and by the enclosing logical operators.
+func walkConditions(f *query.Filtering, fn func(path []string, c interface{}, negated bool) error) error {
+	return walkConditionNode(filteringRoot(f), false, fn)
+}
 
-		case *query.LogicalOperator_LeftStringCondition:
-			vres = validate(leftVal.LeftStringCondition.GetFieldPath(), leftVal.LeftStringCondition)
+func walkConditionNode(node interface{}, negated bool, fn func(path []string, c interface{}, negated bool) error) error {
+	switch c := node.(type) {
+	case *query.LogicalOperator:
+		left, right := logicalOperands(c)
+		if err := walkConditionNode(left, negated != c.GetIsNegative(), fn); err != nil {
+			return err
+		}
+		return walkConditionNode(right, negated != c.GetIsNegative(), fn)
+	case *query.StringCondition:
+		return fn(c.GetFieldPath(), c, negated != c.GetIsNegative())
+	case *query.NumberCondition:
+		return fn(c.GetFieldPath(), c, negated != c.GetIsNegative())
+	case *query.NullCondition:
+		return fn(c.GetFieldPath(), c, negated != c.GetIsNegative())
+	case *query.StringArrayCondition:
+		return fn(c.GetFieldPath(), c, negated != c.GetIsNegative())
+	case *query.NumberArrayCondition:
+		return fn(c.GetFieldPath(), c, negated != c.GetIsNegative())
+	}
+	return nil
+}
 
-		case *query.LogicalOperator_LeftNumberCondition:
-			vres = validate(leftVal.LeftNumberCondition.GetFieldPath(), leftVal.LeftNumberCondition)
+// walkFiltering calls fn for every condition of the filtering tree in
+// left-to-right order and stops at the first error.
+func walkFiltering(f *query.Filtering, fn func(path []string, c interface{}) error) error {
+	switch val := f.GetRoot().(type) {
+	case *query.Filtering_Operator:
+		return walkLogicalOperator(val.Operator, fn)
 
-		case *query.LogicalOperator_LeftNullCondition:
-			vres = validate(leftVal.LeftNullCondition.GetFieldPath(), leftVal.LeftNullCondition)
+	case *query.Filtering_StringCondition:
+		return fn(val.StringCondition.GetFieldPath(), val.StringCondition)
 
-		case *query.LogicalOperator_LeftStringArrayCondition:
-			vres = validate(leftVal.LeftStringArrayCondition.GetFieldPath(), leftVal.LeftStringArrayCondition)
+	case *query.Filtering_NumberCondition:
+		return fn(val.NumberCondition.GetFieldPath(), val.NumberCondition)
 
-		case *query.LogicalOperator_LeftNumberArrayCondition:
-			vres = validate(leftVal.LeftNumberArrayCondition.GetFieldPath(), leftVal.LeftNumberArrayCondition)
-		}
+	case *query.Filtering_NullCondition:
+		return fn(val.NullCondition.GetFieldPath(), val.NullCondition)
 
-		if vres != nil {
-			return vres
-		}
+	case *query.Filtering_StringArrayCondition:
+		return fn(val.StringArrayCondition.GetFieldPath(), val.StringArrayCondition)
+
+	case *query.Filtering_NumberArrayCondition:
+		return fn(val.NumberArrayCondition.GetFieldPath(), val.NumberArrayCondition)
+	}
+	return nil
+}
 
-		right := val.GetRight()
-		switch rightVal := right.(type) {
-		case *query.LogicalOperator_RightOperator:
-			getOperator(rightVal.RightOperator)
+func walkLogicalOperator(val *query.LogicalOperator, fn func(path []string, c interface{}) error) error {
+	var vres error
 
-		case *query.LogicalOperator_RightStringCondition:
-			vres = validate(rightVal.RightStringCondition.GetFieldPath(), rightVal.RightStringCondition)
+	switch leftVal := val.GetLeft().(type) {
+	case *query.LogicalOperator_LeftOperator:
+		vres = walkLogicalOperator(leftVal.LeftOperator, fn)
 
-		case *query.LogicalOperator_RightNumberCondition:
-			vres = validate(rightVal.RightNumberCondition.GetFieldPath(), rightVal.RightNumberCondition)
+	case *query.LogicalOperator_LeftStringCondition:
+		vres = fn(leftVal.LeftStringCondition.GetFieldPath(), leftVal.LeftStringCondition)
 
-		case *query.LogicalOperator_RightNullCondition:
-			vres = validate(rightVal.RightNullCondition.GetFieldPath(), rightVal.RightNullCondition)
+	case *query.LogicalOperator_LeftNumberCondition:
+		vres = fn(leftVal.LeftNumberCondition.GetFieldPath(), leftVal.LeftNumberCondition)
 
-		case *query.LogicalOperator_RightStringArrayCondition:
-			vres = validate(rightVal.RightStringArrayCondition.GetFieldPath(), rightVal.RightStringArrayCondition)
+	case *query.LogicalOperator_LeftNullCondition:
+		vres = fn(leftVal.LeftNullCondition.GetFieldPath(), leftVal.LeftNullCondition)
 
-		case *query.LogicalOperator_RightNumberArrayCondition:
-			vres = validate(rightVal.RightNumberArrayCondition.GetFieldPath(), rightVal.RightNumberArrayCondition)
-		}
+	case *query.LogicalOperator_LeftStringArrayCondition:
+		vres = fn(leftVal.LeftStringArrayCondition.GetFieldPath(), leftVal.LeftStringArrayCondition)
 
+	case *query.LogicalOperator_LeftNumberArrayCondition:
+		vres = fn(leftVal.LeftNumberArrayCondition.GetFieldPath(), leftVal.LeftNumberArrayCondition)
+	}
+
+	if vres != nil {
 		return vres
 	}
 
-	if f != nil {
-		root := f.GetRoot()
-		switch val := root.(type) {
-		case *query.Filtering_Operator:
-			vres = getOperator(val.Operator)
+	switch rightVal := val.GetRight().(type) {
+	case *query.LogicalOperator_RightOperator:
+		vres = walkLogicalOperator(rightVal.RightOperator, fn)
 
-		case *query.Filtering_StringCondition:
-			vres = validate(val.StringCondition.GetFieldPath(), val.StringCondition)
+	case *query.LogicalOperator_RightStringCondition:
+		vres = fn(rightVal.RightStringCondition.GetFieldPath(), rightVal.RightStringCondition)
 
-		case *query.Filtering_NumberCondition:
-			vres = validate(val.NumberCondition.GetFieldPath(), val.NumberCondition)
+	case *query.LogicalOperator_RightNumberCondition:
+		vres = fn(rightVal.RightNumberCondition.GetFieldPath(), rightVal.RightNumberCondition)
 
-		case *query.Filtering_NullCondition:
-			vres = validate(val.NullCondition.GetFieldPath(), val.NullCondition)
+	case *query.LogicalOperator_RightNullCondition:
+		vres = fn(rightVal.RightNullCondition.GetFieldPath(), rightVal.RightNullCondition)
 
-		case *query.Filtering_StringArrayCondition:
-			vres = validate(val.StringArrayCondition.GetFieldPath(), val.StringArrayCondition)
+	case *query.LogicalOperator_RightStringArrayCondition:
+		vres = fn(rightVal.RightStringArrayCondition.GetFieldPath(), rightVal.RightStringArrayCondition)
 
-		case *query.Filtering_NumberArrayCondition:
-			vres = validate(val.NumberArrayCondition.GetFieldPath(), val.NumberArrayCondition)
-		}
+	case *query.LogicalOperator_RightNumberArrayCondition:
+		vres = fn(rightVal.RightNumberArrayCondition.GetFieldPath(), rightVal.RightNumberArrayCondition)
 	}
+
 	return vres
 }
 
@@ -212,22 +310,14 @@ func ValidateSorting(p *query.Sorting, fields []string) error {
 }
 
 func ValidateFieldSelection(fs *query.FieldSelection, allowedFields []string) error {
-	var flatten func(fields map[string]*query.Field) []string
-	flatten = func(fields map[string]*query.Field) []string {
-		var flatFields []string
-		for _, v := range fields {
-			if v.GetSubs() != nil {
-				subFields := flatten(v.GetSubs())
-				for _, i := range subFields {
-					flatFields = append(flatFields, v.GetName()+"."+i)
-				}
+	flatFields := flattenFieldSelection(fs.GetFields())
+	for _, f := range flatFields {
+		if path := strings.Split(f, "."); isFieldPattern(path) {
+			if len(matchFieldPattern(path, allowedFields)) == 0 {
+				return fmt.Errorf("Unknown field: '%s'", f)
 			}
-			flatFields = append(flatFields, v.GetName())
+			continue
 		}
-		return flatFields
-	}
-	flatFields := flatten(fs.GetFields())
-	for _, f := range flatFields {
 		var ok bool
 		for _, v := range allowedFields {
 			if f == v {
@@ -241,3 +331,17 @@ func ValidateFieldSelection(fs *query.FieldSelection, allowedFields []string) er
 	}
 	return nil
 }
+
+func flattenFieldSelection(fields map[string]*query.Field) []string {
+	var flatFields []string
+	for _, v := range fields {
+		if v.GetSubs() != nil {
+			subFields := flattenFieldSelection(v.GetSubs())
+			for _, i := range subFields {
+				flatFields = append(flatFields, v.GetName()+"."+i)
+			}
+		}
+		flatFields = append(flatFields, v.GetName())
+	}
+	return flatFields
+}
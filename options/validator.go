@@ -0,0 +1,439 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// QueryGetter extracts collection operators from a request message.
+// Any of the returned values may be nil if the request does not carry it.
+type QueryGetter func(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection)
+
+// MethodValidator holds query validation rules of a single RPC compiled
+// into a form that does not require scanning the rules on every call.
+// A nil set of rules disables validation of the corresponding parameter.
+type MethodValidator struct {
+	rules          MethodRules
+	filtering      map[string]filteringRule
+	sorting        map[string]struct{}
+	fieldSelection map[string]struct{}
+	oneofMembers   map[string]string
+	requireParent  map[string]struct{}
+	getQuery       QueryGetter
+	deprecation    deprecation
+
+	// mu guards the fields set after construction, which may be set by a
+	// registry while requests are validated.
+	mu         sync.RWMutex
+	principals PrincipalExtractor
+	method     string
+	authorizer QueryAuthorizer
+	scopes     ScopeExtractor
+}
+
+type filteringRule struct {
+	valueType  QueryValidate_ValueType
+	allowed    FilterOperatorMask
+	keyPattern *regexp.Regexp
+	literal    *literalChecker
+}
+
+// NewMethodValidator compiles filtering, sorting and field selection rules
+// of a method into a MethodValidator.
+func NewMethodValidator(filtering map[string]FilteringOption, sorting []string, fieldSelection []string, getQuery QueryGetter) (*MethodValidator, error) {
+	return NewRulesValidator(MethodRules{Filtering: filtering, Sorting: sorting, FieldSelection: fieldSelection}, getQuery)
+}
+
+// MustRulesValidator is like NewRulesValidator but panics if the rules are
+// invalid. It is used by the generated code, whose rules are checked by the
+// plugin.
+func MustRulesValidator(rules MethodRules, getQuery QueryGetter) *MethodValidator {
+	v, err := NewRulesValidator(rules, getQuery)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// NewRulesValidator compiles the rules of a method into a MethodValidator.
+// It returns an error if a key or literal pattern of the filtering rules is
+// not a valid regular expression.
+func NewRulesValidator(rules MethodRules, getQuery QueryGetter) (*MethodValidator, error) {
+	v := &MethodValidator{
+		rules:       rules,
+		getQuery:    getQuery,
+		deprecation: newDeprecation(rules.Deprecated),
+	}
+
+	if rules.Filtering != nil {
+		v.filtering = make(map[string]filteringRule, len(rules.Filtering))
+		for tag, o := range rules.Filtering {
+			literal, err := newLiteralChecker(o.Literal)
+			if err != nil {
+				return nil, fmt.Errorf("invalid literal pattern for '%s': %s", tag, err)
+			}
+			rule := filteringRule{valueType: o.ValueType, allowed: o.AllowedOperators(), literal: literal}
+			if o.KeyPattern != "" {
+				re, err := compileKeyPattern(o.KeyPattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key pattern for '%s': %s", strings.TrimSuffix(tag, ".*"), err)
+				}
+				rule.keyPattern = re
+			}
+			v.filtering[tag] = rule
+		}
+	}
+
+	if rules.Sorting != nil {
+		v.sorting = make(map[string]struct{}, len(rules.Sorting))
+		for _, tag := range rules.Sorting {
+			v.sorting[tag] = struct{}{}
+		}
+	}
+
+	if rules.FieldSelection != nil {
+		v.fieldSelection = make(map[string]struct{}, len(rules.FieldSelection))
+		for _, tag := range rules.FieldSelection {
+			v.fieldSelection[tag] = struct{}{}
+		}
+	}
+
+	if len(rules.FieldSelectionRequireParent) > 0 {
+		v.requireParent = make(map[string]struct{}, len(rules.FieldSelectionRequireParent))
+		for _, tag := range rules.FieldSelectionRequireParent {
+			v.requireParent[tag] = struct{}{}
+		}
+	}
+
+	if len(rules.Oneofs) > 0 {
+		v.oneofMembers = make(map[string]string)
+		for oneof, rule := range rules.Oneofs {
+			for _, m := range rule.Members {
+				v.oneofMembers[m] = oneof
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// Rules returns the rules the validator was built from.
+func (v *MethodValidator) Rules() MethodRules {
+	return v.rules
+}
+
+// Query returns the collection operators carried by the request, any of which
+// may be nil.
+func (v *MethodValidator) Query(req interface{}) (*query.Filtering, *query.Sorting, *query.FieldSelection) {
+	if v.getQuery == nil {
+		return nil, nil, nil
+	}
+	return v.getQuery(req)
+}
+
+// Validate validates all collection operators carried by the request.
+func (v *MethodValidator) Validate(req interface{}) error {
+	if v.getQuery == nil {
+		return nil
+	}
+
+	f, s, fs := v.getQuery(req)
+	if err := v.ValidateFiltering(f); err != nil {
+		return err
+	}
+	if err := v.ValidateSorting(s); err != nil {
+		return err
+	}
+	return v.ValidateFieldSelection(fs)
+}
+
+// SetPrincipalExtractor sets the extractor of the caller used by the
+// context-aware validation methods, ContextPrincipalExtractor by default.
+func (v *MethodValidator) SetPrincipalExtractor(e PrincipalExtractor) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.principals = e
+}
+
+// SetQueryAuthorizer sets the authorizer consulted by ValidateCtx and
+// AuthorizeQuery, none by default.
+func (v *MethodValidator) SetQueryAuthorizer(a QueryAuthorizer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.authorizer = a
+}
+
+// setMethod sets the name of the method the validator is registered for.
+func (v *MethodValidator) setMethod(method string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.method = method
+}
+
+// AuthorizeQuery asks the query authorizer whether the caller extracted from
+// ctx may run the query, any part of which may be nil. The query is expected
+// to be valid. The method name is known to the validators of a registry only.
+func (v *MethodValidator) AuthorizeQuery(ctx context.Context, f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) error {
+	v.mu.RLock()
+	authorizer, method := v.authorizer, v.method
+	v.mu.RUnlock()
+	if authorizer == nil {
+		return nil
+	}
+
+	principal, err := v.principal(ctx)
+	if err != nil {
+		return err
+	}
+	return authorizer.AuthorizeQuery(ctx, &AuthorizationInput{
+		Method:    method,
+		Principal: principal,
+		Query:     v.queryInput(f, s, fs),
+	})
+}
+
+// queryInput normalizes the query like NewQueryInput and resolves the paths
+// of the field lists going through recursive fields, so that the policies
+// see the paths of the fields they stand for.
+func (v *MethodValidator) queryInput(f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) *QueryInput {
+	in := NewQueryInput(f, s, fs)
+	in.FilterFields = resolveFields(v.rules.Recursions.Filtering, in.FilterFields)
+	in.SortFields = resolveFields(v.rules.Recursions.Sorting, in.SortFields)
+	in.Fields = resolveFields(v.rules.Recursions.FieldSelection, in.Fields)
+	return in
+}
+
+func (v *MethodValidator) principal(ctx context.Context) (*Principal, error) {
+	v.mu.RLock()
+	extractor := v.principals
+	v.mu.RUnlock()
+	if extractor == nil {
+		extractor = ContextPrincipalExtractor
+	}
+	return extractor.ExtractPrincipal(ctx)
+}
+
+// ValidateCtx validates all collection operators carried by the request,
+// checks that the caller is allowed to use the fields they refer to and
+// finally asks the query authorizer, if any, see AuthorizeQuery.
+func (v *MethodValidator) ValidateCtx(ctx context.Context, req interface{}) error {
+	if v.getQuery == nil {
+		return nil
+	}
+
+	f, s, fs := v.getQuery(req)
+	if err := v.ValidateFilteringCtx(ctx, f); err != nil {
+		return err
+	}
+	if err := v.ValidateSortingCtx(ctx, s); err != nil {
+		return err
+	}
+	if err := v.ValidateFieldSelectionCtx(ctx, fs); err != nil {
+		return err
+	}
+	return v.AuthorizeQuery(ctx, f, s, fs)
+}
+
+// ValidateFiltering validates f against the filtering rules of the method.
+func (v *MethodValidator) ValidateFiltering(f *query.Filtering) error {
+	if v.filtering == nil {
+		return nil
+	}
+	if err := walkConditions(f, v.validateCondition); err != nil {
+		return err
+	}
+	return v.validateOneofs(f)
+}
+
+// ValidateFilteringString parses the filtering expression and validates it
+// against the filtering rules of the method. Parse and validation errors are
+// reported as *ExpressionError. Only syntax errors are reported if the method
+// has no filtering rules.
+func (v *MethodValidator) ValidateFilteringString(expr string) error {
+	_, err := v.parseFilteringString(expr)
+	return err
+}
+
+func (v *MethodValidator) parseFilteringString(expr string) (*query.Filtering, error) {
+	if v.filtering == nil {
+		return ParseFilteringString(expr)
+	}
+	return validateFilteringString(expr, v.validateCondition, v.validateOneofs)
+}
+
+// ValidateFilteringCtx validates f against the filtering rules of the method
+// and checks that the caller extracted from ctx is allowed to filter by the
+// fields of f. Permission errors are reported as *PermissionDeniedError.
+func (v *MethodValidator) ValidateFilteringCtx(ctx context.Context, f *query.Filtering) error {
+	if err := v.ValidateFiltering(f); err != nil {
+		return err
+	}
+	return v.checkFilteringPermissions(ctx, f)
+}
+
+// ValidateFilteringStringCtx validates the filtering expression like
+// ValidateFilteringString and checks permissions like ValidateFilteringCtx.
+func (v *MethodValidator) ValidateFilteringStringCtx(ctx context.Context, expr string) error {
+	_, err := v.ParseFilteringStringCtx(ctx, expr)
+	return err
+}
+
+// ParseFilteringStringCtx validates the filtering expression like
+// ValidateFilteringStringCtx and returns the parsed filtering, so that the
+// expression is parsed once.
+func (v *MethodValidator) ParseFilteringStringCtx(ctx context.Context, expr string) (*query.Filtering, error) {
+	f, err := v.parseFilteringString(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkFilteringPermissions(ctx, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (v *MethodValidator) checkFilteringPermissions(ctx context.Context, f *query.Filtering) error {
+	if v.filtering == nil || len(v.rules.Permissions.Filtering) == 0 {
+		return nil
+	}
+
+	principal, err := v.principal(ctx)
+	if err != nil {
+		return err
+	}
+	return walkFiltering(f, func(path []string, _ interface{}) error {
+		resolved, err := resolveRecursion(v.rules.Recursions.Filtering, path)
+		if err != nil {
+			return err
+		}
+		return checkPermissions(v.rules.Permissions.Filtering, principal, filteringParameter, strings.Join(path, "."), path, resolved)
+	})
+}
+
+// RevalidateFilters classifies stored filtering expressions against the
+// filtering rules of the method, see options.RevalidateFilters.
+func (v *MethodValidator) RevalidateFilters(exprs []string, aliases map[string]string) []FilterReport {
+	return revalidateFilters(exprs, aliases, v.ValidateFilteringString)
+}
+
+func (v *MethodValidator) validateCondition(path []string, c interface{}, negated bool) error {
+	resolved, err := resolveRecursion(v.rules.Recursions.Filtering, path)
+	if err != nil {
+		return err
+	}
+	fieldTag, key, ok := matchFieldTag(resolved, func(tag string) bool {
+		_, ok := v.filtering[tag]
+		return ok
+	})
+	if !ok {
+		return fmt.Errorf("Unknown field: %s", strings.Join(path, "."))
+	}
+	rule := v.filtering[fieldTag]
+	if key != "" && rule.keyPattern != nil && !rule.keyPattern.MatchString(key) {
+		return fmt.Errorf("Invalid key '%s' for '%s'", key, strings.TrimSuffix(fieldTag, ".*"))
+	}
+	if err := validateCondition(strings.Join(path, "."), rule.valueType, rule.allowed, c); err != nil {
+		return err
+	}
+	return rule.literal.validate(strings.Join(path, "."), c, negated)
+}
+
+// ValidateSorting validates s against the sorting rules of the method.
+func (v *MethodValidator) ValidateSorting(s *query.Sorting) error {
+	if v.sorting == nil {
+		return nil
+	}
+
+	for _, criteria := range s.GetCriterias() {
+		resolved, err := resolveRecursion(v.rules.Recursions.Sorting, strings.Split(criteria.GetTag(), "."))
+		if err != nil {
+			return err
+		}
+		if _, ok := v.sorting[strings.Join(resolved, ".")]; !ok {
+			return fmt.Errorf("Sorting is not allowed for '%s'", criteria.GetTag())
+		}
+	}
+	return nil
+}
+
+// ValidateSortingCtx validates s against the sorting rules of the method and
+// checks that the caller extracted from ctx is allowed to sort by the fields.
+func (v *MethodValidator) ValidateSortingCtx(ctx context.Context, s *query.Sorting) error {
+	if err := v.ValidateSorting(s); err != nil {
+		return err
+	}
+	if v.sorting == nil || len(v.rules.Permissions.Sorting) == 0 {
+		return nil
+	}
+
+	principal, err := v.principal(ctx)
+	if err != nil {
+		return err
+	}
+	for _, criteria := range s.GetCriterias() {
+		path := strings.Split(criteria.GetTag(), ".")
+		resolved, err := resolveRecursion(v.rules.Recursions.Sorting, path)
+		if err != nil {
+			return err
+		}
+		if err := checkPermissions(v.rules.Permissions.Sorting, principal, sortingParameter, criteria.GetTag(), path, resolved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateFieldSelection validates fs against the field selection rules of the method.
+// Selecting a field selects all its subfields, "*" selects any field of the
+// message, e.g. "address.*".
+func (v *MethodValidator) ValidateFieldSelection(fs *query.FieldSelection) error {
+	if v.fieldSelection == nil {
+		return nil
+	}
+
+	for _, f := range flattenFieldSelection(fs.GetFields()) {
+		resolved, err := resolveRecursion(v.rules.Recursions.FieldSelection, strings.Split(f, "."))
+		if err != nil {
+			return err
+		}
+		if err := v.validateSelectedField(f, resolved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateFieldSelectionCtx validates fs against the field selection rules of
+// the method and checks that the caller extracted from ctx is allowed to
+// select the fields, the fields matched by wildcards and all their nested
+// fields, so selecting a parent of a field the caller is not allowed to see
+// is denied. No fields are checked if the rules drop unauthorized fields. An
+// empty selection selects all fields and is not denied, it should be narrowed
+// by AuthorizeFieldSelection.
+func (v *MethodValidator) ValidateFieldSelectionCtx(ctx context.Context, fs *query.FieldSelection) error {
+	if err := v.ValidateFieldSelection(fs); err != nil {
+		return err
+	}
+	if v.fieldSelection == nil || len(v.rules.Permissions.FieldSelection) == 0 || v.rules.DropUnauthorizedFields {
+		return nil
+	}
+
+	principal, err := v.principal(ctx)
+	if err != nil {
+		return err
+	}
+	for _, f := range leafFieldSelection(fs.GetFields()) {
+		if err := v.checkSelectedField(principal, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package options
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/atlas-app-toolkit/query"
+)
+
+// AuthorizeFieldSelection validates fs like ValidateFieldSelectionCtx and
+// returns the selection of the fields the caller extracted from ctx is
+// allowed to see, which should be used to build the response:
+//   - a selected field the caller is not allowed to see is dropped if the
+//     result message has the unauthorized_fields = DROP option, the request
+//     is rejected with *PermissionDeniedError otherwise;
+//   - a selected field having nested fields the caller is not allowed to see
+//     is replaced by its visible nested fields if the result message has the
+//     unauthorized_fields = DROP option, the request is rejected otherwise;
+//   - if no fields are selected, the default selection of the caller is
+//     returned, which is nil if the caller is allowed to see all fields.
+//
+// Sensitive fields are never visible, a selected parent of a sensitive field
+// is replaced by its other nested fields whatever the unauthorized_fields
+// option.
+//
+// fs is returned as is if the caller is allowed to see all selected fields.
+func (v *MethodValidator) AuthorizeFieldSelection(ctx context.Context, fs *query.FieldSelection) (*query.FieldSelection, error) {
+	if err := v.ValidateFieldSelectionCtx(ctx, fs); err != nil {
+		return nil, err
+	}
+	if !v.hasHiddenFields() {
+		return fs, nil
+	}
+
+	principal, err := v.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(fs.GetFields()) == 0 {
+		return v.defaultFieldSelection(principal), nil
+	}
+
+	var (
+		res      = &query.FieldSelection{}
+		dropped  string
+		narrowed bool
+	)
+	for _, f := range leafFieldSelection(fs.GetFields()) {
+		paths := v.visibleSelectedFields(principal, f)
+		if len(paths) == 0 && dropped == "" {
+			dropped = f
+		}
+		if len(paths) != 1 || paths[0] != f {
+			narrowed = true
+		}
+		for _, p := range paths {
+			res.Add(p)
+		}
+	}
+	if !narrowed {
+		return fs, nil
+	}
+	// An empty selection would select all fields.
+	if len(res.GetFields()) == 0 {
+		return nil, &PermissionDeniedError{Parameter: fieldSelectionParameter, Field: dropped}
+	}
+	return res, nil
+}
+
+// DefaultFieldSelection returns the selection of the fields the caller
+// extracted from ctx is allowed to see, nil if the caller may see all fields.
+func (v *MethodValidator) DefaultFieldSelection(ctx context.Context) (*query.FieldSelection, error) {
+	if !v.hasHiddenFields() {
+		return nil, nil
+	}
+
+	principal, err := v.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.defaultFieldSelection(principal), nil
+}
+
+// hasHiddenFields reports whether some selectable fields may be hidden from a
+// caller, either by their permissions or because they are sensitive.
+func (v *MethodValidator) hasHiddenFields() bool {
+	return v.fieldSelection != nil && (len(v.rules.Permissions.FieldSelection) > 0 || len(v.rules.Sensitive) > 0)
+}
+
+func (v *MethodValidator) defaultFieldSelection(principal *Principal) *query.FieldSelection {
+	var (
+		paths  []string
+		hidden bool
+	)
+	for _, s := range v.rules.Sensitive {
+		if !strings.Contains(s, ".") {
+			hidden = true
+		}
+	}
+	for _, f := range v.rules.FieldSelection {
+		if _, ok := v.rules.Oneofs[f]; ok || strings.Contains(f, ".") {
+			continue
+		}
+		visible := v.visibleFields(principal, f)
+		if len(visible) != 1 || visible[0] != f {
+			hidden = true
+		}
+		paths = append(paths, visible...)
+	}
+	if !hidden {
+		return nil
+	}
+
+	sort.Strings(paths)
+	res := &query.FieldSelection{}
+	for _, p := range paths {
+		res.Add(p)
+	}
+	return res
+}
+
+// visibleSelectedFields returns the paths of the fields the caller is
+// allowed to see among the fields the selected field stands for.
+func (v *MethodValidator) visibleSelectedFields(principal *Principal, f string) []string {
+	r, err := v.resolveSelectedField(f)
+	if err != nil || !v.isVisible(principal, r.orig) {
+		return nil
+	}
+
+	var res []string
+	for _, field := range v.concreteFields(r.path) {
+		for _, p := range v.visibleFields(principal, field) {
+			res = append(res, r.unresolve(p))
+		}
+	}
+	return res
+}
+
+// visibleFields returns the field if the caller is allowed to see it and all
+// its nested fields, otherwise the visible nested fields.
+func (v *MethodValidator) visibleFields(principal *Principal, field string) []string {
+	if !v.isVisible(principal, strings.Split(field, ".")) {
+		return nil
+	}
+
+	var children []string
+	hidden := false
+	for _, s := range v.rules.Sensitive {
+		if strings.HasPrefix(s, field+".") {
+			hidden = true
+		}
+	}
+	for _, a := range v.rules.FieldSelection {
+		if _, ok := v.rules.Oneofs[a]; ok || !strings.HasPrefix(a, field+".") {
+			continue
+		}
+		if !v.isVisible(principal, strings.Split(a, ".")) {
+			hidden = true
+		}
+		if !strings.Contains(a[len(field)+1:], ".") {
+			children = append(children, a)
+		}
+	}
+	if !hidden {
+		return []string{field}
+	}
+
+	var res []string
+	for _, c := range children {
+		res = append(res, v.visibleFields(principal, c)...)
+	}
+	return res
+}
+
+// checkSelectedField returns an error if the caller is not allowed to see the
+// selected field, a field matched by it or any of their nested fields.
+func (v *MethodValidator) checkSelectedField(principal *Principal, f string) error {
+	r, err := v.resolveSelectedField(f)
+	if err != nil {
+		return err
+	}
+	denied := &PermissionDeniedError{Parameter: fieldSelectionParameter, Field: f}
+	if !v.isVisible(principal, r.orig) || !v.isVisible(principal, r.path) {
+		return denied
+	}
+	for _, field := range v.concreteFields(r.path) {
+		for p, roles := range v.rules.Permissions.FieldSelection {
+			if (p == field || strings.HasPrefix(p, field+".")) && !principal.HasAnyRole(roles) {
+				return denied
+			}
+		}
+	}
+	return nil
+}
+
+func (v *MethodValidator) isVisible(principal *Principal, path []string) bool {
+	return checkPermissions(v.rules.Permissions.FieldSelection, principal, fieldSelectionParameter, "", path) == nil
+}
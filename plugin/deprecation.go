@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+)
+
+func (p *QueryValidatePlugin) genDeprecated() {
+	p.P(`var `, p.fileName(deprecatedFieldsVarSuffix), ` = map[string][]string{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil {
+				continue
+			}
+
+			fields := p.getDeprecatedData(inputMsg, resultMsg)
+			if len(fields) == 0 {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			for _, f := range fields {
+				p.P(`"`, f, `",`)
+			}
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+// getDeprecatedData returns the sorted paths of the deprecated fields on the
+// paths of the filterable, sortable and selectable fields. A field is
+// deprecated by the deprecated option of the field or by the deprecated flag
+// of its query validation options.
+func (p *QueryValidatePlugin) getDeprecatedData(inputMsg, resultMsg *generator.Descriptor) []string {
+	var fields []string
+	if p.hasFiltering(inputMsg) {
+		for _, v := range p.getFilteringData(resultMsg) {
+			fields = append(fields, v.fieldName)
+		}
+	}
+	if p.hasSorting(inputMsg) {
+		fields = append(fields, p.getSortingData(resultMsg)...)
+	}
+	if p.hasFieldSelection(inputMsg) {
+		fields = append(fields, p.getFieldSelectionData(resultMsg)...)
+	}
+
+	var (
+		res  []string
+		seen = make(map[string]struct{})
+	)
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		for i := 1; i <= len(path); i++ {
+			prefix := strings.Join(path[:i], ".")
+			if _, ok := seen[prefix]; ok {
+				continue
+			}
+			seen[prefix] = struct{}{}
+
+			field, opts := p.lookupField(resultMsg, path[:i])
+			if field.GetOptions().GetDeprecated() || opts.GetDeprecated() {
+				res = append(res, prefix)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// eFieldBehavior mirrors the google.api.field_behavior extension, which is
+// decoded without depending on the googleapis packages.
+var eFieldBehavior = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: ([]int32)(nil),
+	Field:         1052,
+	Name:          "google.api.field_behavior",
+	Tag:           "varint,1052,rep,name=field_behavior,json=fieldBehavior",
+}
+
+// fieldBehaviors are the values of the google.api.FieldBehavior enum.
+var fieldBehaviors = map[string]int32{
+	"OPTIONAL":          1,
+	"REQUIRED":          2,
+	"OUTPUT_ONLY":       3,
+	"INPUT_ONLY":        4,
+	"IMMUTABLE":         5,
+	"UNORDERED_LIST":    6,
+	"NON_EMPTY_DEFAULT": 7,
+	"IDENTIFIER":        8,
+}
+
+// exclusion is a set of the collection operators a field is excluded from.
+type exclusion uint8
+
+const (
+	excludeFiltering exclusion = 1 << iota
+	excludeSorting
+	excludeFieldSelection
+)
+
+var exclusionNames = map[string]exclusion{
+	"filtering":       excludeFiltering,
+	"sorting":         excludeSorting,
+	"field_selection": excludeFieldSelection,
+	"none":            0,
+}
+
+// defaultFieldBehaviors is the mapping applied by the field_behavior=true
+// parameter: input only fields are never returned nor stored as is, output
+// only fields are often computed and can't be queried by the storage layer.
+var defaultFieldBehaviors = map[int32]exclusion{
+	fieldBehaviors["INPUT_ONLY"]:  excludeFiltering | excludeSorting | excludeFieldSelection,
+	fieldBehaviors["OUTPUT_ONLY"]: excludeFiltering | excludeSorting,
+}
+
+// parseFieldBehaviors returns the mapping of the field behaviors to the
+// operators the fields having them are excluded from. The field_behavior=true
+// parameter switches the default mapping on, the field_behavior_<behavior>
+// parameters set the mapping of a behavior, e.g.
+// field_behavior_output_only=filtering+sorting or field_behavior_input_only=none.
+func parseFieldBehaviors(params map[string]string) map[int32]exclusion {
+	res := make(map[int32]exclusion)
+	if v, ok := params["field_behavior"]; ok {
+		if on, err := strconv.ParseBool(v); err != nil {
+			log.Print("Invalid parameter for field_behavior, should be a boolean")
+		} else if on {
+			for b, e := range defaultFieldBehaviors {
+				res[b] = e
+			}
+		}
+	}
+
+	for k, v := range params {
+		if !strings.HasPrefix(k, "field_behavior_") {
+			continue
+		}
+		b, ok := fieldBehaviors[strings.ToUpper(strings.TrimPrefix(k, "field_behavior_"))]
+		if !ok {
+			log.Printf("Invalid parameter %s, unknown field behavior", k)
+			continue
+		}
+		var e exclusion
+		for _, name := range strings.Split(v, "+") {
+			x, ok := exclusionNames[name]
+			if !ok {
+				log.Printf("Invalid parameter for %s, should be a '+' separated list of filtering, sorting, field_selection or none", k)
+				continue
+			}
+			e |= x
+		}
+		res[b] = e
+	}
+	return res
+}
+
+// getFieldBehaviors returns the values of the google.api.field_behavior
+// option of the field.
+func getFieldBehaviors(field *descriptor.FieldDescriptorProto) []int32 {
+	if field.Options == nil || !proto.HasExtension(field.Options, eFieldBehavior) {
+		return nil
+	}
+
+	v, err := proto.GetExtension(field.Options, eFieldBehavior)
+	if err != nil {
+		return nil
+	}
+
+	behaviors, _ := v.([]int32)
+	return behaviors
+}
+
+// behaviorExclusion returns the operators the field is excluded from by its
+// behaviors. Explicit filtering rules of the field take precedence.
+func (p *QueryValidatePlugin) behaviorExclusion(field *descriptor.FieldDescriptorProto, opts *options.QueryValidate) exclusion {
+	if len(p.fieldBehaviors) == 0 {
+		return 0
+	}
+
+	var res exclusion
+	for _, b := range getFieldBehaviors(field) {
+		res |= p.fieldBehaviors[b]
+	}
+	if len(opts.GetFiltering().GetAllow()) > 0 || len(opts.GetFiltering().GetDeny()) > 0 {
+		res &^= excludeFiltering
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func (p *QueryValidatePlugin) genFieldSelectionRequireParent() {
+	p.P(`var `, p.fileName(fieldSelectionRequireParentSuffix), ` = map[string][]string{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil || !p.hasFieldSelection(inputMsg) {
+				continue
+			}
+			fields := p.getRequireParentData(resultMsg, p.getFieldSelectionData(resultMsg))
+			if len(fields) == 0 {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			for _, field := range fields {
+				p.P(`"`, field, `",`)
+			}
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+// getRequireParentData returns the selectable nested fields of msg having the
+// field_selection.require_parent option.
+func (p *QueryValidatePlugin) getRequireParentData(msg *generator.Descriptor, fields []string) []string {
+	var data []string
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		if len(path) < 2 {
+			continue
+		}
+		if p.lookupFieldOptions(msg, path).GetFieldSelection().GetRequireParent() {
+			data = append(data, f)
+		}
+	}
+	return data
+}
+
+// lookupFieldOptions returns the query validation options of the field at
+// the path relative to msg, nil if the path does not name a field.
+func (p *QueryValidatePlugin) lookupFieldOptions(msg *generator.Descriptor, path []string) *options.QueryValidate {
+	_, opts := p.lookupField(msg, path)
+	return opts
+}
+
+// lookupField returns the field at the path relative to msg and its query
+// validation options, nil if the path does not name a field.
+func (p *QueryValidatePlugin) lookupField(msg *generator.Descriptor, path []string) (*descriptor.FieldDescriptorProto, *options.QueryValidate) {
+	for i, name := range path {
+		var field *descriptor.FieldDescriptorProto
+		for _, opts := range p.getMessageQueryValidationOptions(msg.DescriptorProto) {
+			if opts.GetName() == name {
+				field = p.syntheticField(name, opts.GetValue())
+				break
+			}
+		}
+		for _, f := range msg.GetField() {
+			if field == nil && f.GetName() == name {
+				field = f
+			}
+		}
+		if field == nil {
+			return nil, nil
+		}
+
+		opts := getQueryValidationOptions(field)
+		if sfield := p.syntheticField(name, opts); sfield != nil {
+			field = sfield
+			opts = getQueryValidationOptions(sfield)
+		}
+		if i == len(path)-1 {
+			return field, opts
+		}
+		if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+			return nil, nil
+		}
+		if msg, _ = p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor); msg == nil {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// The options of protoc-gen-gorm are decoded into the subset below mirroring
+// gorm.GormMessageOptions and gorm.GormFieldOptions, so that the generator
+// does not depend on protoc-gen-gorm.
+
+type gormMessageOptions struct {
+	Ormable bool `protobuf:"varint,1,opt,name=ormable"`
+}
+
+func (m *gormMessageOptions) Reset()         { *m = gormMessageOptions{} }
+func (m *gormMessageOptions) String() string { return proto.CompactTextString(m) }
+func (*gormMessageOptions) ProtoMessage()    {}
+
+type gormFieldOptions struct {
+	Tag        *gormTag         `protobuf:"bytes,1,opt,name=tag"`
+	Drop       bool             `protobuf:"varint,2,opt,name=drop"`
+	HasOne     *gormAssociation `protobuf:"bytes,3,opt,name=has_one"`
+	BelongsTo  *gormAssociation `protobuf:"bytes,4,opt,name=belongs_to"`
+	HasMany    *gormAssociation `protobuf:"bytes,5,opt,name=has_many"`
+	ManyToMany *gormAssociation `protobuf:"bytes,6,opt,name=many_to_many"`
+}
+
+func (m *gormFieldOptions) Reset()         { *m = gormFieldOptions{} }
+func (m *gormFieldOptions) String() string { return proto.CompactTextString(m) }
+func (*gormFieldOptions) ProtoMessage()    {}
+
+func (m *gormFieldOptions) GetTag() *gormTag {
+	if m != nil {
+		return m.Tag
+	}
+	return nil
+}
+
+type gormTag struct {
+	Column string `protobuf:"bytes,1,opt,name=column"`
+	Ignore bool   `protobuf:"varint,14,opt,name=ignore"`
+}
+
+func (m *gormTag) Reset()         { *m = gormTag{} }
+func (m *gormTag) String() string { return proto.CompactTextString(m) }
+func (*gormTag) ProtoMessage()    {}
+
+func (m *gormTag) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+// gormAssociation stands for any of the association options, whose content
+// is irrelevant to the queries.
+type gormAssociation struct{}
+
+func (m *gormAssociation) Reset()         { *m = gormAssociation{} }
+func (m *gormAssociation) String() string { return proto.CompactTextString(m) }
+func (*gormAssociation) ProtoMessage()    {}
+
+var eGormMessage = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MessageOptions)(nil),
+	ExtensionType: (*gormMessageOptions)(nil),
+	Field:         52119,
+	Name:          "gorm.opts",
+	Tag:           "bytes,52119,opt,name=opts",
+}
+
+var eGormField = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*gormFieldOptions)(nil),
+	Field:         52119,
+	Name:          "gorm.field",
+	Tag:           "bytes,52119,opt,name=field",
+}
+
+func getGormMessageOptions(msg *descriptor.DescriptorProto) *gormMessageOptions {
+	if msg.Options == nil || !proto.HasExtension(msg.Options, eGormMessage) {
+		return nil
+	}
+
+	v, err := proto.GetExtension(msg.Options, eGormMessage)
+	if err != nil {
+		return nil
+	}
+
+	opts, _ := v.(*gormMessageOptions)
+	return opts
+}
+
+func getGormFieldOptions(field *descriptor.FieldDescriptorProto) *gormFieldOptions {
+	if field.Options == nil || !proto.HasExtension(field.Options, eGormField) {
+		return nil
+	}
+
+	v, err := proto.GetExtension(field.Options, eGormField)
+	if err != nil {
+		return nil
+	}
+
+	opts, _ := v.(*gormFieldOptions)
+	return opts
+}
+
+// isOrmable reports whether gorm models are generated for msg and the gorm
+// options are not ignored by the gorm=false parameter.
+func (p *QueryValidatePlugin) isOrmable(msg *generator.Descriptor) bool {
+	if p.ignoreGorm || msg == nil {
+		return false
+	}
+	opts := getGormMessageOptions(msg.DescriptorProto)
+	return opts != nil && opts.Ormable
+}
+
+// isGormUnqueryable reports whether the field of the ormable msg can't be
+// translated to SQL by the gorm layer: the field is not persisted or it is a
+// message field other than a singular association gorm can join, i.e. a has
+// one or a belongs to association of an ormable message. Synthetic fields,
+// maps and fields of the types having a value type are not concerned.
+func (p *QueryValidatePlugin) isGormUnqueryable(msg *generator.Descriptor, field *descriptor.FieldDescriptorProto, opts *options.QueryValidate) bool {
+	if opts.GetValueTypeUrl() != "" || !p.isOrmable(msg) {
+		return false
+	}
+
+	gormOpts := getGormFieldOptions(field)
+	if gormOpts != nil && (gormOpts.Drop || gormOpts.Tag != nil && gormOpts.Tag.Ignore) {
+		return true
+	}
+
+	if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE ||
+		opts.GetValueType() != options.QueryValidate_DEFAULT ||
+		p.getValueType(field) != options.QueryValidate_DEFAULT ||
+		field.GetTypeName() == protoTypeJSONValue || p.isMapField(field) {
+		return false
+	}
+
+	if field.IsRepeated() || gormOpts != nil && (gormOpts.HasMany != nil || gormOpts.ManyToMany != nil) {
+		return true
+	}
+	nested, _ := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+	return !p.isOrmable(nested)
+}
+
+func (p *QueryValidatePlugin) genColumns() {
+	p.P(`var `, p.fileName(methodColumnsVarSuffix), ` = map[string]map[string]string{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil {
+				continue
+			}
+
+			columns := p.getColumnData(inputMsg, resultMsg)
+			if len(columns) == 0 {
+				continue
+			}
+
+			paths := make([]string, 0, len(columns))
+			for path := range columns {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			for _, path := range paths {
+				p.P(`"`, path, `": "`, columns[path], `",`)
+			}
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+// getColumnData returns the custom column names set by the gorm tags of the
+// filterable and sortable fields keyed by the path of the field.
+func (p *QueryValidatePlugin) getColumnData(inputMsg, resultMsg *generator.Descriptor) map[string]string {
+	if p.ignoreGorm {
+		return nil
+	}
+
+	var fields []string
+	if p.hasFiltering(inputMsg) {
+		for _, v := range p.getFilteringData(resultMsg) {
+			fields = append(fields, v.fieldName)
+		}
+	}
+	if p.hasSorting(inputMsg) {
+		fields = append(fields, p.getSortingData(resultMsg)...)
+	}
+
+	var res map[string]string
+	for _, f := range fields {
+		field, _ := p.lookupField(resultMsg, strings.Split(f, "."))
+		if field == nil {
+			continue
+		}
+		if tag := getGormFieldOptions(field).GetTag(); tag.GetColumn() != "" {
+			if res == nil {
+				res = make(map[string]string)
+			}
+			res[f] = tag.GetColumn()
+		}
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/httprule"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// The google.api.http option is decoded from the wire format, so that the
+// generator does not depend on the googleapis annotations.
+const (
+	httpRuleFieldNumber = 72295728
+
+	// Fields of google.api.HttpRule.
+	httpRuleCustom             = 8
+	httpRuleAdditionalBindings = 11
+
+	// Fields of google.api.CustomHttpPattern.
+	httpCustomKind = 1
+	httpCustomPath = 2
+)
+
+// httpRuleMethods are the HTTP methods of the patterns of google.api.HttpRule
+// keyed by the field number.
+var httpRuleMethods = map[int32]string{
+	2: "GET",
+	3: "PUT",
+	4: "POST",
+	5: "DELETE",
+	6: "PATCH",
+}
+
+func (p *QueryValidatePlugin) genHTTPRules() {
+	p.P(`var `, p.fileName(methodHTTPRulesVarSuffix), ` = map[string][]options.HTTPRule{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			if p.getResultMessage(outputMsg) == nil {
+				continue
+			}
+
+			rules := p.getHTTPRules(method)
+			if len(rules) == 0 {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			for _, r := range rules {
+				p.P(`{Method: `, strconv.Quote(r.Method), `, Pattern: `, strconv.Quote(r.Pattern), `},`)
+			}
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+// getHTTPRules returns the bindings of the google.api.http option of the
+// method followed by its additional bindings. The generation fails if a path
+// template is not valid.
+func (p *QueryValidatePlugin) getHTTPRules(method *descriptor.MethodDescriptorProto) []options.HTTPRule {
+	if method.Options == nil {
+		return nil
+	}
+
+	b, err := proto.Marshal(method.Options)
+	if err != nil {
+		return nil
+	}
+	f := lastWireField(decodeWireFields(b), httpRuleFieldNumber)
+	if f == nil {
+		return nil
+	}
+
+	rules := decodeHTTPRule(f.data)
+	for _, r := range rules {
+		if _, err := httprule.Parse(r.Pattern); err != nil {
+			p.Fail(method.GetName(), ": invalid google.api.http pattern ", strconv.Quote(r.Pattern), ": ", err.Error())
+		}
+	}
+	return rules
+}
+
+func decodeHTTPRule(data []byte) []options.HTTPRule {
+	var (
+		rule     *options.HTTPRule
+		bindings []options.HTTPRule
+	)
+	for _, f := range decodeWireFields(data) {
+		if f.wireType != proto.WireBytes {
+			continue
+		}
+		switch f.number {
+		case httpRuleCustom:
+			custom := decodeWireFields(f.data)
+			kind, path := lastWireField(custom, httpCustomKind), lastWireField(custom, httpCustomPath)
+			if kind != nil && path != nil {
+				rule = &options.HTTPRule{Method: string(kind.data), Pattern: string(path.data)}
+			}
+		case httpRuleAdditionalBindings:
+			bindings = append(bindings, decodeHTTPRule(f.data)...)
+		default:
+			if method, ok := httpRuleMethods[f.number]; ok {
+				rule = &options.HTTPRule{Method: method, Pattern: string(f.data)}
+			}
+		}
+	}
+	if rule == nil {
+		return bindings
+	}
+	return append([]options.HTTPRule{*rule}, bindings...)
+}
@@ -0,0 +1,35 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func TestDecodeHTTPRule(t *testing.T) {
+	str := func(b *proto.Buffer, number int, s string) {
+		b.EncodeVarint(uint64(number<<3 | proto.WireBytes))
+		b.EncodeStringBytes(s)
+	}
+	custom := proto.NewBuffer(nil)
+	str(custom, httpCustomKind, "HEAD")
+	str(custom, httpCustomPath, "/v1/users")
+	binding := proto.NewBuffer(nil)
+	binding.EncodeVarint(uint64(httpRuleCustom<<3 | proto.WireBytes))
+	binding.EncodeRawBytes(custom.Bytes())
+
+	rule := proto.NewBuffer(nil)
+	str(rule, 1, "example.TestService.List")
+	str(rule, 2, "/v1/users")
+	str(rule, 7, "*")
+	rule.EncodeVarint(uint64(httpRuleAdditionalBindings<<3 | proto.WireBytes))
+	rule.EncodeRawBytes(binding.Bytes())
+
+	expected := []options.HTTPRule{{Method: "GET", Pattern: "/v1/users"}, {Method: "HEAD", Pattern: "/v1/users"}}
+	if rules := decodeHTTPRule(rule.Bytes()); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Expected %v, but got %v", expected, rules)
+	}
+}
@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
@@ -35,11 +36,29 @@ func CleanImports(pFileText *string) *string {
 	for _, dep := range unneededImports {
 		fileText = strings.Replace(fileText, dep, "", -1)
 	}
+	// Blank imports of the packages imported by name, e.g. for the pruners,
+	// are redundant.
+	for _, line := range strings.Split(fileText, "\n") {
+		if path := strings.TrimPrefix(line, "import _ "); path != line && strings.Count(fileText, " "+path+"\n") > 1 {
+			fileText = strings.Replace(fileText, line+"\n", "", 1)
+		}
+	}
 	return &fileText
 }
 
 // GenerateImports writes out required imports for the generated files
 func (p *QueryValidatePlugin) GenerateImports(file *generator.FileDescriptor) {
+	p.PrintImport("context", "context")
 	p.PrintImport("options", "github.com/infobloxopen/protoc-gen-atlas-query-validate/options")
 	p.PrintImport("query", "github.com/infobloxopen/atlas-app-toolkit/query")
+
+	// The pruners refer to the Go types of messages declared in other packages.
+	paths := make([]string, 0, len(p.prunedImports))
+	for path := range p.prunedImports {
+		paths = append(paths, string(path))
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		p.PrintImport(p.GoPackageName(generator.GoImportPath(path)), generator.GoImportPath(path))
+	}
 }
@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// lint reports suspicious or contradictory query validation options of the
+// messages declared in the current file. The problems are logged as warnings
+// unless the lint=true parameter is passed, in which case generation fails.
+func (p *QueryValidatePlugin) lint() {
+	if !p.isFileToGenerate(p.currentFile) {
+		return
+	}
+
+	var warnings []string
+	for _, msg := range p.currentFile.Messages() {
+		warnings = append(warnings, p.lintMessage(msg)...)
+	}
+
+	for _, w := range warnings {
+		log.Print("atlas-query-validate: warning: ", w)
+	}
+	if p.lintFail && len(warnings) > 0 {
+		p.Fail(fmt.Sprintf("%d query validation option problem(s) found in %s", len(warnings), p.currentFile.GetName()))
+	}
+}
+
+func (p *QueryValidatePlugin) isFileToGenerate(file *generator.FileDescriptor) bool {
+	for _, name := range p.Request.GetFileToGenerate() {
+		if name == file.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *QueryValidatePlugin) lintMessage(msg *generator.Descriptor) []string {
+	var (
+		warnings []string
+		msgName  = strings.Join(append([]string{p.currentFile.GetPackage()}, msg.TypeName()...), ".")
+	)
+
+	for _, entry := range p.getMessageQueryValidationOptions(msg.DescriptorProto) {
+		name := msgName + "." + entry.GetName()
+		for _, field := range msg.GetField() {
+			if field.GetName() == entry.GetName() {
+				warnings = append(warnings, fmt.Sprintf("%s: synthetic validate entry shadows the real field", name))
+				break
+			}
+		}
+		if f := p.syntheticField(entry.GetName(), entry.GetValue()); f != nil {
+			warnings = append(warnings, p.lintField(name, f, entry.GetValue(), true)...)
+		} else if p.isAllowListEmpty(entry.GetValue(), entry.GetValue().GetValueType()) {
+			warnings = append(warnings, fmt.Sprintf("%s: no filtering operator of the allow list is applicable", name))
+		}
+	}
+
+	for _, field := range msg.GetField() {
+		opts := getQueryValidationOptions(field)
+		if opts == nil {
+			continue
+		}
+		if sfield := p.syntheticField(field.GetName(), opts); sfield != nil {
+			field = sfield
+		}
+		warnings = append(warnings, p.lintField(msgName+"."+field.GetName(), field, opts, false)...)
+
+		if !isNullable(msg, field) && !p.isMapField(field) {
+			for _, op := range opts.GetFiltering().GetAllow() {
+				if op == options.QueryValidate_IS_NULL {
+					warnings = append(warnings, fmt.Sprintf("%s.%s: IS_NULL has no effect on a field which is not nullable", msgName, field.GetName()))
+					break
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+func (p *QueryValidatePlugin) lintField(name string, field *descriptor.FieldDescriptorProto, opts *options.QueryValidate, synthetic bool) []string {
+	var (
+		warnings []string
+		nested   *generator.Descriptor
+	)
+
+	if field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE && p.getValueType(field) == options.QueryValidate_DEFAULT {
+		nested, _ = p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+	}
+
+	if opts.GetEnableNestedFields() && nested == nil && opts.GetValueTypeUrl() == "" {
+		warnings = append(warnings, fmt.Sprintf("%s: enable_nested_fields has no effect on a field of scalar type", name))
+	}
+
+	if opts.GetMaxDepth() != 0 && nested == nil {
+		warnings = append(warnings, fmt.Sprintf("%s: max_depth has no effect on a field of scalar type", name))
+	}
+
+	if len(opts.GetNestedFields()) > 0 {
+		if nested == nil {
+			warnings = append(warnings, fmt.Sprintf("%s: nested_fields has no effect on a field of scalar type", name))
+		} else {
+			for _, n := range opts.GetNestedFields() {
+				if !p.hasNestedField(nested, n) {
+					warnings = append(warnings, fmt.Sprintf("%s: nested_fields names nonexistent field '%s' of %s", name, n, field.GetTypeName()))
+				}
+			}
+		}
+	}
+
+	if opts.GetValueTypeUrl() != "" && nested != nil {
+		var filterable bool
+		for _, v := range p.getFilteringDataAux(nested, p.getNestDepth(nested), opts.GetNestedFields(), newNesting(nested)) {
+			if len(getAllowedOperators(v.option)) > 0 {
+				filterable = true
+				break
+			}
+		}
+		if !filterable {
+			warnings = append(warnings, fmt.Sprintf("%s: value_type_url type %s has no filterable fields", name, opts.GetValueTypeUrl()))
+		}
+	}
+
+	if opts.GetRepeatedSemantics() != options.QueryValidate_REPEATED_NONE && (!field.IsRepeated() || p.isMapField(field)) {
+		warnings = append(warnings, fmt.Sprintf("%s: repeated_semantics has no effect on a field which is not repeated", name))
+	}
+
+	// Sorting is disabled implicitly for synthetic and value_type_url fields.
+	if !synthetic && opts.GetValueTypeUrl() == "" && field.IsRepeated() && opts.GetSorting().GetDisable() {
+		warnings = append(warnings, fmt.Sprintf("%s: sorting.disable has no effect on a repeated field", name))
+	}
+
+	if opts.GetSensitiveOverride() && !isSensitive(field, opts) {
+		warnings = append(warnings, fmt.Sprintf("%s: sensitive_override has no effect on a field which is not sensitive", name))
+	}
+
+	valueType := opts.GetValueType()
+	if valueType == options.QueryValidate_DEFAULT && !field.IsRepeated() {
+		valueType = p.getValueType(field)
+	}
+	if p.isAllowListEmpty(opts, valueType) {
+		warnings = append(warnings, fmt.Sprintf("%s: no filtering operator of the allow list is applicable", name))
+	}
+
+	return warnings
+}
+
+// hasNestedField reports whether the nested_fields pattern matches any real
+// or synthetic field of msg.
+func (p *QueryValidatePlugin) hasNestedField(msg *generator.Descriptor, pattern string) bool {
+	segments := strings.SplitN(pattern, ".", 2)
+
+	for _, entry := range p.getMessageOptions(msg.DescriptorProto).GetValidate() {
+		if ok, _ := path.Match(segments[0], entry.GetName()); ok && len(segments) == 1 {
+			return true
+		}
+	}
+
+	for _, field := range msg.GetField() {
+		if ok, _ := path.Match(segments[0], field.GetName()); !ok {
+			continue
+		}
+		if len(segments) == 1 {
+			return true
+		}
+		if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+			continue
+		}
+		if nested, ok := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor); ok && p.hasNestedField(nested, segments[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowListEmpty reports whether the allow list of opts is given but none
+// of its operators is supported for the value type.
+func (p *QueryValidatePlugin) isAllowListEmpty(opts *options.QueryValidate, valueType options.QueryValidate_ValueType) bool {
+	allow := opts.GetFiltering().GetAllow()
+	if len(allow) == 0 {
+		return false
+	}
+	for _, op := range getSupportedOperators(valueType) {
+		for _, a := range allow {
+			if a == op || a == options.QueryValidate_ALL {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// ManifestPlugin computes the validation rules of all methods like
+// QueryValidatePlugin does, but collects them instead of generating code.
+type ManifestPlugin struct {
+	QueryValidatePlugin
+	Rules map[string]options.MethodRules
+}
+
+// Name identifies the plugin
+func (p *ManifestPlugin) Name() string {
+	return "atlas-query-validate-manifest"
+}
+
+// Init is called once after data structures are built but before
+// code generation begins.
+func (p *ManifestPlugin) Init(g *generator.Generator) {
+	p.QueryValidatePlugin.Init(g)
+	p.Rules = make(map[string]options.MethodRules)
+}
+
+// Generate collects the rules of the methods declared in the file.
+func (p *ManifestPlugin) Generate(file *generator.FileDescriptor) {
+	p.setFile(file)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil {
+				continue
+			}
+
+			var rules options.MethodRules
+			if p.hasFiltering(inputMsg) {
+				rules.Filtering = make(map[string]options.FilteringOption)
+				for _, v := range p.getFilteringData(resultMsg) {
+					o := v.option
+					o.Allowed, o.AllowedSet = options.NewFilterOperatorMask(getAllowedOperators(o)...), true
+					rules.Filtering[v.fieldName] = o
+				}
+			}
+			if p.hasSorting(inputMsg) {
+				rules.Sorting = append([]string{}, p.getSortingData(resultMsg)...)
+			}
+			if p.hasFieldSelection(inputMsg) {
+				rules.FieldSelection = append([]string{}, p.getFieldSelectionData(resultMsg)...)
+				rules.FieldSelectionRequireParent = p.getRequireParentData(resultMsg, rules.FieldSelection)
+				rules.Sensitive = p.getSensitiveData(resultMsg, rules.FieldSelection)
+			}
+			if rules.Filtering == nil && rules.Sorting == nil && rules.FieldSelection == nil {
+				continue
+			}
+			if oneofs := p.getOneofData(resultMsg); len(oneofs) > 0 && (rules.Filtering != nil || rules.FieldSelection != nil) {
+				rules.Oneofs = make(map[string]options.OneofRule, len(oneofs))
+				for _, v := range oneofs {
+					rules.Oneofs[v.oneofName] = v.rule
+				}
+			}
+
+			rules.Recursions = p.getRecursionData(inputMsg, resultMsg)
+			rules.Permissions = p.getPermissionData(inputMsg, resultMsg)
+			rules.DropUnauthorizedFields = p.dropUnauthorizedFields(inputMsg, resultMsg)
+			rules.Scope = p.getScopeRule(method, inputMsg, resultMsg)
+			rules.Columns = p.getColumnData(inputMsg, resultMsg)
+			rules.Deprecated = p.getDeprecatedData(inputMsg, resultMsg)
+			rules.HTTPRules = p.getHTTPRules(method)
+
+			methodName := fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName())
+			p.Rules[methodName] = rules
+		}
+	}
+}
+
+// GenerateImports does nothing as no code is generated.
+func (p *ManifestPlugin) GenerateImports(file *generator.FileDescriptor) {}
+
+// Manifest returns the validation rules of all methods declared in the set
+// keyed by the full method name. parameter is the plugin parameter string,
+// e.g. "nested_field_depth_limit=3,enable_nested_fields=true".
+func Manifest(set *descriptor.FileDescriptorSet, parameter string) map[string]options.MethodRules {
+	if len(set.GetFile()) == 0 {
+		return map[string]options.MethodRules{}
+	}
+
+	g := generator.New()
+	// The plugin runs for every file of the request, so it is enough to
+	// request generation of a single one.
+	g.Request = &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{set.File[len(set.File)-1].GetName()},
+		Parameter:      &parameter,
+		ProtoFile:      set.File,
+	}
+	g.CommandLineParameters(g.Request.GetParameter())
+	g.WrapTypes()
+	g.SetPackageNames()
+	g.BuildTypeNameMap()
+
+	p := &ManifestPlugin{}
+	g.GeneratePlugin(p)
+	return p.Rules
+}
+
+// ManifestFile returns the validation rules of all methods declared in the
+// FileDescriptorSet stored in the named file, e.g. produced by
+// protoc --include_imports --descriptor_set_out, see Manifest.
+func ManifestFile(name, parameter string) (map[string]options.MethodRules, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptor.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return Manifest(set, parameter), nil
+}
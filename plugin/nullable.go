@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+)
+
+// proto3OptionalFieldNumber is the number of the proto3_optional field of
+// FieldDescriptorProto, which is newer than the descriptor the generator is
+// built with, so it is read from the unrecognized fields.
+const proto3OptionalFieldNumber = 17
+
+// isNullable reports whether the field of msg has presence, so that it may be
+// checked for null: a singular message field, e.g. a wrapper or a timestamp,
+// a oneof member, which proto3 optional fields are as well, or an optional
+// field of a proto2 message.
+func isNullable(msg *generator.Descriptor, field *descriptor.FieldDescriptorProto) bool {
+	if field.IsRepeated() {
+		return false
+	}
+	if field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE || field.OneofIndex != nil {
+		return true
+	}
+	return field.GetLabel() == descriptor.FieldDescriptorProto_LABEL_OPTIONAL && msg.File().GetSyntax() != "proto3"
+}
+
+func isProto3Optional(field *descriptor.FieldDescriptorProto) bool {
+	f := lastWireField(decodeWireFields(field.XXX_unrecognized), proto3OptionalFieldNumber)
+	return f != nil && f.wireType == proto.WireVarint && f.value != 0
+}
+
+// isSyntheticOneof reports whether the oneof of msg at the index only wraps
+// a proto3 optional field.
+func isSyntheticOneof(msg *generator.Descriptor, index int) bool {
+	for _, field := range msg.GetField() {
+		if field.OneofIndex != nil && int(field.GetOneofIndex()) == index {
+			return isProto3Optional(field)
+		}
+	}
+	return false
+}
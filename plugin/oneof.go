@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+type oneofValidate struct {
+	oneofName string
+	rule      options.OneofRule
+}
+
+func (p *QueryValidatePlugin) genOneofs() {
+	p.P(`var `, p.fileName(methodOneofVarSuffix), ` = map[string]map[string]options.OneofRule{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil || !p.hasFiltering(inputMsg) && !p.hasFieldSelection(inputMsg) {
+				continue
+			}
+			oneofs := p.getOneofData(resultMsg)
+			if len(oneofs) == 0 {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			for _, v := range oneofs {
+				var members string
+				for _, m := range v.rule.Members {
+					members += `"` + m + `",`
+				}
+				f := `Members: []string{` + members + `},`
+				if v.rule.SingleBranch {
+					f += `SingleBranch: true,`
+				}
+				p.P(`"`, v.oneofName, `": {`, f, `},`)
+			}
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+func (p *QueryValidatePlugin) getOneofData(msg *generator.Descriptor) []oneofValidate {
+	return p.getOneofDataAux(msg, p.getNestDepth(msg), newNesting(msg))
+}
+
+// getOneofDataAux returns the oneofs of msg and of the messages nested in it
+// via singular fields with member paths relative to msg. Oneofs of recursive
+// types are collected at their first occurrence only.
+func (p *QueryValidatePlugin) getOneofDataAux(msg *generator.Descriptor, maxNesting int, n *nesting) []oneofValidate {
+	var data []oneofValidate
+
+	for i, oneof := range msg.GetOneofDecl() {
+		if isSyntheticOneof(msg, i) {
+			continue
+		}
+		v := oneofValidate{
+			oneofName: oneof.GetName(),
+			rule:      options.OneofRule{SingleBranch: getOneofQueryValidationOptions(oneof).GetSingleBranch()},
+		}
+		for _, field := range msg.GetField() {
+			if field.OneofIndex != nil && int(field.GetOneofIndex()) == i {
+				v.rule.Members = append(v.rule.Members, field.GetName())
+			}
+		}
+		data = append(data, v)
+	}
+
+	for _, field := range msg.GetField() {
+		if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE || field.IsRepeated() ||
+			p.getValueType(field) != options.QueryValidate_DEFAULT {
+			continue
+		}
+		opts := getQueryValidationOptions(field)
+		if opts.GetValueType() != options.QueryValidate_DEFAULT || p.atDepthLimit(field, nil, maxNesting, n) {
+			continue
+		}
+
+		nestedMsg, ok := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+		if !ok {
+			continue
+		}
+		depth := n.nestDepth(field, nestedMsg, nil, maxNesting)
+		if depth == 0 {
+			continue
+		}
+		for _, v := range p.getOneofDataAux(nestedMsg, depth, n.enter(field.GetName(), nestedMsg)) {
+			members := make([]string, len(v.rule.Members))
+			for i, m := range v.rule.Members {
+				members[i] = field.GetName() + "." + m
+			}
+			v.oneofName = field.GetName() + "." + v.oneofName
+			v.rule.Members = members
+			data = append(data, v)
+		}
+	}
+
+	return data
+}
+
+// selectableOneofs returns the names of the oneofs of msg having members
+// among the selectable fields.
+func (p *QueryValidatePlugin) selectableOneofs(msg *generator.Descriptor, selectable []string) []string {
+	var res []string
+	for i, oneof := range msg.GetOneofDecl() {
+		if isSyntheticOneof(msg, i) {
+			continue
+		}
+	members:
+		for _, field := range msg.GetField() {
+			if field.OneofIndex == nil || int(field.GetOneofIndex()) != i {
+				continue
+			}
+			for _, s := range selectable {
+				if s == field.GetName() {
+					res = append(res, oneof.GetName())
+					break members
+				}
+			}
+		}
+	}
+	return res
+}
+
+func getOneofQueryValidationOptions(oneof *descriptor.OneofDescriptorProto) *options.OneofQueryValidate {
+	if oneof.Options == nil {
+		return nil
+	}
+	v, err := proto.GetExtension(oneof.Options, options.E_Oneof)
+	if err != nil {
+		return nil
+	}
+	opts, _ := v.(*options.OneofQueryValidate)
+	return opts
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func (p *QueryValidatePlugin) genPermissions() {
+	p.P(`var `, p.fileName(methodPermissionsVarSuffix), ` = map[string]options.MethodPermissions{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil {
+				continue
+			}
+
+			perms := p.getPermissionData(inputMsg, resultMsg)
+			if perms.Filtering == nil && perms.Sorting == nil && perms.FieldSelection == nil {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			p.genPermissionMap("Filtering", perms.Filtering)
+			p.genPermissionMap("Sorting", perms.Sorting)
+			p.genPermissionMap("FieldSelection", perms.FieldSelection)
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+func (p *QueryValidatePlugin) genPermissionMap(name string, perms map[string][]string) {
+	if perms == nil {
+		return
+	}
+
+	paths := make([]string, 0, len(perms))
+	for path := range perms {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	p.P(name, `: map[string][]string{`)
+	for _, path := range paths {
+		var roles string
+		for _, r := range perms[path] {
+			roles += fmt.Sprintf(`%q,`, r)
+		}
+		p.P(`"`, path, `": {`, roles, `},`)
+	}
+	p.P(`},`)
+}
+
+func (p *QueryValidatePlugin) getPermissionData(inputMsg, resultMsg *generator.Descriptor) options.MethodPermissions {
+	var res options.MethodPermissions
+	if p.hasFiltering(inputMsg) {
+		var fields []string
+		for _, v := range p.getFilteringData(resultMsg) {
+			fields = append(fields, v.fieldName)
+		}
+		res.Filtering = p.getFieldPermissions(resultMsg, fields, func(perms *options.QueryValidate_Permissions) []string {
+			return perms.GetFiltering()
+		})
+	}
+	if p.hasSorting(inputMsg) {
+		res.Sorting = p.getFieldPermissions(resultMsg, p.getSortingData(resultMsg), func(perms *options.QueryValidate_Permissions) []string {
+			return perms.GetSorting()
+		})
+	}
+	if p.hasFieldSelection(inputMsg) {
+		res.FieldSelection = p.getFieldPermissions(resultMsg, p.getFieldSelectionData(resultMsg), func(perms *options.QueryValidate_Permissions) []string {
+			return perms.GetFieldSelection()
+		})
+	}
+	return res
+}
+
+// getFieldPermissions returns the roles required by the fields on the paths
+// of the allowed fields of msg keyed by the path of the field declaring them.
+func (p *QueryValidatePlugin) getFieldPermissions(msg *generator.Descriptor, fields []string, roles func(*options.QueryValidate_Permissions) []string) map[string][]string {
+	var (
+		res  map[string][]string
+		seen = make(map[string]struct{})
+	)
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		for i := 1; i <= len(path); i++ {
+			prefix := strings.Join(path[:i], ".")
+			if _, ok := seen[prefix]; ok {
+				continue
+			}
+			seen[prefix] = struct{}{}
+
+			if r := roles(p.lookupFieldOptions(msg, path[:i]).GetPermissions()); len(r) > 0 {
+				if res == nil {
+					res = make(map[string][]string)
+				}
+				res[prefix] = r
+			}
+		}
+	}
+	return res
+}
+
+// dropUnauthorizedFields reports whether the selected fields the caller is not
+// allowed to see are dropped rather than rejected.
+func (p *QueryValidatePlugin) dropUnauthorizedFields(inputMsg, resultMsg *generator.Descriptor) bool {
+	return p.hasFieldSelection(inputMsg) &&
+		p.getMessageOptions(resultMsg.DescriptorProto).GetUnauthorizedFields() == options.MessageQueryValidate_DROP
+}
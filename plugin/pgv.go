@@ -0,0 +1,380 @@
+package plugin
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// The validate.rules option of protoc-gen-validate is decoded from the wire
+// format, so that the generator does not depend on protoc-gen-validate.
+const (
+	pgvRulesFieldNumber = 1071
+
+	// Fields of validate.FieldRules.
+	pgvFloat    = 1
+	pgvDouble   = 2
+	pgvInt32    = 3
+	pgvInt64    = 4
+	pgvUInt32   = 5
+	pgvUInt64   = 6
+	pgvSInt32   = 7
+	pgvSInt64   = 8
+	pgvFixed32  = 9
+	pgvFixed64  = 10
+	pgvSFixed32 = 11
+	pgvSFixed64 = 12
+	pgvString   = 14
+	pgvEnum     = 16
+	pgvRepeated = 18
+
+	// Field of validate.RepeatedRules.
+	pgvRepeatedItems = 4
+)
+
+// pgvStringFormats are the well-known formats of validate.StringRules keyed
+// by the field number.
+var pgvStringFormats = map[int32]string{
+	12: "email",
+	13: "hostname",
+	14: "ip",
+	15: "ipv4",
+	16: "ipv6",
+	17: "uri",
+	18: "uri_ref",
+	21: "address",
+	22: "uuid",
+}
+
+// getPGVRules returns the fields of the validate.rules option of the field,
+// or of its items for a repeated field, nil if there are none.
+func getPGVRules(field *descriptor.FieldDescriptorProto) []wireField {
+	if field.Options == nil {
+		return nil
+	}
+
+	b, err := proto.Marshal(field.Options)
+	if err != nil {
+		return nil
+	}
+	f := lastWireField(decodeWireFields(b), pgvRulesFieldNumber)
+	if f == nil {
+		return nil
+	}
+
+	rules := decodeWireFields(f.data)
+	if !field.IsRepeated() {
+		return rules
+	}
+	if f = lastWireField(rules, pgvRepeated); f == nil {
+		return nil
+	}
+	if f = lastWireField(decodeWireFields(f.data), pgvRepeatedItems); f == nil {
+		return nil
+	}
+	return decodeWireFields(f.data)
+}
+
+// getLiteralConstraint translates the protoc-gen-validate rules of the field
+// into the constraint of the filtering literals, nil if the rules are not
+// read, i.e. the pgv=true parameter is not passed, or don't constrain them.
+func (p *QueryValidatePlugin) getLiteralConstraint(field *descriptor.FieldDescriptorProto, valueType options.QueryValidate_ValueType) *options.LiteralConstraint {
+	if !p.pgv {
+		return nil
+	}
+
+	rules := getPGVRules(field)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var c *options.LiteralConstraint
+	switch valueType {
+	case options.QueryValidate_STRING:
+		if f := lastWireField(rules, pgvString); f != nil {
+			c = getStringConstraint(decodeWireFields(f.data))
+		} else if f := lastWireField(rules, pgvEnum); f != nil && field.GetType() == descriptor.FieldDescriptorProto_TYPE_ENUM {
+			c = p.getEnumConstraint(field, decodeWireFields(f.data))
+		}
+	case options.QueryValidate_NUMBER:
+		for _, kind := range []int32{pgvFloat, pgvDouble, pgvInt32, pgvInt64, pgvUInt32, pgvUInt64, pgvSInt32, pgvSInt64, pgvFixed32, pgvFixed64, pgvSFixed32, pgvSFixed64} {
+			if f := lastWireField(rules, kind); f != nil {
+				c = getNumberConstraint(kind, decodeWireFields(f.data))
+				break
+			}
+		}
+	}
+	// The literals are matched by the regexp package of Go, whose RE2
+	// syntax differs from the one of the other protoc-gen-validate targets.
+	if c != nil && c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			p.Fail(field.GetName(), ": invalid validate.rules pattern: ", err.Error())
+		}
+	}
+	return c
+}
+
+func getStringConstraint(rules []wireField) *options.LiteralConstraint {
+	var (
+		c     options.LiteralConstraint
+		empty = true
+	)
+	for _, f := range rules {
+		switch f.number {
+		case 1: // const
+			c.In = []string{string(f.data)}
+		case 2: // min_len
+			c.MinLen = int(f.value)
+		case 3: // max_len
+			c.MaxLen = int(f.value)
+			if f.value == 0 {
+				c.In = []string{""}
+			}
+		case 19: // len
+			c.MinLen, c.MaxLen = int(f.value), int(f.value)
+			if f.value == 0 {
+				c.In = []string{""}
+			}
+		case 6: // pattern
+			c.Pattern = string(f.data)
+		case 10: // in
+			c.In = append(c.In, string(f.data))
+		case 11: // not_in
+			c.NotIn = append(c.NotIn, string(f.data))
+		case 26: // ignore_empty
+			c.IgnoreEmpty = f.value != 0
+			continue
+		default:
+			format, ok := pgvStringFormats[f.number]
+			if !ok || f.value == 0 {
+				continue
+			}
+			c.Format = format
+		}
+		empty = false
+	}
+	if empty {
+		return nil
+	}
+	return &c
+}
+
+func (p *QueryValidatePlugin) getEnumConstraint(field *descriptor.FieldDescriptorProto, rules []wireField) *options.LiteralConstraint {
+	enum, ok := p.ObjectNamed(field.GetTypeName()).(*generator.EnumDescriptor)
+	if !ok {
+		return nil
+	}
+	name := func(n int32) string {
+		for _, v := range enum.GetValue() {
+			if v.GetNumber() == n {
+				return v.GetName()
+			}
+		}
+		return strconv.Itoa(int(n))
+	}
+
+	var c options.LiteralConstraint
+	for _, f := range rules {
+		switch f.number {
+		case 1: // const
+			c.In = []string{name(int32(f.value))}
+		case 2: // defined_only
+			if f.value != 0 && len(c.In) == 0 {
+				for _, v := range enum.GetValue() {
+					c.In = append(c.In, v.GetName())
+				}
+			}
+		case 3: // in
+			for _, n := range varintValues(f) {
+				c.In = append(c.In, name(int32(n)))
+			}
+		case 4: // not_in
+			for _, n := range varintValues(f) {
+				c.NotIn = append(c.NotIn, name(int32(n)))
+			}
+		}
+	}
+	if c.In == nil && c.NotIn == nil {
+		return nil
+	}
+	return &c
+}
+
+func getNumberConstraint(kind int32, rules []wireField) *options.LiteralConstraint {
+	var (
+		c     options.LiteralConstraint
+		empty = true
+	)
+	for _, f := range rules {
+		if f.number == 8 { // ignore_empty
+			c.IgnoreEmpty = f.value != 0
+			continue
+		}
+		values := numberValues(kind, f)
+		if len(values) == 0 {
+			continue
+		}
+		v := values[len(values)-1]
+		switch f.number {
+		case 1: // const
+			c.NumberIn = []float64{v}
+		case 2: // lt
+			c.Max, c.ExclusiveMax = options.Float64(v), true
+		case 3: // lte
+			c.Max, c.ExclusiveMax = options.Float64(v), false
+		case 4: // gt
+			c.Min, c.ExclusiveMin = options.Float64(v), true
+		case 5: // gte
+			c.Min, c.ExclusiveMin = options.Float64(v), false
+		case 6: // in
+			c.NumberIn = append(c.NumberIn, values...)
+		case 7: // not_in
+			c.NumberNotIn = append(c.NumberNotIn, values...)
+		default:
+			continue
+		}
+		empty = false
+	}
+	// An exclusive range, e.g. lt: 0, gt: 10, allows the values out of it
+	// and can't be expressed by bounds.
+	if c.Min != nil && c.Max != nil && *c.Max < *c.Min {
+		c.Min, c.Max = nil, nil
+	}
+	if empty {
+		return nil
+	}
+	return &c
+}
+
+// varintValues returns the values of a varint field, which may be packed.
+func varintValues(f wireField) []uint64 {
+	if f.wireType != proto.WireBytes {
+		return []uint64{f.value}
+	}
+	var (
+		res []uint64
+		buf = proto.NewBuffer(f.data)
+	)
+	for {
+		v, err := buf.DecodeVarint()
+		if err != nil {
+			return res
+		}
+		res = append(res, v)
+	}
+}
+
+// numberValues returns the values of the field of the numeric rules of the
+// kind, which may be packed.
+func numberValues(kind int32, f wireField) []float64 {
+	raw := []uint64{f.value}
+	if f.wireType == proto.WireBytes {
+		raw = nil
+		buf := proto.NewBuffer(f.data)
+		for {
+			var (
+				v   uint64
+				err error
+			)
+			switch kind {
+			case pgvFloat, pgvFixed32, pgvSFixed32:
+				v, err = buf.DecodeFixed32()
+			case pgvDouble, pgvFixed64, pgvSFixed64:
+				v, err = buf.DecodeFixed64()
+			default:
+				v, err = buf.DecodeVarint()
+			}
+			if err != nil {
+				break
+			}
+			raw = append(raw, v)
+		}
+	}
+
+	res := make([]float64, 0, len(raw))
+	for _, v := range raw {
+		switch kind {
+		case pgvFloat:
+			res = append(res, float64(math.Float32frombits(uint32(v))))
+		case pgvDouble:
+			res = append(res, math.Float64frombits(v))
+		case pgvInt32, pgvInt64, pgvSFixed64:
+			res = append(res, float64(int64(v)))
+		case pgvSFixed32:
+			res = append(res, float64(int32(v)))
+		case pgvSInt32, pgvSInt64:
+			res = append(res, float64(int64(v>>1)^-int64(v&1)))
+		default:
+			res = append(res, float64(v))
+		}
+	}
+	return res
+}
+
+// genLiteralConstraint returns the Go expression of the constraint.
+func genLiteralConstraint(c *options.LiteralConstraint) string {
+	var fields []string
+	if c.Format != "" {
+		fields = append(fields, `Format: `+strconv.Quote(c.Format))
+	}
+	if c.Pattern != "" {
+		fields = append(fields, `Pattern: `+strconv.Quote(c.Pattern))
+	}
+	if c.MinLen != 0 {
+		fields = append(fields, `MinLen: `+strconv.Itoa(c.MinLen))
+	}
+	if c.MaxLen != 0 {
+		fields = append(fields, `MaxLen: `+strconv.Itoa(c.MaxLen))
+	}
+	quoteStrings := func(name string, values []string) {
+		if values == nil {
+			return
+		}
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		fields = append(fields, name+`: []string{`+strings.Join(quoted, `, `)+`}`)
+	}
+	quoteStrings("In", c.In)
+	quoteStrings("NotIn", c.NotIn)
+	formatNumbers := func(name string, values []float64) {
+		if values == nil {
+			return
+		}
+		formatted := make([]string, len(values))
+		for i, v := range values {
+			formatted[i] = formatFloat(v)
+		}
+		fields = append(fields, name+`: []float64{`+strings.Join(formatted, `, `)+`}`)
+	}
+	formatNumbers("NumberIn", c.NumberIn)
+	formatNumbers("NumberNotIn", c.NumberNotIn)
+	if c.Min != nil {
+		fields = append(fields, `Min: options.Float64(`+formatFloat(*c.Min)+`)`)
+		if c.ExclusiveMin {
+			fields = append(fields, `ExclusiveMin: true`)
+		}
+	}
+	if c.Max != nil {
+		fields = append(fields, `Max: options.Float64(`+formatFloat(*c.Max)+`)`)
+		if c.ExclusiveMax {
+			fields = append(fields, `ExclusiveMax: true`)
+		}
+	}
+	if c.IgnoreEmpty {
+		fields = append(fields, `IgnoreEmpty: true`)
+	}
+	return `&options.LiteralConstraint{` + strings.Join(fields, `, `) + `}`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
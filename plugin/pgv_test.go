@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// The protoc-gen-validate release the examples are built with predates the
+// ignore_empty rule, so it is tested on the decoded rules.
+func TestIgnoreEmpty(t *testing.T) {
+	ignoreEmpty := wireField{number: 26, wireType: proto.WireVarint, value: 1}
+	if c := getStringConstraint([]wireField{ignoreEmpty}); c != nil {
+		t.Errorf("Expected no constraint for ignore_empty alone, but got %+v", c)
+	}
+	c := getStringConstraint([]wireField{{number: 2, wireType: proto.WireVarint, value: 3}, ignoreEmpty})
+	if c == nil || c.MinLen != 3 || !c.IgnoreEmpty {
+		t.Errorf("Expected min_len 3 ignoring empty strings, but got %+v", c)
+	}
+
+	ignoreEmpty.number = 8
+	c = getNumberConstraint(pgvInt64, []wireField{{number: 5, wireType: proto.WireVarint, value: 1}, ignoreEmpty})
+	if c == nil || c.Min == nil || *c.Min != 1 || !c.IgnoreEmpty {
+		t.Errorf("Expected gte 1 ignoring zero, but got %+v", c)
+	}
+	if s := genLiteralConstraint(c); s != `&options.LiteralConstraint{Min: options.Float64(1), IgnoreEmpty: true}` {
+		t.Errorf("Unexpected generated constraint %s", s)
+	}
+}
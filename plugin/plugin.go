@@ -3,7 +3,9 @@ package plugin
 import (
 	"fmt"
 	"log"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -16,16 +18,28 @@ import (
 )
 
 const (
-	filtering                          = ".infoblox.api.Filtering"
-	sorting                            = ".infoblox.api.Sorting"
-	fieldSelection                     = ".infoblox.api.FieldSelection"
-	messagesValidationVarSuffix        = "MessagesRequireQueryValidation"
-	methodFilteringVarSuffix           = "MethodsRequireFilteringValidation"
-	methodSortingVarSuffix             = "MethodsRequireSortingValidation"
-	methodFieldSelectionVarSuffix      = "MethodsRequireFieldSelectionValidation"
-	validateFilteringMethodSuffix      = "ValidateFiltering"
-	validateSortingMethodSuffix        = "ValidateSorting"
-	validateFieldSelectionMethodSuffix = "ValidateFieldSelection"
+	filtering                           = ".infoblox.api.Filtering"
+	sorting                             = ".infoblox.api.Sorting"
+	fieldSelection                      = ".infoblox.api.FieldSelection"
+	messagesValidationVarSuffix         = "MessagesRequireQueryValidation"
+	methodFilteringVarSuffix            = "MethodsRequireFilteringValidation"
+	methodSortingVarSuffix              = "MethodsRequireSortingValidation"
+	methodFieldSelectionVarSuffix       = "MethodsRequireFieldSelectionValidation"
+	methodOneofVarSuffix                = "MethodsRequireOneofValidation"
+	methodRecursionVarSuffix            = "MethodsRequireRecursionValidation"
+	fieldSelectionRequireParentSuffix   = "MethodsFieldSelectionRequireParent"
+	methodPermissionsVarSuffix          = "MethodsRequirePermissions"
+	methodColumnsVarSuffix              = "MethodsColumns"
+	deprecatedFieldsVarSuffix           = "MethodsDeprecatedFields"
+	sensitiveFieldsVarSuffix            = "MethodsSensitiveFields"
+	methodHTTPRulesVarSuffix            = "MethodsHTTPRules"
+	prunerSuffix                        = "Prune"
+	validateFilteringMethodSuffix       = "ValidateFiltering"
+	validateFilteringStringMethodSuffix = "ValidateFilteringString"
+	validateSortingMethodSuffix         = "ValidateSorting"
+	validateFieldSelectionMethodSuffix  = "ValidateFieldSelection"
+	scopeFilteringMethodSuffix          = "ScopeFiltering"
+	methodValidatorsVarSuffix           = "MethodValidators"
 
 	protoTypeTimestamp   = ".google.protobuf.Timestamp"
 	protoTypeUUID        = ".gorm.types.UUID"
@@ -46,30 +60,31 @@ const (
 // QueryValidatePlugin implements the plugin interface and creates validations for collection operation parameters code from .protos
 type QueryValidatePlugin struct {
 	*generator.Generator
-	currentFile                             *generator.FileDescriptor
-	messagesValidationVarName               string
-	requiredFilteringValidationVarName      string
-	requiredSortingValidationVarName        string
-	validateFilteringMethodName             string
-	validateSortingMethodName               string
-	validateFieldSelectionMethodName        string
-	requiredFieldSelectionValidationVarName string
-	maxNesting                              int
-	alwaysNest                              bool
+	currentFile    *generator.FileDescriptor
+	fileNamePrefix string
+	maxNesting     int
+	alwaysNest     bool
+	lintFail       bool
+	pruners        bool
+	prunedImports  map[generator.GoImportPath]bool
+	fieldBehaviors map[int32]exclusion
+	ignoreGorm     bool
+	pgv            bool
 }
 
 func (p *QueryValidatePlugin) setFile(file *generator.FileDescriptor) {
 	p.currentFile = file
+	p.prunedImports = make(map[generator.GoImportPath]bool)
 	// p.Generator.SetFile(file.FileDescriptorProto)
 
 	baseFileName := filepath.Base(file.GetName())
-	p.messagesValidationVarName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + messagesValidationVarSuffix)
-	p.requiredFilteringValidationVarName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + methodFilteringVarSuffix)
-	p.requiredSortingValidationVarName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + methodSortingVarSuffix)
-	p.requiredFieldSelectionValidationVarName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + methodFieldSelectionVarSuffix)
-	p.validateFilteringMethodName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + validateFilteringMethodSuffix)
-	p.validateSortingMethodName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + validateSortingMethodSuffix)
-	p.validateFieldSelectionMethodName = generator.CamelCase(strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName)) + validateFieldSelectionMethodSuffix)
+	p.fileNamePrefix = strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName))
+}
+
+// fileName returns the name of a generated variable or function of the
+// current file, the CamelCased file name followed by the suffix.
+func (p *QueryValidatePlugin) fileName(suffix string) string {
+	return generator.CamelCase(p.fileNamePrefix + suffix)
 }
 
 // Name identifies the plugin
@@ -99,26 +114,54 @@ func (p *QueryValidatePlugin) Init(g *generator.Generator) {
 	} else {
 		p.alwaysNest = false
 	}
+	if v, ok := g.Param["lint"]; ok {
+		p.lintFail, _ = strconv.ParseBool(v)
+	}
+	if v, ok := g.Param["pruners"]; ok {
+		p.pruners, _ = strconv.ParseBool(v)
+	}
+	p.fieldBehaviors = parseFieldBehaviors(g.Param)
+	if v, ok := g.Param["gorm"]; ok {
+		useGorm, _ := strconv.ParseBool(v)
+		p.ignoreGorm = !useGorm
+	}
+	if v, ok := g.Param["pgv"]; ok {
+		p.pgv, _ = strconv.ParseBool(v)
+	}
 }
 
 // Generate produces the code generated by the plugin for this file,
 // except for the imports, by calling the generator's methods P, In, and Out.
 func (p *QueryValidatePlugin) Generate(file *generator.FileDescriptor) {
 	p.setFile(file)
+	p.lint()
 	p.genValidationData()
+	p.genMethodValidators()
+	p.genRegisterMethodValidators()
 	p.genValidateFiltering()
+	p.genValidateFilteringString()
 	p.genValidateSorting()
 	p.genValidateFieldSelection()
+	p.genScopeFiltering()
+	p.genPruners()
 }
 
 func (p *QueryValidatePlugin) genValidationData() {
 	p.genFiltering()
 	p.genSorting()
 	p.genFieldSelection()
+	p.genFieldSelectionRequireParent()
+	p.genOneofs()
+	p.genRecursions()
+	p.genPermissions()
+	p.genColumns()
+	p.genDeprecated()
+	p.genSensitive()
+	p.genHTTPRules()
 }
 
 func (p *QueryValidatePlugin) genFiltering() {
-	p.P(`var `, p.requiredFilteringValidationVarName, ` = map[string]map[string]options.FilteringOption {`)
+	p.P(`var `, p.fileName(methodFilteringVarSuffix), ` = map[string]map[string]options.FilteringOption {`)
 	for _, srv := range p.currentFile.GetService() {
 		for _, method := range srv.GetMethod() {
 			hasFiltering := p.hasFiltering(p.ObjectNamed(method.GetInputType()).(*generator.Descriptor))
@@ -135,6 +178,26 @@ func (p *QueryValidatePlugin) genFiltering() {
 						}
 						f = `Deny: []options.QueryValidate_FilterOperator{` + f + `},`
 					}
+					if allowed := getAllowedOperators(v.option); len(allowed) != 0 {
+						var a string
+						for _, op := range allowed {
+							a += "options.QueryValidate_" + op.String() + `,`
+						}
+						f += `Allowed: options.NewFilterOperatorMask(` + a + `),`
+					}
+					f += `AllowedSet: true,`
+					if v.option.Repeated != options.QueryValidate_REPEATED_NONE {
+						f += `Repeated: options.QueryValidate_` + v.option.Repeated.String() + `,`
+					}
+					if v.option.KeyPattern != "" {
+						f += `KeyPattern: ` + strconv.Quote(v.option.KeyPattern) + `,`
+					}
+					if v.option.Literal != nil {
+						f += `Literal: ` + genLiteralConstraint(v.option.Literal) + `,`
+					}
+					if v.option.Nullable {
+						f += `Nullable: true,`
+					}
 					t := `ValueType: options.QueryValidate_` + v.option.ValueType.String()
 					p.P(`"`, v.fieldName, `": options.FilteringOption{`+f+t+`},`)
 				}
@@ -146,7 +209,7 @@ func (p *QueryValidatePlugin) genFiltering() {
 }
 
 func (p *QueryValidatePlugin) genSorting() {
-	p.P(`var `, p.requiredSortingValidationVarName, ` = map[string][]string {`)
+	p.P(`var `, p.fileName(methodSortingVarSuffix), ` = map[string][]string {`)
 	for _, srv := range p.currentFile.GetService() {
 		for _, method := range srv.GetMethod() {
 			hasSorting := p.hasSorting(p.ObjectNamed(method.GetInputType()).(*generator.Descriptor))
@@ -166,7 +229,7 @@ func (p *QueryValidatePlugin) genSorting() {
 }
 
 func (p *QueryValidatePlugin) genFieldSelection() {
-	p.P(`var `, p.requiredFieldSelectionValidationVarName, ` = map[string][]string{`)
+	p.P(`var `, p.fileName(methodFieldSelectionVarSuffix), ` = map[string][]string{`)
 	for _, srv := range p.currentFile.GetService() {
 		for _, method := range srv.GetMethod() {
 			hasFieldSelection := p.hasFieldSelection(p.ObjectNamed(method.GetInputType()).(*generator.Descriptor))
@@ -185,6 +248,90 @@ func (p *QueryValidatePlugin) genFieldSelection() {
 	p.P(`}`)
 }
 
+func (p *QueryValidatePlugin) genMethodValidators() {
+	p.P(`var `, p.fileName(methodValidatorsVarSuffix), ` = map[string]*options.MethodValidator{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil {
+				continue
+			}
+
+			getFiltering := p.getQueryFieldGetter(inputMsg, filtering)
+			getSorting := p.getQueryFieldGetter(inputMsg, sorting)
+			getFieldSelection := p.getQueryFieldGetter(inputMsg, fieldSelection)
+			if getFiltering == "" && getSorting == "" && getFieldSelection == "" {
+				continue
+			}
+
+			methodName := fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName())
+			p.P(`"`, methodName, `": options.MustRulesValidator(`)
+			p.P(`options.MethodRules{`)
+			p.P(`Filtering: `, p.fileName(methodFilteringVarSuffix), `["`, methodName, `"],`)
+			p.P(`Sorting: `, p.fileName(methodSortingVarSuffix), `["`, methodName, `"],`)
+			p.P(`FieldSelection: `, p.fileName(methodFieldSelectionVarSuffix), `["`, methodName, `"],`)
+			p.P(`FieldSelectionRequireParent: `, p.fileName(fieldSelectionRequireParentSuffix), `["`, methodName, `"],`)
+			p.P(`Oneofs: `, p.fileName(methodOneofVarSuffix), `["`, methodName, `"],`)
+			p.P(`Recursions: `, p.fileName(methodRecursionVarSuffix), `["`, methodName, `"],`)
+			p.P(`Permissions: `, p.fileName(methodPermissionsVarSuffix), `["`, methodName, `"],`)
+			p.P(`Columns: `, p.fileName(methodColumnsVarSuffix), `["`, methodName, `"],`)
+			p.P(`Deprecated: `, p.fileName(deprecatedFieldsVarSuffix), `["`, methodName, `"],`)
+			p.P(`Sensitive: `, p.fileName(sensitiveFieldsVarSuffix), `["`, methodName, `"],`)
+			p.P(`HTTPRules: `, p.fileName(methodHTTPRulesVarSuffix), `["`, methodName, `"],`)
+			if p.dropUnauthorizedFields(inputMsg, resultMsg) {
+				p.P(`DropUnauthorizedFields: true,`)
+			}
+			if scope := p.getScopeRule(method, inputMsg, resultMsg); scope != nil {
+				p.P(`Scope: &options.ScopeRule{Field: "`, scope.Field, `", ValueType: options.QueryValidate_`, scope.ValueType.String(), `},`)
+			}
+			p.P(`},`)
+			p.P(`func(req interface{}) (f *query.Filtering, s *query.Sorting, fs *query.FieldSelection) {`)
+			if getFiltering != "" {
+				p.P(`if r, ok := req.(interface{ `, getFiltering, `() *query.Filtering }); ok {`)
+				p.P(`f = r.`, getFiltering, `()`)
+				p.P(`}`)
+			}
+			if getSorting != "" {
+				p.P(`if r, ok := req.(interface{ `, getSorting, `() *query.Sorting }); ok {`)
+				p.P(`s = r.`, getSorting, `()`)
+				p.P(`}`)
+			}
+			if getFieldSelection != "" {
+				p.P(`if r, ok := req.(interface{ `, getFieldSelection, `() *query.FieldSelection }); ok {`)
+				p.P(`fs = r.`, getFieldSelection, `()`)
+				p.P(`}`)
+			}
+			p.P(`return`)
+			p.P(`},`)
+			p.P(`),`)
+		}
+	}
+	p.P(`}`)
+}
+
+func (p *QueryValidatePlugin) genRegisterMethodValidators() {
+	p.P(`func init() {`)
+	p.P(`for method, v := range `, p.fileName(methodValidatorsVarSuffix), ` {`)
+	p.P(`if err := options.Registry.Register(method, v); err != nil {`)
+	p.P(`panic(err)`)
+	p.P(`}`)
+	p.P(`}`)
+	p.P(`}`)
+}
+
+// getQueryFieldGetter returns the name of the getter of the msg field
+// having typeName type or an empty string if there is no such field.
+func (p *QueryValidatePlugin) getQueryFieldGetter(msg *generator.Descriptor, typeName string) string {
+	for _, msgField := range msg.GetField() {
+		if msgField.GetTypeName() == typeName {
+			return "Get" + generator.CamelCase(msgField.GetName())
+		}
+	}
+	return ""
+}
+
 func (p *QueryValidatePlugin) hasFieldSelection(msg *generator.Descriptor) bool {
 	for _, msgField := range msg.GetField() {
 		if msgField.GetTypeName() == fieldSelection {
@@ -263,10 +410,10 @@ func (p *QueryValidatePlugin) syntheticField(name string, o *options.QueryValida
 }
 
 func (p *QueryValidatePlugin) getFilteringData(msg *generator.Descriptor) []fieldValidate {
-	return p.getFilteringDataAux(msg, p.getNestDepth(msg))
+	return p.getFilteringDataAux(msg, p.getNestDepth(msg), nil, newNesting(msg))
 }
 
-func (p *QueryValidatePlugin) getFilteringDataAux(msg *generator.Descriptor, maxNesting int) []fieldValidate {
+func (p *QueryValidatePlugin) getFilteringDataAux(msg *generator.Descriptor, maxNesting int, nestedFields []string, n *nesting) []fieldValidate {
 
 	var (
 		data      []fieldValidate
@@ -277,7 +424,9 @@ func (p *QueryValidatePlugin) getFilteringDataAux(msg *generator.Descriptor, max
 	for _, opts := range p.getMessageQueryValidationOptions(msg.DescriptorProto) {
 		if f := p.syntheticField(opts.GetName(), opts.GetValue()); f != nil {
 			fields = append(fields, f)
-		} else {
+		} else if _, ok := p.isAllowedNestedField(opts.GetName(), nestedFields); ok && opts.GetValue().GetSensitive() {
+			data = append(data, p.getSensitiveFilteringData(opts.GetName(), nil, opts.GetValue()))
+		} else if ok {
 			data = append(data, fieldValidate{
 				fieldName: opts.GetName(),
 				option: options.FilteringOption{
@@ -291,19 +440,44 @@ func (p *QueryValidatePlugin) getFilteringDataAux(msg *generator.Descriptor, max
 	fields = append(fields, msg.GetField()...)
 
 	for _, field := range fields {
+		subFields, ok := p.isAllowedNestedField(field.GetName(), nestedFields)
+		if !ok {
+			continue
+		}
+
 		opts := getQueryValidationOptions(field)
 		if sfield := p.syntheticField(field.GetName(), opts); sfield != nil {
 			field = sfield
 			opts = getQueryValidationOptions(sfield)
 		}
+		if subFields == nil {
+			subFields = opts.GetNestedFields()
+		}
+
+		if isSensitive(field, opts) {
+			data = append(data, p.getSensitiveFilteringData(field.GetName(), field, opts))
+			continue
+		}
+
+		if p.behaviorExclusion(field, opts)&excludeFiltering != 0 || p.isGormUnqueryable(msg, field, opts) {
+			data = append(data, p.getDenyAllFilteringData(field.GetName(), field, opts))
+			continue
+		}
+
+		if field.GetTypeName() == protoTypeJSONValue || p.isMapField(field) {
+			data = append(data, p.getKeyRulesData(field, opts)...)
+			continue
+		}
 
 		fieldName := field.GetName()
-		if field.GetTypeName() == protoTypeJSONValue {
-			fieldName += ".*"
+
+		repeated := options.QueryValidate_REPEATED_NONE
+		if field.IsRepeated() {
+			repeated = opts.GetRepeatedSemantics()
 		}
 
 		if valueType = opts.GetValueType(); valueType == options.QueryValidate_DEFAULT {
-			if field.IsRepeated() {
+			if field.IsRepeated() && repeated == options.QueryValidate_REPEATED_NONE {
 				data = append(data, fieldValidate{
 					fieldName: fieldName,
 					option: options.FilteringOption{
@@ -318,18 +492,28 @@ func (p *QueryValidatePlugin) getFilteringDataAux(msg *generator.Descriptor, max
 
 			if valueType = p.getValueType(field); valueType == options.QueryValidate_DEFAULT {
 
-				if maxNesting == 1 {
+				if p.atDepthLimit(field, subFields, maxNesting, n) {
 					continue
 				}
 
-				if field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE && p.allowNested(msg, opts) {
+				if field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE &&
+					(p.allowNested(msg, opts) || len(subFields) > 0 || repeated != options.QueryValidate_REPEATED_NONE) {
 
 					nestedMsg := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
 					if nestedMsg == nil {
 						p.Fail(`Cannot find named object of type `, field.GetTypeName())
 					}
 
-					for _, v := range p.getFilteringDataAux(nestedMsg, maxNesting-1) {
+					// Paths below a singular field of a recursive type are also resolved at run time.
+					depth := n.nestDepth(field, nestedMsg, subFields, maxNesting)
+					if depth == 0 {
+						continue
+					}
+
+					for _, v := range p.getFilteringDataAux(nestedMsg, depth, subFields, n.enter(fieldName, nestedMsg)) {
+						if v.option.Repeated == options.QueryValidate_REPEATED_NONE {
+							v.option.Repeated = repeated
+						}
 						data = append(data, fieldValidate{
 							fieldName: fieldName + "." + v.fieldName,
 							option:    v.option,
@@ -352,23 +536,106 @@ func (p *QueryValidatePlugin) getFilteringDataAux(msg *generator.Descriptor, max
 			}
 		}
 
-		data = append(data, fieldValidate{fieldName, options.FilteringOption{ValueType: valueType, Deny: p.getDenyRules(fieldName, opts, valueType)}})
+		data = append(data, fieldValidate{fieldName, options.FilteringOption{
+			ValueType: valueType,
+			Deny:      p.getDenyRules(fieldName, opts, valueType),
+			Repeated:  repeated,
+			Literal:   p.getLiteralConstraint(field, valueType),
+			Nullable:  isNullable(msg, field),
+		}})
 	}
 	return data
 }
 
-func (p *QueryValidatePlugin) isAllowedNestedField(n string, o *options.QueryValidate) bool {
-	if o == nil || len(o.NestedFields) == 0 {
-		return true
+// isAllowedNestedField reports whether the field named n is allowed by the
+// nested_fields patterns of the parent field and returns the patterns which
+// apply to the fields of its own nested message. The patterns are dotted paths
+// relative to the parent field with path.Match globs in every segment,
+// e.g. "geo.lat" or "c*". No patterns allow all fields.
+func (p *QueryValidatePlugin) isAllowedNestedField(n string, nestedFields []string) ([]string, bool) {
+	if len(nestedFields) == 0 {
+		return nil, true
 	}
 
-	for _, v := range o.NestedFields {
-		if v == n {
-			return true
+	var (
+		subFields []string
+		allowed   bool
+	)
+	for _, v := range nestedFields {
+		segments := strings.SplitN(v, ".", 2)
+		if ok, _ := path.Match(segments[0], n); !ok {
+			continue
+		}
+		if len(segments) == 1 {
+			return nil, true
 		}
+		allowed = true
+		subFields = append(subFields, segments[1])
 	}
 
-	return false
+	return subFields, allowed
+}
+
+func (p *QueryValidatePlugin) isMapField(field *descriptor.FieldDescriptorProto) bool {
+	if !field.IsRepeated() || field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		return false
+	}
+	msg, ok := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+	return ok && msg.GetOptions().GetMapEntry()
+}
+
+// getKeyRulesData returns filtering rules of a map or JSON field. The rules
+// are keyed by the field name followed by a key or a key prefix and "*".
+func (p *QueryValidatePlugin) getKeyRulesData(field *descriptor.FieldDescriptorProto, opts *options.QueryValidate) []fieldValidate {
+	valueType := opts.GetValueType()
+	if valueType == options.QueryValidate_DEFAULT {
+		if p.isMapField(field) {
+			entry := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+			if key := entry.GetField()[0]; key.GetType() != descriptor.FieldDescriptorProto_TYPE_STRING {
+				p.Fail(field.GetName(), ": only maps with string keys are supported")
+			}
+			valueType = p.getValueType(entry.GetField()[1])
+		} else {
+			valueType = p.getValueType(field)
+		}
+	}
+
+	if opts.GetKeyRegex() != "" {
+		if _, err := regexp.Compile(opts.GetKeyRegex()); err != nil {
+			p.Fail(field.GetName(), ": invalid key_regex: ", err.Error())
+		}
+	}
+
+	keys := opts.GetKeys()
+	if len(keys) == 0 {
+		keys = []*options.QueryValidate_KeyRule{{Pattern: "*", Filtering: opts.GetFiltering()}}
+	}
+
+	var data []fieldValidate
+	for _, k := range keys {
+		pattern := k.GetPattern()
+		if pattern == "" || strings.Contains(strings.TrimSuffix(pattern, "*"), "*") ||
+			strings.HasSuffix(pattern, "*") && pattern != "*" && !strings.HasSuffix(pattern, ".*") {
+			p.Fail(field.GetName(), `: invalid key pattern '`, pattern, `', expect a key or a key prefix followed by ".*"`)
+		}
+
+		fieldName := field.GetName() + "." + pattern
+		vt := k.GetValueType()
+		if vt == options.QueryValidate_DEFAULT {
+			vt = valueType
+		}
+
+		// A key may be missing, so the values are nullable.
+		o := options.FilteringOption{ValueType: vt, Deny: []options.QueryValidate_FilterOperator{options.QueryValidate_ALL}, Nullable: true}
+		if vt != options.QueryValidate_DEFAULT {
+			o.Deny = p.getDenyRules(fieldName, &options.QueryValidate{Filtering: k.GetFiltering()}, vt)
+		}
+		if strings.HasSuffix(pattern, "*") {
+			o.KeyPattern = opts.GetKeyRegex()
+		}
+		data = append(data, fieldValidate{fieldName: fieldName, option: o})
+	}
+	return data
 }
 
 func (p *QueryValidatePlugin) getValueType(field *descriptor.FieldDescriptorProto) options.QueryValidate_ValueType {
@@ -416,10 +683,10 @@ func (p *QueryValidatePlugin) getValueType(field *descriptor.FieldDescriptorProt
 }
 
 func (p *QueryValidatePlugin) getSortingData(msg *generator.Descriptor) []string {
-	return p.getSortingDataAux(msg, p.getNestDepth(msg))
+	return p.getSortingDataAux(msg, p.getNestDepth(msg), nil, newNesting(msg))
 }
 
-func (p *QueryValidatePlugin) getSortingDataAux(msg *generator.Descriptor, maxNesting int) []string {
+func (p *QueryValidatePlugin) getSortingDataAux(msg *generator.Descriptor, maxNesting int, nestedFields []string, n *nesting) []string {
 
 	var (
 		data      []string
@@ -430,7 +697,7 @@ func (p *QueryValidatePlugin) getSortingDataAux(msg *generator.Descriptor, maxNe
 	for _, opts := range p.getMessageQueryValidationOptions(msg.DescriptorProto) {
 		if f := p.syntheticField(opts.GetName(), opts.GetValue()); f != nil {
 			fields = append(fields, f)
-		} else if !opts.GetValue().GetSorting().GetDisable() {
+		} else if _, ok := p.isAllowedNestedField(opts.GetName(), nestedFields); ok && !opts.GetValue().GetSorting().GetDisable() && !opts.GetValue().GetSensitive() {
 			data = append(data, opts.GetName())
 		}
 	}
@@ -438,13 +705,21 @@ func (p *QueryValidatePlugin) getSortingDataAux(msg *generator.Descriptor, maxNe
 	fields = append(fields, msg.GetField()...)
 
 	for _, field := range fields {
+		subFields, ok := p.isAllowedNestedField(field.GetName(), nestedFields)
+		if !ok {
+			continue
+		}
+
 		opts := getQueryValidationOptions(field)
 		if sfield := p.syntheticField(field.GetName(), opts); sfield != nil {
 			field = sfield
 			opts = getQueryValidationOptions(sfield)
 		}
+		if subFields == nil {
+			subFields = opts.GetNestedFields()
+		}
 
-		if opts.GetSorting().GetDisable() {
+		if opts.GetSorting().GetDisable() || isSensitive(field, opts) || p.behaviorExclusion(field, opts)&excludeSorting != 0 || p.isGormUnqueryable(msg, field, opts) {
 			continue
 		}
 
@@ -457,14 +732,18 @@ func (p *QueryValidatePlugin) getSortingDataAux(msg *generator.Descriptor, maxNe
 
 			if valueType = p.getValueType(field); valueType == options.QueryValidate_DEFAULT {
 
-				if maxNesting == 1 {
+				if p.atDepthLimit(field, subFields, maxNesting, n) {
 					continue
 				}
 
-				if field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE && p.allowNested(msg, opts) {
+				if field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE && (p.allowNested(msg, opts) || len(subFields) > 0) {
 
 					nestedMsg := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
-					for _, v := range p.getSortingDataAux(nestedMsg, maxNesting-1) {
+					depth := n.nestDepth(field, nestedMsg, subFields, maxNesting)
+					if depth == 0 {
+						continue
+					}
+					for _, v := range p.getSortingDataAux(nestedMsg, depth, subFields, n.enter(fieldName, nestedMsg)) {
 						data = append(data, fieldName+"."+v)
 					}
 				}
@@ -480,10 +759,10 @@ func (p *QueryValidatePlugin) getSortingDataAux(msg *generator.Descriptor, maxNe
 }
 
 func (p *QueryValidatePlugin) getFieldSelectionData(msg *generator.Descriptor) []string {
-	return p.getFieldSelectionDataAux(msg, p.getNestDepth(msg))
+	return p.getFieldSelectionDataAux(msg, p.getNestDepth(msg), nil, newNesting(msg))
 }
 
-func (p *QueryValidatePlugin) getFieldSelectionDataAux(msg *generator.Descriptor, maxNesting int) []string {
+func (p *QueryValidatePlugin) getFieldSelectionDataAux(msg *generator.Descriptor, maxNesting int, nestedFields []string, n *nesting) []string {
 
 	var (
 		data      []string
@@ -494,7 +773,7 @@ func (p *QueryValidatePlugin) getFieldSelectionDataAux(msg *generator.Descriptor
 	for _, opts := range p.getMessageQueryValidationOptions(msg.DescriptorProto) {
 		if f := p.syntheticField(opts.GetName(), opts.GetValue()); f != nil {
 			fields = append(fields, f)
-		} else if !opts.GetValue().GetFieldSelection().GetDisable() {
+		} else if _, ok := p.isAllowedNestedField(opts.GetName(), nestedFields); ok && !opts.GetValue().GetFieldSelection().GetDisable() && !opts.GetValue().GetSensitive() {
 			data = append(data, opts.GetName())
 		}
 	}
@@ -502,13 +781,21 @@ func (p *QueryValidatePlugin) getFieldSelectionDataAux(msg *generator.Descriptor
 	fields = append(fields, msg.GetField()...)
 
 	for _, field := range fields {
+		subFields, ok := p.isAllowedNestedField(field.GetName(), nestedFields)
+		if !ok {
+			continue
+		}
+
 		opts := getQueryValidationOptions(field)
 		if sfield := p.syntheticField(field.GetName(), opts); sfield != nil {
 			field = sfield
 			opts = getQueryValidationOptions(sfield)
 		}
+		if subFields == nil {
+			subFields = opts.GetNestedFields()
+		}
 
-		if opts.GetFieldSelection().GetDisable() {
+		if opts.GetFieldSelection().GetDisable() || isSensitive(field, opts) || p.behaviorExclusion(field, opts)&excludeFieldSelection != 0 {
 			continue
 		}
 
@@ -533,12 +820,20 @@ func (p *QueryValidatePlugin) getFieldSelectionDataAux(msg *generator.Descriptor
 					protoTypeUInt64Value:
 				default:
 
-					if maxNesting == 1 {
+					if p.isMapField(field) {
+						break
+					}
+
+					if p.atDepthLimit(field, subFields, maxNesting, n) {
 						continue
 					}
 
 					nestedMsg := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
-					for _, v := range p.getFieldSelectionDataAux(nestedMsg, maxNesting-1) {
+					depth := n.nestDepth(field, nestedMsg, subFields, maxNesting)
+					if depth == 0 {
+						break
+					}
+					for _, v := range p.getFieldSelectionDataAux(nestedMsg, depth, subFields, n.enter(fieldName, nestedMsg)) {
 						data = append(data, fieldName+"."+v)
 					}
 				}
@@ -548,6 +843,9 @@ func (p *QueryValidatePlugin) getFieldSelectionDataAux(msg *generator.Descriptor
 		data = append(data, fieldName)
 	}
 
+	// A oneof is selectable as a pseudo-field standing for its members.
+	data = append(data, p.selectableOneofs(msg, data)...)
+
 	return data
 }
 
@@ -563,33 +861,7 @@ func (p *QueryValidatePlugin) getDenyRules(fieldName string, opts *options.Query
 		return nil
 	}
 
-	var supportedOps []options.QueryValidate_FilterOperator
-	if filterType == options.QueryValidate_NUMBER {
-		supportedOps = []options.QueryValidate_FilterOperator{
-			options.QueryValidate_EQ,
-			options.QueryValidate_GT,
-			options.QueryValidate_GE,
-			options.QueryValidate_LT,
-			options.QueryValidate_LE,
-			options.QueryValidate_IN,
-		}
-	} else if filterType == options.QueryValidate_STRING {
-		supportedOps = []options.QueryValidate_FilterOperator{
-			options.QueryValidate_EQ,
-			options.QueryValidate_MATCH,
-			options.QueryValidate_GT,
-			options.QueryValidate_GE,
-			options.QueryValidate_LT,
-			options.QueryValidate_LE,
-			options.QueryValidate_IN,
-			options.QueryValidate_IEQ,
-		}
-	} else if filterType == options.QueryValidate_BOOL {
-		supportedOps = []options.QueryValidate_FilterOperator{
-			options.QueryValidate_EQ,
-			options.QueryValidate_IN,
-		}
-	}
+	supportedOps := getSupportedOperators(filterType)
 
 	ops := opsAllowed
 	if len(opsDenied) > 0 {
@@ -640,33 +912,58 @@ func (p *QueryValidatePlugin) getDenyRules(fieldName string, opts *options.Query
 	return res
 }
 
+// getSupportedOperators returns the operators which may be allowed or denied
+// for the value type, IS_NULL included.
+func getSupportedOperators(filterType options.QueryValidate_ValueType) []options.QueryValidate_FilterOperator {
+	return options.FilteringOption{ValueType: filterType, Nullable: true}.SupportedOperators().Operators()
+}
+
+// getAllowedOperators returns the operators supported for the value type of
+// the option which are not denied. IS_NULL is supported for nullable fields
+// only.
+func getAllowedOperators(o options.FilteringOption) []options.QueryValidate_FilterOperator {
+	o.Allowed, o.AllowedSet = 0, false
+	return o.AllowedOperators().Operators()
+}
+
 func (p *QueryValidatePlugin) genValidateFiltering() {
-	p.P(`func `, p.validateFilteringMethodName, `(methodName string, f *query.Filtering) error {`)
-	p.P(`info, ok := `, p.requiredFilteringValidationVarName, `[methodName]`)
+	p.P(`func `, p.fileName(validateFilteringMethodSuffix), `(methodName string, f *query.Filtering) error {`)
+	p.P(`v, ok := `, p.fileName(methodValidatorsVarSuffix), `[methodName]`)
 	p.P(`if !ok {`)
 	p.P(`return nil`)
 	p.P(`}`)
-	p.P(`return options.ValidateFiltering(f, info)`)
+	p.P(`return v.ValidateFiltering(f)`)
+	p.P(`}`)
+}
+
+func (p *QueryValidatePlugin) genValidateFilteringString() {
+	p.P(`func `, p.fileName(validateFilteringStringMethodSuffix), `(methodName string, expr string) error {`)
+	p.P(`v, ok := `, p.fileName(methodValidatorsVarSuffix), `[methodName]`)
+	p.P(`if !ok {`)
+	p.P(`_, err := options.ParseFilteringString(expr)`)
+	p.P(`return err`)
+	p.P(`}`)
+	p.P(`return v.ValidateFilteringString(expr)`)
 	p.P(`}`)
 }
 
 func (p *QueryValidatePlugin) genValidateSorting() {
-	p.P(`func `, p.validateSortingMethodName, `(methodName string, s *query.Sorting) error {`)
-	p.P(`info, ok := `, p.requiredSortingValidationVarName, `[methodName]`)
+	p.P(`func `, p.fileName(validateSortingMethodSuffix), `(methodName string, s *query.Sorting) error {`)
+	p.P(`v, ok := `, p.fileName(methodValidatorsVarSuffix), `[methodName]`)
 	p.P(`if !ok {`)
 	p.P(`return nil`)
 	p.P(`}`)
-	p.P(`return options.ValidateSorting(s, info)`)
+	p.P(`return v.ValidateSorting(s)`)
 	p.P(`}`)
 }
 
 func (p *QueryValidatePlugin) genValidateFieldSelection() {
-	p.P(`func `, p.validateFieldSelectionMethodName, `(methodName string, s *query.FieldSelection) error {`)
-	p.P(`info, ok := `, p.requiredFieldSelectionValidationVarName, `[methodName]`)
+	p.P(`func `, p.fileName(validateFieldSelectionMethodSuffix), `(methodName string, s *query.FieldSelection) error {`)
+	p.P(`v, ok := `, p.fileName(methodValidatorsVarSuffix), `[methodName]`)
 	p.P(`if !ok {`)
 	p.P(`return nil`)
 	p.P(`}`)
-	p.P(`return options.ValidateFieldSelection(s, info)`)
+	p.P(`return v.ValidateFieldSelection(s)`)
 	p.P(`}`)
 }
 
@@ -0,0 +1,214 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+)
+
+// genPruners generates a function per message having selectable fields,
+// which zeroes the fields of the message not selected by an
+// options.FieldTree. The pruners refer to the Go types of the messages and
+// are generated only if the pruners=true parameter is passed.
+func (p *QueryValidatePlugin) genPruners() {
+	if !p.pruners {
+		return
+	}
+
+	msgs := p.getPrunedMessages()
+	names := make([]string, 0, len(msgs))
+	for name := range msgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.genPruner(msgs[name], msgs)
+	}
+}
+
+// getPrunedMessages returns the result messages of the methods supporting
+// field selection and the messages nested in them having selectable fields
+// keyed by the Go type name. Messages declared in files which are not
+// imported by the current file directly are not pruned, as their Go
+// packages are not imported by the generated code.
+func (p *QueryValidatePlugin) getPrunedMessages() map[string]*generator.Descriptor {
+	msgs := make(map[string]*generator.Descriptor)
+	add := func(msg *generator.Descriptor) bool {
+		if msg.File() != p.currentFile && !p.isDependency(msg.File()) {
+			return false
+		}
+		if p.DefaultPackageName(msg) != "" {
+			p.prunedImports[msg.GoImportPath()] = true
+		}
+		msgs[p.TypeName(msg)] = msg
+		return true
+	}
+
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil || !p.hasFieldSelection(inputMsg) {
+				continue
+			}
+
+			add(resultMsg)
+			for _, f := range p.getFieldSelectionData(resultMsg) {
+				msg := resultMsg
+				path := strings.Split(f, ".")
+				for _, name := range path[:len(path)-1] {
+					field := msg.GetFieldDescriptor(name)
+					if field == nil || field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+						break
+					}
+					if msg, _ = p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor); msg == nil || !add(msg) {
+						break
+					}
+				}
+			}
+		}
+	}
+	return msgs
+}
+
+// isDependency reports whether the file is imported by the current file.
+func (p *QueryValidatePlugin) isDependency(file *generator.FileDescriptor) bool {
+	for _, dep := range p.currentFile.GetDependency() {
+		if dep == file.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+// prunerName returns the name of the pruner of the message, which includes
+// the Go package name of messages declared in other packages.
+func (p *QueryValidatePlugin) prunerName(msg *generator.Descriptor) string {
+	typeName := p.TypeName(msg)
+	if i := strings.Index(typeName, "."); i >= 0 {
+		typeName = generator.CamelCase(typeName[:i]) + typeName[i+1:]
+	}
+	return p.fileName(prunerSuffix) + typeName
+}
+
+func (p *QueryValidatePlugin) genPruner(msg *generator.Descriptor, msgs map[string]*generator.Descriptor) {
+	typeName := p.TypeName(msg)
+	p.P(`// `, p.prunerName(msg), ` zeroes the fields of m not selected by fields, a nil tree selects all fields.`)
+	p.P(`func `, p.prunerName(msg), `(m *`, typeName, `, fields options.FieldTree) {`)
+	p.P(`if m == nil || fields == nil {`)
+	p.P(`return`)
+	p.P(`}`)
+
+	// nested returns the pruner of the message of the field, if any.
+	nested := func(field *descriptor.FieldDescriptorProto) string {
+		if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE || p.IsMap(field) {
+			return ""
+		}
+		obj, ok := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+		if !ok || msgs[p.TypeName(obj)] == nil {
+			return ""
+		}
+		return p.prunerName(obj)
+	}
+
+	for _, field := range msg.GetField() {
+		goName := p.GetFieldName(msg, field)
+		goType, _ := p.GoType(msg, field)
+		// A proto3 optional field is the only member of a synthetic oneof,
+		// but it is generated as a pointer field named after itself.
+		if isProto3Optional(field) {
+			goName = p.GetOneOfFieldName(msg, field)
+			goType = "*" + strings.TrimPrefix(goType, "*")
+		} else if field.OneofIndex != nil {
+			continue
+		}
+
+		pruner := nested(field)
+		if pruner == "" {
+			p.P(`if _, ok := fields["`, field.GetName(), `"]; !ok {`)
+			p.P(`m.`, goName, ` = `, p.zeroValue(field, goType))
+			p.P(`}`)
+			continue
+		}
+
+		p.P(`if sub, ok := fields["`, field.GetName(), `"]; !ok {`)
+		p.P(`m.`, goName, ` = `, p.zeroValue(field, goType))
+		p.P(`} else {`)
+		switch {
+		case strings.HasPrefix(goType, "[]*"):
+			p.P(`for _, v := range m.`, goName, ` {`)
+			p.P(pruner, `(v, sub)`)
+			p.P(`}`)
+		case strings.HasPrefix(goType, "[]"):
+			p.P(`for i := range m.`, goName, ` {`)
+			p.P(pruner, `(&m.`, goName, `[i], sub)`)
+			p.P(`}`)
+		case strings.HasPrefix(goType, "*"):
+			p.P(pruner, `(m.`, goName, `, sub)`)
+		default:
+			p.P(pruner, `(&m.`, goName, `, sub)`)
+		}
+		p.P(`}`)
+	}
+
+	for i := range msg.GetOneofDecl() {
+		if isSyntheticOneof(msg, i) {
+			continue
+		}
+		var members []*descriptor.FieldDescriptorProto
+		descend := false
+		for _, field := range msg.GetField() {
+			if field.OneofIndex != nil && int(field.GetOneofIndex()) == i {
+				members = append(members, field)
+				descend = descend || nested(field) != ""
+			}
+		}
+
+		oneofName := p.GetFieldName(msg, members[0])
+		if descend {
+			p.P(`switch x := m.`, oneofName, `.(type) {`)
+		} else {
+			p.P(`switch m.`, oneofName, `.(type) {`)
+		}
+		for _, field := range members {
+			p.P(`case *`, p.OneOfTypeName(msg, field), `:`)
+			if pruner := nested(field); pruner != "" {
+				p.P(`if sub, ok := fields["`, field.GetName(), `"]; !ok {`)
+				p.P(`m.`, oneofName, ` = nil`)
+				p.P(`} else {`)
+				p.P(pruner, `(x.`, p.GetOneOfFieldName(msg, field), `, sub)`)
+				p.P(`}`)
+			} else {
+				p.P(`if _, ok := fields["`, field.GetName(), `"]; !ok {`)
+				p.P(`m.`, oneofName, ` = nil`)
+				p.P(`}`)
+			}
+		}
+		p.P(`}`)
+	}
+
+	p.P(`}`)
+	p.P()
+}
+
+// zeroValue returns the Go expression of the zero value of the field of
+// the goType.
+func (p *QueryValidatePlugin) zeroValue(field *descriptor.FieldDescriptorProto, goType string) string {
+	switch {
+	case p.IsMap(field),
+		strings.HasPrefix(goType, "*"),
+		strings.HasPrefix(goType, "[]"):
+		return "nil"
+	case goType == "string":
+		return `""`
+	case goType == "bool":
+		return "false"
+	case field.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return goType + "{}"
+	default:
+		return "0"
+	}
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	plugin_go "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/gogo/protobuf/vanity/command"
+)
+
+// The protoc releases the examples are built with predate proto3 optional
+// fields, so the pruners are tested on descriptors carrying the
+// proto3_optional flag as an unknown field.
+func TestPrunerProto3Optional(t *testing.T) {
+	proto3Optional := proto.NewBuffer(nil)
+	proto3Optional.EncodeVarint(uint64(proto3OptionalFieldNumber<<3 | proto.WireVarint))
+	proto3Optional.EncodeVarint(1)
+
+	field := func(name string, number int32, typ descriptor.FieldDescriptorProto_Type, typeName string) *descriptor.FieldDescriptorProto {
+		f := &descriptor.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+			JsonName: proto.String(name),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	floor := field("floor", 2, descriptor.FieldDescriptorProto_TYPE_INT32, "")
+	floor.OneofIndex = proto.Int32(0)
+	floor.XXX_unrecognized = proto3Optional.Bytes()
+	room := field("room", 3, descriptor.FieldDescriptorProto_TYPE_STRING, "")
+	room.OneofIndex = proto.Int32(1)
+	code := field("code", 4, descriptor.FieldDescriptorProto_TYPE_STRING, "")
+	code.OneofIndex = proto.Int32(1)
+
+	req := &plugin_go.CodeGeneratorRequest{
+		FileToGenerate: []string{"shelf.proto"},
+		Parameter:      proto.String("pruners=true"),
+		ProtoFile: []*descriptor.FileDescriptorProto{
+			{
+				Name:    proto.String("query.proto"),
+				Package: proto.String("infoblox.api"),
+				Syntax:  proto.String("proto3"),
+				Options: &descriptor.FileOptions{GoPackage: proto.String("github.com/infobloxopen/atlas-app-toolkit/query")},
+				MessageType: []*descriptor.DescriptorProto{
+					{Name: proto.String("FieldSelection")},
+				},
+			},
+			{
+				Name:       proto.String("shelf.proto"),
+				Package:    proto.String("example"),
+				Syntax:     proto.String("proto3"),
+				Dependency: []string{"query.proto"},
+				Options:    &descriptor.FileOptions{GoPackage: proto.String("example")},
+				MessageType: []*descriptor.DescriptorProto{
+					{
+						Name:  proto.String("Shelf"),
+						Field: []*descriptor.FieldDescriptorProto{field("name", 1, descriptor.FieldDescriptorProto_TYPE_STRING, ""), floor, room, code},
+						OneofDecl: []*descriptor.OneofDescriptorProto{
+							{Name: proto.String("_floor")},
+							{Name: proto.String("location")},
+						},
+					},
+					{
+						Name:  proto.String("ListShelvesRequest"),
+						Field: []*descriptor.FieldDescriptorProto{field("fields", 1, descriptor.FieldDescriptorProto_TYPE_MESSAGE, ".infoblox.api.FieldSelection")},
+					},
+					{
+						Name:  proto.String("ListShelvesResponse"),
+						Field: []*descriptor.FieldDescriptorProto{field("results", 1, descriptor.FieldDescriptorProto_TYPE_MESSAGE, ".example.Shelf")},
+					},
+				},
+				Service: []*descriptor.ServiceDescriptorProto{
+					{
+						Name: proto.String("Library"),
+						Method: []*descriptor.MethodDescriptorProto{
+							{Name: proto.String("ListShelves"), InputType: proto.String(".example.ListShelvesRequest"), OutputType: proto.String(".example.ListShelvesResponse")},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	p := &QueryValidatePlugin{}
+	res := command.GeneratePlugin(req, p, ".pb.atlas.query.validate.go")
+	if res.Error != nil {
+		t.Fatalf("Unexpected error: %s", res.GetError())
+	}
+	if len(res.File) == 0 {
+		t.Fatalf("Expected a generated file")
+	}
+	content := res.File[0].GetContent()
+	for _, s := range []string{
+		`m.Floor = nil`,
+		`switch m.Location.(type) {`,
+	} {
+		if !strings.Contains(content, s) {
+			t.Errorf("Expected the pruner to contain %q, but got:\n%s", s, content)
+		}
+	}
+	if strings.Contains(content, `XFloor`) {
+		t.Errorf("Unexpected pruning of the synthetic oneof:\n%s", content)
+	}
+}
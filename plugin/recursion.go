@@ -0,0 +1,188 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// nesting tracks the fields and messages on the path from the result message
+// to the message being traversed. A field whose message type is already on
+// the path is recorded as a recursion, whose paths are resolved at run time.
+type nesting struct {
+	path       []string
+	msgs       []*generator.Descriptor
+	recursions map[string]options.Recursion
+	// recursive reports that the path goes through a recorded recursion, so
+	// that the recursions below it are not recorded again.
+	recursive bool
+}
+
+func newNesting(msg *generator.Descriptor) *nesting {
+	return &nesting{
+		msgs:       []*generator.Descriptor{msg},
+		recursions: make(map[string]options.Recursion),
+	}
+}
+
+// enter returns the nesting of the message of the field.
+func (n *nesting) enter(fieldName string, msg *generator.Descriptor) *nesting {
+	path := append(n.path[:len(n.path):len(n.path)], fieldName)
+	_, recorded := n.recursions[strings.Join(path, ".")]
+	return &nesting{
+		path:       path,
+		msgs:       append(n.msgs[:len(n.msgs):len(n.msgs)], msg),
+		recursions: n.recursions,
+		recursive:  n.recursive || recorded,
+	}
+}
+
+// target returns the index of the message on the path the field is a
+// recursion of or -1. Fields with nested_fields patterns are not recursions
+// as the patterns may differ from those of the enclosing field, and repeated
+// fields are not resolved recursively.
+func (n *nesting) target(field *descriptor.FieldDescriptorProto, msg *generator.Descriptor, subFields []string) int {
+	if len(subFields) > 0 || field.IsRepeated() {
+		return -1
+	}
+	for i, m := range n.msgs {
+		if m == msg {
+			return i
+		}
+	}
+	return -1
+}
+
+// nestDepth returns the nesting depth the message of the field is expanded
+// to, which is one less than the depth of the enclosing message. A field of
+// a recursive message type is recorded as a recursion, so that the paths
+// below it are resolved at run time up to its max_depth, or up to the depth
+// limit if max_depth is not set, and they are expanded up to the lesser of
+// the two as well, e.g. for the package level validation functions.
+func (n *nesting) nestDepth(field *descriptor.FieldDescriptorProto, msg *generator.Descriptor, subFields []string, maxNesting int) int {
+	depth := maxNesting - 1
+	i := n.target(field, msg, subFields)
+	if i < 0 {
+		return depth
+	}
+
+	maxDepth := int(getQueryValidationOptions(field).GetMaxDepth())
+	if maxDepth == 0 {
+		maxDepth = depth
+	}
+	if maxDepth == 0 {
+		return 0
+	}
+	if !n.recursive {
+		fieldPath := strings.Join(append(n.path[:len(n.path):len(n.path)], field.GetName()), ".")
+		n.recursions[fieldPath] = options.Recursion{
+			Target:   strings.Join(n.path[:i], "."),
+			MaxDepth: maxDepth,
+		}
+	}
+	if maxDepth < depth {
+		depth = maxDepth
+	}
+	return depth
+}
+
+// atDepthLimit reports whether the message field is not expanded as the
+// depth limit is reached, unless it is a recursion with max_depth.
+func (p *QueryValidatePlugin) atDepthLimit(field *descriptor.FieldDescriptorProto, subFields []string, maxNesting int, n *nesting) bool {
+	if maxNesting > 1 {
+		return false
+	}
+	if getQueryValidationOptions(field).GetMaxDepth() == 0 || field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		return true
+	}
+	msg, ok := p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor)
+	return !ok || n.target(field, msg, subFields) < 0
+}
+
+func (p *QueryValidatePlugin) getRecursionData(inputMsg, resultMsg *generator.Descriptor) options.MethodRecursions {
+	var (
+		res   options.MethodRecursions
+		depth = p.getNestDepth(resultMsg)
+	)
+	if p.hasFiltering(inputMsg) {
+		n := newNesting(resultMsg)
+		p.getFilteringDataAux(resultMsg, depth, nil, n)
+		res.Filtering = n.result()
+	}
+	if p.hasSorting(inputMsg) {
+		n := newNesting(resultMsg)
+		p.getSortingDataAux(resultMsg, depth, nil, n)
+		res.Sorting = n.result()
+	}
+	if p.hasFieldSelection(inputMsg) {
+		n := newNesting(resultMsg)
+		p.getFieldSelectionDataAux(resultMsg, depth, nil, n)
+		res.FieldSelection = n.result()
+	}
+	return res
+}
+
+func (n *nesting) result() map[string]options.Recursion {
+	if len(n.recursions) == 0 {
+		return nil
+	}
+	return n.recursions
+}
+
+func (p *QueryValidatePlugin) genRecursions() {
+	p.P(`var `, p.fileName(methodRecursionVarSuffix), ` = map[string]options.MethodRecursions{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil {
+				continue
+			}
+
+			r := p.getRecursionData(inputMsg, resultMsg)
+			if r.Filtering == nil && r.Sorting == nil && r.FieldSelection == nil {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			p.genRecursionMap("Filtering", r.Filtering)
+			p.genRecursionMap("Sorting", r.Sorting)
+			p.genRecursionMap("FieldSelection", r.FieldSelection)
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+func (p *QueryValidatePlugin) genRecursionMap(name string, recursions map[string]options.Recursion) {
+	if recursions == nil {
+		return
+	}
+
+	paths := make([]string, 0, len(recursions))
+	for path := range recursions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	p.P(name, `: map[string]options.Recursion{`)
+	for _, path := range paths {
+		r := recursions[path]
+		var f string
+		if r.Target != "" {
+			f += `Target: "` + r.Target + `",`
+		}
+		if r.MaxDepth != 0 {
+			f += `MaxDepth: ` + strconv.Itoa(r.MaxDepth) + `,`
+		}
+		p.P(`"`, path, `": {`, f, `},`)
+	}
+	p.P(`},`)
+}
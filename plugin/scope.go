@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func (p *QueryValidatePlugin) genScopeFiltering() {
+	p.P(`func `, p.fileName(scopeFilteringMethodSuffix), `(ctx context.Context, methodName string, f *query.Filtering) (*query.Filtering, error) {`)
+	p.P(`v, ok := `, p.fileName(methodValidatorsVarSuffix), `[methodName]`)
+	p.P(`if !ok {`)
+	p.P(`return nil, &options.UnscopedMethodError{Method: methodName}`)
+	p.P(`}`)
+	p.P(`return v.ScopeFiltering(ctx, f)`)
+	p.P(`}`)
+}
+
+// getScopeRule returns the scope rule of the method set by the method option
+// or by the option of the result message, nil if there is none. The option
+// of the result message is ignored by the methods not supporting filtering.
+func (p *QueryValidatePlugin) getScopeRule(method *descriptor.MethodDescriptorProto, inputMsg, resultMsg *generator.Descriptor) *options.ScopeRule {
+	scopeField := getMethodOptions(method).GetScopeField()
+	if scopeField == "" {
+		if !p.hasFiltering(inputMsg) {
+			return nil
+		}
+		scopeField = p.getMessageOptions(resultMsg.DescriptorProto).GetScopeField()
+	}
+	if scopeField == "" {
+		return nil
+	}
+	if !p.hasFiltering(inputMsg) {
+		p.Fail(`scope_field of method`, method.GetName(), `which does not support filtering`)
+	}
+
+	field, opts := p.lookupField(resultMsg, strings.Split(scopeField, "."))
+	if field == nil {
+		p.Fail(`scope_field`, scopeField, `does not name a field of`, resultMsg.GetName())
+	}
+	valueType := opts.GetValueType()
+	if valueType == options.QueryValidate_DEFAULT {
+		valueType = p.getValueType(field)
+	}
+	if valueType == options.QueryValidate_DEFAULT || field.IsRepeated() {
+		p.Fail(`scope_field`, scopeField, `of`, resultMsg.GetName(), `must be a scalar field`)
+	}
+	return &options.ScopeRule{Field: scopeField, ValueType: valueType}
+}
+
+func getMethodOptions(method *descriptor.MethodDescriptorProto) *options.MethodQueryValidate {
+	if method.Options == nil {
+		return nil
+	}
+
+	v, err := proto.GetExtension(method.Options, options.E_Method)
+	if err != nil {
+		return nil
+	}
+
+	opts, ok := v.(*options.MethodQueryValidate)
+	if !ok {
+		return nil
+	}
+	return opts
+}
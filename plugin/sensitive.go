@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// debugRedactFieldNumber is the number of the debug_redact field option,
+// which is newer than the descriptor the generator is built with, so the
+// option is read from the unrecognized fields.
+const debugRedactFieldNumber = 16
+
+// isSensitive reports whether the field is marked as sensitive by the query
+// validation options or by the debug_redact field option.
+func isSensitive(field *descriptor.FieldDescriptorProto, opts *options.QueryValidate) bool {
+	return opts.GetSensitive() || hasDebugRedact(field)
+}
+
+func hasDebugRedact(field *descriptor.FieldDescriptorProto) bool {
+	if field.Options == nil {
+		return false
+	}
+	f := lastWireField(decodeWireFields(field.Options.XXX_unrecognized), debugRedactFieldNumber)
+	return f != nil && f.wireType == proto.WireVarint && f.value != 0
+}
+
+func (p *QueryValidatePlugin) genSensitive() {
+	p.P(`var `, p.fileName(sensitiveFieldsVarSuffix), ` = map[string][]string{`)
+	for _, srv := range p.currentFile.GetService() {
+		for _, method := range srv.GetMethod() {
+			inputMsg := p.ObjectNamed(method.GetInputType()).(*generator.Descriptor)
+			outputMsg := p.ObjectNamed(method.GetOutputType()).(*generator.Descriptor)
+			resultMsg := p.getResultMessage(outputMsg)
+			if resultMsg == nil || !p.hasFieldSelection(inputMsg) {
+				continue
+			}
+			fields := p.getSensitiveData(resultMsg, p.getFieldSelectionData(resultMsg))
+			if len(fields) == 0 {
+				continue
+			}
+
+			p.P(`"`, fmt.Sprintf("/%s.%s/%s", p.currentFile.GetPackage(), srv.GetName(), method.GetName()), `": {`)
+			for _, field := range fields {
+				p.P(`"`, field, `",`)
+			}
+			p.P(`},`)
+		}
+	}
+	p.P(`}`)
+}
+
+// getSensitiveData returns the paths of the sensitive fields of msg and of the
+// selectable fields having selectable nested fields, which are left out when
+// their parent or no field is selected. The nested fields of a field selected
+// as a whole are not known at run time, so the sensitive fields in it are not
+// listed.
+func (p *QueryValidatePlugin) getSensitiveData(msg *generator.Descriptor, fields []string) []string {
+	parents := map[string]bool{"": true}
+	for _, f := range fields {
+		if i := strings.LastIndex(f, "."); i > 0 {
+			parents[f[:i]] = true
+		}
+	}
+
+	var data []string
+	for _, parent := range append([]string{""}, fields...) {
+		if !parents[parent] {
+			continue
+		}
+		nested := msg
+		if parent != "" {
+			field, _ := p.lookupField(msg, strings.Split(parent, "."))
+			if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+				continue
+			}
+			if nested, _ = p.ObjectNamed(field.GetTypeName()).(*generator.Descriptor); nested == nil {
+				continue
+			}
+		}
+		for _, field := range nested.GetField() {
+			if isSensitive(field, getQueryValidationOptions(field)) {
+				data = append(data, strings.TrimPrefix(parent+"."+field.GetName(), "."))
+			}
+		}
+	}
+	return data
+}
+
+// getSensitiveFilteringData returns the filtering rule of a sensitive field,
+// which denies all operators unless some are explicitly allowed and the
+// sensitive_override option acknowledges it. field is nil for the entries of
+// the message option naming nested fields.
+func (p *QueryValidatePlugin) getSensitiveFilteringData(fieldName string, field *descriptor.FieldDescriptorProto, opts *options.QueryValidate) fieldValidate {
+	data := p.getDenyAllFilteringData(fieldName, field, opts)
+
+	if len(opts.GetFiltering().GetAllow()) > 0 {
+		if !opts.GetSensitiveOverride() {
+			p.Fail(fieldName, ": filtering operators allowed on a sensitive field without sensitive_override")
+		}
+		if data.option.ValueType != options.QueryValidate_DEFAULT {
+			data.option.Deny = p.getDenyRules(fieldName, opts, data.option.ValueType)
+		}
+	}
+	return data
+}
+
+// getDenyAllFilteringData returns the filtering rule denying all operators
+// on the field, nested fields included.
+func (p *QueryValidatePlugin) getDenyAllFilteringData(fieldName string, field *descriptor.FieldDescriptorProto, opts *options.QueryValidate) fieldValidate {
+	valueType := opts.GetValueType()
+	if valueType == options.QueryValidate_DEFAULT && field != nil && !field.IsRepeated() {
+		valueType = p.getValueType(field)
+	}
+	return fieldValidate{fieldName, options.FilteringOption{
+		ValueType: valueType,
+		Deny:      []options.QueryValidate_FilterOperator{options.QueryValidate_ALL},
+	}}
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// The protoc releases the examples are built with predate the debug_redact
+// option, so it is tested on descriptors carrying it as an unknown field.
+func TestIsSensitive(t *testing.T) {
+	debugRedact := func(v uint64) []byte {
+		b := proto.NewBuffer(nil)
+		b.EncodeVarint(uint64(debugRedactFieldNumber<<3 | proto.WireVarint))
+		b.EncodeVarint(v)
+		return b.Bytes()
+	}
+
+	tests := []struct {
+		Name         string
+		Unrecognized []byte
+		Opts         *options.QueryValidate
+		Sensitive    bool
+	}{
+		{"plain", nil, nil, false},
+		{"sensitive", nil, &options.QueryValidate{Sensitive: true}, true},
+		{"debug_redact", debugRedact(1), nil, true},
+		{"debug_redact_false", debugRedact(0), nil, false},
+		{"debug_redact_overridden", append(debugRedact(1), debugRedact(0)...), nil, false},
+	}
+
+	for _, test := range tests {
+		field := &descriptor.FieldDescriptorProto{
+			Name:    proto.String("serial"),
+			Options: &descriptor.FieldOptions{XXX_unrecognized: test.Unrecognized},
+		}
+		if sensitive := isSensitive(field, test.Opts); sensitive != test.Sensitive {
+			t.Errorf("Expected %s field to be sensitive: %t, but got %t", test.Name, test.Sensitive, sensitive)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"github.com/gogo/protobuf/proto"
+)
+
+// wireField is a field of an encoded message decoded without knowing the
+// message type: value holds varint and fixed values, data the content of
+// length-delimited fields.
+type wireField struct {
+	number   int32
+	wireType int
+	value    uint64
+	data     []byte
+}
+
+// decodeWireFields returns the fields of the encoded message b in order,
+// the fields decoded before a malformed one if b is malformed. Groups are not
+// supported.
+func decodeWireFields(b []byte) []wireField {
+	var (
+		res []wireField
+		buf = proto.NewBuffer(b)
+	)
+	for {
+		key, err := buf.DecodeVarint()
+		if err != nil {
+			return res
+		}
+		f := wireField{number: int32(key >> 3), wireType: int(key & 7)}
+		switch f.wireType {
+		case proto.WireVarint:
+			f.value, err = buf.DecodeVarint()
+		case proto.WireFixed64:
+			f.value, err = buf.DecodeFixed64()
+		case proto.WireBytes:
+			f.data, err = buf.DecodeRawBytes(true)
+		case proto.WireFixed32:
+			f.value, err = buf.DecodeFixed32()
+		default:
+			return res
+		}
+		if err != nil {
+			return res
+		}
+		res = append(res, f)
+	}
+}
+
+// lastWireField returns the last field of the number, which wins for
+// singular fields, nil if there is none.
+func lastWireField(fields []wireField, number int32) *wireField {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].number == number {
+			return &fields[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,540 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// activation resolves the variables of an expression, the iteration variable
+// of a macro shadows the variables of the enclosing activations.
+type activation struct {
+	name   string
+	value  interface{}
+	vars   map[string]interface{}
+	parent *activation
+}
+
+func (a *activation) lookup(name string) (interface{}, error) {
+	for ; a != nil; a = a.parent {
+		if a.vars == nil && a.name == name {
+			return a.value, nil
+		}
+		if v, ok := a.vars[name]; ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("no such attribute: %s", name)
+}
+
+func (a *activation) bind(name string, value interface{}) *activation {
+	return &activation{name: name, value: value, parent: a}
+}
+
+type node interface {
+	eval(a *activation) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(*activation) (interface{}, error) {
+	return n.value, nil
+}
+
+type ident struct {
+	name string
+}
+
+func (n *ident) eval(a *activation) (interface{}, error) {
+	return a.lookup(n.name)
+}
+
+// selection is a field selection, or its presence test if test is set.
+type selection struct {
+	operand node
+	field   string
+	test    bool
+}
+
+func (n *selection) eval(a *activation) (interface{}, error) {
+	operand, err := n.operand.eval(a)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("type '%s' does not support field selection", typeName(operand))
+	}
+	v, ok := m[n.field]
+	if n.test {
+		return ok, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", n.field)
+	}
+	return v, nil
+}
+
+type indexing struct {
+	operand node
+	index   node
+}
+
+func (n *indexing) eval(a *activation) (interface{}, error) {
+	operand, err := n.operand.eval(a)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(a)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := operand.(type) {
+	case []interface{}:
+		i, ok := index.(float64)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("invalid list index: %v", index)
+		}
+		if i < 0 || int(i) >= len(c) {
+			return nil, fmt.Errorf("index out of range: %v", i)
+		}
+		return c[int(i)], nil
+
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("unsupported map key type '%s'", typeName(index))
+		}
+		v, ok := c[key]
+		if !ok {
+			return nil, fmt.Errorf("no such key: %s", key)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("type '%s' does not support indexing", typeName(operand))
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (n *unary) eval(a *activation) (interface{}, error) {
+	operand, err := n.operand.eval(a)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := operand.(type) {
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s%s", n.op, typeName(operand))
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (n *binary) eval(a *activation) (interface{}, error) {
+	if n.op == "&&" || n.op == "||" {
+		return n.logical(a)
+	}
+
+	left, err := n.left.eval(a)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(a)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	}
+
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return arithmetic(n.op, l, r)
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			if n.op == "+" {
+				return l + r, nil
+			}
+			return compare(n.op, strings.Compare(l, r))
+		}
+	case []interface{}:
+		if r, ok := right.([]interface{}); ok && n.op == "+" {
+			return append(append([]interface{}{}, l...), r...), nil
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s %s %s", typeName(left), n.op, typeName(right))
+}
+
+// logical evaluates the operators commutatively: an error of one operand is
+// ignored if the other operand decides the result.
+func (n *binary) logical(a *activation) (interface{}, error) {
+	decisive := n.op == "||"
+
+	left, lerr := evalBool(n.left, a)
+	if lerr == nil && left == decisive {
+		return decisive, nil
+	}
+	right, rerr := evalBool(n.right, a)
+	if rerr == nil && right == decisive {
+		return decisive, nil
+	}
+	if lerr != nil {
+		return nil, lerr
+	}
+	if rerr != nil {
+		return nil, rerr
+	}
+	return !decisive, nil
+}
+
+func evalBool(n node, a *activation) (bool, error) {
+	v, err := n.eval(a)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("no such overload: expected bool, got '%s'", typeName(v))
+	}
+	return b, nil
+}
+
+func arithmetic(op string, l, r float64) (interface{}, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("modulus by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+
+	switch {
+	case l < r:
+		return compare(op, -1)
+	case l > r:
+		return compare(op, 1)
+	}
+	return compare(op, 0)
+}
+
+func compare(op string, c int) (interface{}, error) {
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return nil, fmt.Errorf("no such overload: string %s string", op)
+}
+
+type conditional struct {
+	cond, then, otherwise node
+}
+
+func (n *conditional) eval(a *activation) (interface{}, error) {
+	cond, err := evalBool(n.cond, a)
+	if err != nil {
+		return nil, err
+	}
+	if cond {
+		return n.then.eval(a)
+	}
+	return n.otherwise.eval(a)
+}
+
+type list struct {
+	elems []node
+}
+
+func (n *list) eval(a *activation) (interface{}, error) {
+	res := make([]interface{}, 0, len(n.elems))
+	for _, e := range n.elems {
+		v, err := e.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+type mapping struct {
+	keys, values []node
+}
+
+func (n *mapping) eval(a *activation) (interface{}, error) {
+	res := make(map[string]interface{}, len(n.keys))
+	for i := range n.keys {
+		k, err := n.keys[i].eval(a)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("unsupported map key type '%s'", typeName(k))
+		}
+		if res[key], err = n.values[i].eval(a); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+type call struct {
+	function string
+	args     []node
+}
+
+func (n *call) eval(a *activation) (interface{}, error) {
+	args := make([]interface{}, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return functions[n.function](args)
+}
+
+// comprehension is a macro iterating over the elements of a list or the
+// keys of a map.
+type comprehension struct {
+	kind     string
+	target   node
+	variable string
+	body     node
+}
+
+func (n *comprehension) eval(a *activation) (interface{}, error) {
+	target, err := n.target.eval(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var elems []interface{}
+	switch c := target.(type) {
+	case []interface{}:
+		elems = c
+	case map[string]interface{}:
+		for k := range c {
+			elems = append(elems, k)
+		}
+	default:
+		return nil, fmt.Errorf("type '%s' does not support %s()", typeName(target), n.kind)
+	}
+
+	if n.kind == "map" {
+		res := make([]interface{}, 0, len(elems))
+		for _, e := range elems {
+			v, err := n.body.eval(a.bind(n.variable, e))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	}
+
+	var (
+		matched  []interface{}
+		firstErr error
+	)
+	for _, e := range elems {
+		ok, err := evalBool(n.body, a.bind(n.variable, e))
+		if err != nil {
+			// Like the logical operators, all and exists ignore the
+			// errors if an element decides the result.
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		switch {
+		case n.kind == "all" && !ok:
+			return false, nil
+		case n.kind == "exists" && ok:
+			return true, nil
+		case ok:
+			matched = append(matched, e)
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	switch n.kind {
+	case "all":
+		return true, nil
+	case "exists":
+		return false, nil
+	case "exists_one":
+		return len(matched) == 1, nil
+	}
+	if matched == nil {
+		matched = []interface{}{}
+	}
+	return matched, nil
+}
+
+// functions are the supported functions, member functions take the target
+// as the first argument.
+var functions = map[string]func(args []interface{}) (interface{}, error){
+	"size": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("size() takes a single argument")
+		}
+		switch v := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("no such overload: size(%s)", typeName(args[0]))
+	},
+	"contains":   stringFunction("contains", strings.Contains),
+	"startsWith": stringFunction("startsWith", strings.HasPrefix),
+	"endsWith":   stringFunction("endsWith", strings.HasSuffix),
+	"matches": stringFunction("matches", func(s, pattern string) bool {
+		re, err := regexp.Compile(pattern)
+		return err == nil && re.MatchString(s)
+	}),
+	"lowerAscii": func(args []interface{}) (interface{}, error) {
+		if len(args) == 1 {
+			if s, ok := args[0].(string); ok {
+				return strings.ToLower(s), nil
+			}
+		}
+		return nil, fmt.Errorf("no such overload: lowerAscii()")
+	},
+	"upperAscii": func(args []interface{}) (interface{}, error) {
+		if len(args) == 1 {
+			if s, ok := args[0].(string); ok {
+				return strings.ToUpper(s), nil
+			}
+		}
+		return nil, fmt.Errorf("no such overload: upperAscii()")
+	},
+}
+
+func stringFunction(name string, fn func(s, arg string) bool) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) == 2 {
+			s, ok1 := args[0].(string)
+			arg, ok2 := args[1].(string)
+			if ok1 && ok2 {
+				return fn(s, arg), nil
+			}
+		}
+		return nil, fmt.Errorf("no such overload: %s()", name)
+	}
+}
+
+func contains(container, elem interface{}) (interface{}, error) {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, e := range c {
+			if equal(e, elem) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := elem.(string)
+		if !ok {
+			return false, nil
+		}
+		_, ok = c[key]
+		return ok, nil
+	}
+	return nil, fmt.Errorf("no such overload: %s in %s", typeName(elem), typeName(container))
+}
+
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null_type"
+	case bool:
+		return "bool"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
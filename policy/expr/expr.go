@@ -0,0 +1,141 @@
+// Package expr implements options.QueryAuthorizer with policies written in a
+// small expression language evaluated in-process. The expressions are not
+// type-checked, and all numbers are float64 like the numbers of the JSON
+// input, so 7 / 2 is 3.5 and 1 == 1.0.
+//
+// The policies are evaluated on options.AuthorizationInput.Document, whose
+// top-level keys are the method, principal and query variables:
+//
+//	!("created_by" in query.filter_fields) || "admin" in principal.roles
+//
+// The language has the literals, lists and maps, field selection and
+// indexing, the arithmetic, relational, logical and conditional operators,
+// the in operator, the has, all, exists, exists_one, filter and map macros
+// and the size, contains, startsWith, endsWith, matches, lowerAscii and
+// upperAscii functions.
+package expr
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// Variables are the variables declared to the policies.
+var Variables = []string{"method", "principal", "query"}
+
+// Program is a compiled expression.
+type Program struct {
+	expr string
+	root node
+}
+
+// Compile parses the expression referring to the declared variables.
+func Compile(expr string, vars ...string) (*Program, error) {
+	root, err := parse(expr, vars)
+	if err != nil {
+		return nil, fmt.Errorf("expr: invalid expression %q: %s", expr, err)
+	}
+	return &Program{expr: expr, root: root}, nil
+}
+
+// Eval evaluates the program. The values of the variables must be nil or of
+// the types produced by encoding/json: bool, float64, string,
+// []interface{} and map[string]interface{}.
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	return p.root.eval(&activation{vars: vars})
+}
+
+// String returns the source of the program.
+func (p *Program) String() string {
+	return p.expr
+}
+
+// Policy is an expression which must evaluate to true for a query to be
+// authorized.
+type Policy struct {
+	// Name identifies the policy in the errors.
+	Name string
+	// Methods are the full names of the methods the policy applies to,
+	// e.g. "/example.TestService/List", any method if empty.
+	Methods []string
+	// Expr refers to the Variables.
+	Expr string
+	// Message is the reason of the denial, the name of the policy by
+	// default.
+	Message string
+}
+
+type compiledPolicy struct {
+	Policy
+	program *Program
+	methods map[string]struct{}
+}
+
+// Authorizer authorizes the queries satisfying all policies applying to the
+// method. A policy failing to evaluate denies the query.
+type Authorizer struct {
+	policies []compiledPolicy
+}
+
+// NewAuthorizer compiles the policies.
+func NewAuthorizer(policies ...Policy) (*Authorizer, error) {
+	a := &Authorizer{}
+	for _, p := range policies {
+		program, err := Compile(p.Expr, Variables...)
+		if err != nil {
+			return nil, fmt.Errorf("expr: policy %s: %s", p.Name, err)
+		}
+
+		cp := compiledPolicy{Policy: p, program: program}
+		if len(p.Methods) > 0 {
+			cp.methods = make(map[string]struct{}, len(p.Methods))
+			for _, m := range p.Methods {
+				cp.methods[m] = struct{}{}
+			}
+		}
+		a.policies = append(a.policies, cp)
+	}
+	return a, nil
+}
+
+// MustNewAuthorizer is like NewAuthorizer but panics if a policy does not compile.
+func MustNewAuthorizer(policies ...Policy) *Authorizer {
+	a, err := NewAuthorizer(policies...)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// AuthorizeQuery implements options.QueryAuthorizer.
+func (a *Authorizer) AuthorizeQuery(ctx context.Context, input *options.AuthorizationInput) error {
+	var doc map[string]interface{}
+	for _, p := range a.policies {
+		if _, ok := p.methods[input.Method]; p.methods != nil && !ok {
+			continue
+		}
+		if doc == nil {
+			var err error
+			if doc, err = input.Document(); err != nil {
+				return err
+			}
+		}
+
+		res, err := p.program.Eval(doc)
+		if err != nil {
+			return &options.PermissionDeniedError{Reason: fmt.Sprintf("policy %s failed: %s", p.Name, err)}
+		}
+		if allowed, ok := res.(bool); !ok {
+			return &options.PermissionDeniedError{Reason: fmt.Sprintf("policy %s evaluated to '%s' instead of bool", p.Name, typeName(res))}
+		} else if !allowed {
+			reason := p.Message
+			if reason == "" {
+				reason = fmt.Sprintf("policy %s", p.Name)
+			}
+			return &options.PermissionDeniedError{Reason: reason}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package expr
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]interface{}{
+		"principal": map[string]interface{}{
+			"subject": "sam",
+			"roles":   []interface{}{"admin", "hr"},
+		},
+		"query": map[string]interface{}{
+			"filter_fields": []interface{}{"first_name", "created_by"},
+			"limit":         10.0,
+		},
+	}
+
+	tests := []struct {
+		Expr   string
+		Result interface{}
+	}{
+		// Precedence and associativity.
+		{`1 + 2 * 3`, 7.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`10 - 4 - 3`, 3.0},
+		{`7 / 2`, 3.5},
+		{`7 % 4`, 3.0},
+		{`-2 * -3`, 6.0},
+		{`1 + 2 == 3`, true},
+		{`1 < 2 == true`, true},
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`!true || true`, true},
+		{`!!true`, true},
+		{`false ? 1 : 2`, 2.0},
+		{`false ? 1 : true ? 2 : 3`, 2.0},
+		{`1 == 1.0`, true},
+		{`1e2 == 100`, true},
+		{`1u == 1`, true},
+
+		// Literals, selection and indexing.
+		{`"a\tb" + 'c'`, "a\tbc"},
+		{`"a" < "b"`, true},
+		{`null == null`, true},
+		{`[1, 2,] + [3]`, []interface{}{1.0, 2.0, 3.0}},
+		{`{"a": 1, "b": [true]}.b[0]`, true},
+		{`{"a": 1}["a"]`, 1.0},
+		{`[1, 2] == [1, 2]`, true},
+		{`{"a": [1]} == {"a": [1]}`, true},
+		{`principal.subject`, "sam"},
+		{`principal.roles[1]`, "hr"},
+		{`query["limit"] <= 10`, true},
+		{`has(principal.subject)`, true},
+		{`has(principal.attributes)`, false},
+
+		// The in operator.
+		{`"admin" in principal.roles`, true},
+		{`"created_by" in query.filter_fields && !("admin" in principal.roles)`, false},
+		{`"limit" in query`, true},
+		{`1 in {"a": 1}`, false},
+
+		// Macros.
+		{`principal.roles.all(r, size(r) > 1)`, true},
+		{`principal.roles.exists(r, r == "hr")`, true},
+		{`principal.roles.exists_one(r, r.startsWith("a"))`, true},
+		{`[1, 2, 3].exists_one(x, x > 1)`, false},
+		{`[1, 2, 3].filter(x, x > 1)`, []interface{}{2.0, 3.0}},
+		{`[1, 2, 3].filter(x, x > 5)`, []interface{}{}},
+		{`[1, 2].map(x, x * 2)`, []interface{}{2.0, 4.0}},
+		{`{"a": 1}.all(k, k == "a")`, true},
+		{`[[1], [2]].all(x, x.exists(x, x > 0))`, true},
+		{`[1, 2].map(principal, principal + 1)`, []interface{}{2.0, 3.0}},
+		{`[].all(x, x)`, true},
+
+		// Errors decided by the other operand.
+		{`true || 1 / 0 == 1`, true},
+		{`1 / 0 == 1 || true`, true},
+		{`false && principal.missing`, false},
+		{`[0, 1].exists(x, 1 / x == 1)`, true},
+
+		// Functions.
+		{`size("héllo")`, 5.0},
+		{`size(principal.roles)`, 2.0},
+		{`principal.roles.size()`, 2.0},
+		{`"abc".contains("b")`, true},
+		{`"abc".endsWith("bc")`, true},
+		{`"abc".matches("^a.c$")`, true},
+		{`"AbC".lowerAscii()`, "abc"},
+		{`upperAscii("abc")`, "ABC"},
+	}
+
+	for _, test := range tests {
+		p, err := Compile(test.Expr, Variables...)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.Expr, err)
+			continue
+		}
+		res, err := p.Eval(vars)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.Expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, test.Result) {
+			t.Errorf("Expected %s to evaluate to %#v, but got %#v", test.Expr, test.Result, res)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	vars := map[string]interface{}{
+		"principal": map[string]interface{}{"roles": []interface{}{"admin"}},
+		"query":     map[string]interface{}{},
+	}
+
+	tests := []struct {
+		Expr string
+		Err  string
+	}{
+		// Type mismatches.
+		{`1 + "a"`, "no such overload: double + string"},
+		{`"a" - "b"`, "no such overload: string - string"},
+		{`[1] < [2]`, "no such overload: list < list"},
+		{`-"a"`, "no such overload: -string"},
+		{`!1`, "no such overload: !double"},
+		{`1 && true`, "no such overload: expected bool, got 'double'"},
+		{`1 ? 2 : 3`, "no such overload: expected bool, got 'double'"},
+		{`"a" in "abc"`, "no such overload: string in string"},
+		{`size(1)`, "no such overload: size(double)"},
+		{`size()`, "size() takes a single argument"},
+		{`"a".contains(1)`, "no such overload: contains()"},
+		{`lowerAscii()`, "no such overload: lowerAscii()"},
+		{`(1).x`, "type 'double' does not support field selection"},
+		{`"a"[0]`, "type 'string' does not support indexing"},
+		{`principal.roles["a"]`, "invalid list index: a"},
+		{`principal.roles[0.5]`, "invalid list index: 0.5"},
+		{`{1: 2}`, "unsupported map key type 'double'"},
+		{`{"a": 1}[1]`, "unsupported map key type 'double'"},
+		{`(1).all(x, x)`, "type 'double' does not support all()"},
+		{`[1].all(x, x)`, "no such overload: expected bool, got 'double'"},
+
+		// Runtime errors.
+		{`1 / 0`, "division by zero"},
+		{`1 % 0`, "modulus by zero"},
+		{`principal.roles[1]`, "index out of range: 1"},
+		{`principal.subject`, "no such key: subject"},
+		{`query["limit"]`, "no such key: limit"},
+		{`method == ""`, "no such attribute: method"},
+		{`false || 1 / 0 == 1`, "division by zero"},
+		{`[0, 1].all(x, 1 / x == 1)`, "division by zero"},
+	}
+
+	for _, test := range tests {
+		p, err := Compile(test.Expr, Variables...)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", test.Expr, err)
+			continue
+		}
+		res, err := p.Eval(vars)
+		if err == nil {
+			t.Errorf("Expected %s to fail, but got %#v", test.Expr, res)
+		} else if err.Error() != test.Err {
+			t.Errorf("Expected error %q for %s, but got %q", test.Err, test.Expr, err)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Err  string
+	}{
+		{``, "unexpected end of expression"},
+		{`1 +`, "unexpected end of expression"},
+		{`(1 + 2`, "unexpected end of expression"},
+		{`1 2`, `unexpected "2" at position 2`},
+		{`1 == = 2`, `unexpected character '=' at position 5`},
+		{`true ? false ? 1 : 2 : 3`, `unexpected "?" at position 13`},
+		{`true ? 1`, "unexpected end of expression"},
+		{`[1, 2`, "unexpected end of expression"},
+		{`{"a" 1}`, `unexpected "1" at position 5`},
+		{`"abc`, "unterminated string at position 0"},
+		{`"a\qb"`, "invalid escape sequence at position 2"},
+		{`1.2.3`, `invalid number "1.2.3" at position 0`},
+		{`1 # 2`, `unexpected character '#' at position 2`},
+		{`user.name`, "undeclared reference to 'user' at position 0"},
+		{`principal.`, "unexpected end of expression"},
+		{`principal.1`, `unexpected "1" at position 10`},
+		{`unknown(1)`, "undeclared function 'unknown'"},
+		{`principal.roles.apply(r)`, "undeclared function 'apply'"},
+		{`has(principal)`, "invalid argument to has() macro"},
+		{`principal.roles.all("r", true)`, "argument of all() macro must be a simple name at position 20"},
+		{`principal.roles.all(r)`, `unexpected ")" at position 21`},
+		{`principal.roles.all(r, r) || r`, "undeclared reference to 'r' at position 29"},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.Expr, Variables...)
+		if err == nil {
+			t.Errorf("Expected %s to fail", test.Expr)
+			continue
+		}
+		if !strings.HasSuffix(err.Error(), ": "+test.Err) {
+			t.Errorf("Expected error %q for %s, but got %q", test.Err, test.Expr, err)
+		}
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	a := MustNewAuthorizer(
+		Policy{
+			Name:    "created-by",
+			Expr:    `!("created_by" in query.filter_fields) || "admin" in principal.roles`,
+			Message: "only admins may filter by created_by",
+		},
+		Policy{
+			Name:    "list-limit",
+			Methods: []string{"/example.TestService/List"},
+			Expr:    `size(query.sort_fields) <= 1`,
+		},
+		Policy{
+			Name:    "not-bool",
+			Methods: []string{"/example.TestService/Read"},
+			Expr:    `principal.subject`,
+		},
+		Policy{
+			Name:    "failing",
+			Methods: []string{"/example.TestService/ListSites"},
+			Expr:    `principal.attributes.tenant == "t1"`,
+		},
+	)
+
+	input := func(method string, roles []string, filterFields, sortFields []string) *options.AuthorizationInput {
+		return &options.AuthorizationInput{
+			Method:    method,
+			Principal: &options.Principal{Subject: "sam", Roles: roles},
+			Query:     &options.QueryInput{FilterFields: filterFields, SortFields: sortFields, Fields: []string{}},
+		}
+	}
+
+	tests := []struct {
+		Input *options.AuthorizationInput
+		Err   string
+	}{
+		{input("/example.TestService/List", nil, []string{"first_name"}, []string{"id"}), ""},
+		{input("/example.TestService/List", []string{"admin"}, []string{"created_by"}, []string{}), ""},
+		{input("/example.TestService/List", []string{"hr"}, []string{"created_by"}, []string{}), "only admins may filter by created_by"},
+		{input("/example.TestService/List", nil, []string{}, []string{"id", "name"}), "policy list-limit"},
+		{input("/example.TestService/ListTargets", nil, []string{}, []string{"id", "name"}), ""},
+		{input("/example.TestService/Read", nil, []string{}, []string{}), "policy not-bool evaluated to 'string' instead of bool"},
+		{input("/example.TestService/ListSites", nil, []string{}, []string{}), "policy failing failed: no such key: attributes"},
+	}
+
+	for _, test := range tests {
+		err := a.AuthorizeQuery(context.Background(), test.Input)
+		if test.Err == "" {
+			if err != nil {
+				t.Errorf("Unexpected error for %s: %s", test.Input.Method, err)
+			}
+			continue
+		}
+		pde, ok := err.(*options.PermissionDeniedError)
+		if !ok {
+			t.Errorf("Expected PermissionDeniedError for %s, but got %v", test.Input.Method, err)
+			continue
+		}
+		if pde.Reason != test.Err {
+			t.Errorf("Expected reason %q for %s, but got %q", test.Err, test.Input.Method, pde.Reason)
+		}
+	}
+
+	if _, err := NewAuthorizer(Policy{Name: "broken", Expr: `principal.`}); err == nil || !strings.Contains(err.Error(), "policy broken") {
+		t.Errorf("Expected error of the broken policy, but got %v", err)
+	}
+}
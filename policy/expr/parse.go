@@ -0,0 +1,463 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// value is the decoded value of number and string literals.
+	value interface{}
+	pos   int
+}
+
+// punctuators are ordered so that the longest match is found first.
+var punctuators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"<", ">", "!", "+", "-", "*", "/", "%", "?", ":", ".", ",", "(", ")", "[", "]", "{", "}",
+}
+
+func scan(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(expr) && (expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.' || expr[i] == 'e' || expr[i] == 'E' ||
+				(expr[i] == '-' || expr[i] == '+') && (expr[i-1] == 'e' || expr[i-1] == 'E')) {
+				i++
+			}
+			// A trailing unsigned integer suffix is accepted and ignored.
+			text := expr[start:i]
+			if i < len(expr) && (expr[i] == 'u' || expr[i] == 'U') {
+				i++
+			}
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: v, pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var b strings.Builder
+			for {
+				if i >= len(expr) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				c := expr[i]
+				if c == byte(r) {
+					i++
+					break
+				}
+				if c != '\\' {
+					b.WriteByte(c)
+					i++
+					continue
+				}
+				if i+1 >= len(expr) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				switch e := expr[i+1]; e {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				case '\\', '"', '\'':
+					b.WriteByte(e)
+				default:
+					return nil, fmt.Errorf("invalid escape sequence at position %d", i)
+				}
+				i += 2
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expr[start:i], value: b.String(), pos: start})
+
+		default:
+			var punct string
+			for _, p := range punctuators {
+				if strings.HasPrefix(expr[i:], p) {
+					punct = p
+					break
+				}
+			}
+			if punct == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: punct, pos: i})
+			i += len(punct)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// macros are the functions taking an iteration variable and an expression
+// evaluated for every element of the target list or the keys of a map.
+var macros = map[string]bool{
+	"all":        true,
+	"exists":     true,
+	"exists_one": true,
+	"filter":     true,
+	"map":        true,
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	// scope holds the declared variables followed by the iteration
+	// variables of the enclosing macros.
+	scope []string
+}
+
+func parse(expr string, vars []string) (node, error) {
+	tokens, err := scan(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, scope: vars}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the punctuators.
+func (p *parser) accept(puncts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenPunct {
+		return "", false
+	}
+	for _, punct := range puncts {
+		if t.text == punct {
+			p.i++
+			return punct, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(punct string) error {
+	if _, ok := p.accept(punct); !ok {
+		return p.unexpected(p.peek())
+	}
+	return nil
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *parser) expr() (node, error) {
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+
+	then, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &conditional{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// precedence lists the binary operators from the lowest precedence.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"<", "<=", ">", ">=", "==", "!=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok && level == 2 && p.peek().kind == tokenIdent && p.peek().text == "in" {
+			op, ok = p.next().text, true
+		}
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: op, operand: operand}, nil
+	}
+	return p.member()
+}
+
+func (p *parser) member() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("."); ok {
+			t := p.next()
+			if t.kind != tokenIdent {
+				return nil, p.unexpected(t)
+			}
+			if _, ok := p.accept("("); !ok {
+				n = &selection{operand: n, field: t.text}
+				continue
+			}
+			if macros[t.text] {
+				n, err = p.macro(n, t.text)
+			} else {
+				n, err = p.call(n, t.text)
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if _, ok := p.accept("["); ok {
+			index, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexing{operand: n, index: index}
+			continue
+		}
+
+		return n, nil
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return &literal{value: t.value}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		}
+
+		if _, ok := p.accept("("); ok {
+			if t.text == "has" {
+				return p.has()
+			}
+			return p.call(nil, t.text)
+		}
+		for i := len(p.scope) - 1; i >= 0; i-- {
+			if p.scope[i] == t.text {
+				return &ident{name: t.text}, nil
+			}
+		}
+		return nil, fmt.Errorf("undeclared reference to '%s' at position %d", t.text, t.pos)
+
+	case tokenPunct:
+		switch t.text {
+		case "(":
+			n, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+
+		case "[":
+			elems, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			return &list{elems: elems}, nil
+
+		case "{":
+			m := &mapping{}
+			for {
+				if _, ok := p.accept("}"); ok {
+					return m, nil
+				}
+				key, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				m.keys = append(m.keys, key)
+				m.values = append(m.values, value)
+				if _, ok := p.accept(","); !ok {
+					if err := p.expect("}"); err != nil {
+						return nil, err
+					}
+					return m, nil
+				}
+			}
+		}
+	}
+	return nil, p.unexpected(t)
+}
+
+// list parses the comma-separated expressions up to the closing punctuator,
+// a trailing comma is allowed.
+func (p *parser) list(closing string) ([]node, error) {
+	var elems []node
+	for {
+		if _, ok := p.accept(closing); ok {
+			return elems, nil
+		}
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, n)
+		if _, ok := p.accept(","); !ok {
+			if err := p.expect(closing); err != nil {
+				return nil, err
+			}
+			return elems, nil
+		}
+	}
+}
+
+func (p *parser) call(target node, function string) (node, error) {
+	if _, ok := functions[function]; !ok {
+		return nil, fmt.Errorf("undeclared function '%s'", function)
+	}
+	args, err := p.list(")")
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		args = append([]node{target}, args...)
+	}
+	return &call{function: function, args: args}, nil
+}
+
+// has parses the argument of the has macro, which must be a field selection.
+func (p *parser) has() (node, error) {
+	arg, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	sel, ok := arg.(*selection)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument to has() macro")
+	}
+	return &selection{operand: sel.operand, field: sel.field, test: true}, nil
+}
+
+func (p *parser) macro(target node, kind string) (node, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, fmt.Errorf("argument of %s() macro must be a simple name at position %d", kind, t.pos)
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+
+	p.scope = append(p.scope, t.text)
+	body, err := p.expr()
+	p.scope = p.scope[:len(p.scope)-1]
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &comprehension{kind: kind, target: target, variable: t.text, body: body}, nil
+}
@@ -0,0 +1,152 @@
+// Package opa adapts an in-process Open Policy Agent query to
+// options.QueryAuthorizer without depending on OPA.
+//
+// The Rego policies receive options.AuthorizationInput.Document as input:
+//
+//	package atlas.query
+//
+//	deny[msg] {
+//		input.query.filter_fields[_] == "created_by"
+//		not admin
+//		msg := "only admins may filter by created_by"
+//	}
+//
+//	admin {
+//		input.principal.roles[_] == "admin"
+//	}
+//
+// The prepared query is wrapped with an EvaluatorFunc:
+//
+//	pq, err := rego.New(rego.Query("data.atlas.query.deny"), rego.Load(paths, nil)).PrepareForEval(ctx)
+//	...
+//	authorizer := opa.NewAuthorizer(opa.EvaluatorFunc(func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+//		rs, err := pq.Eval(ctx, rego.EvalInput(input))
+//		if err != nil || len(rs) == 0 || len(rs[0].Expressions) == 0 {
+//			return nil, err
+//		}
+//		return rs[0].Expressions[0].Value, nil
+//	}))
+package opa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+// Evaluator evaluates the policy decision for the input document. A nil
+// decision means the decision is undefined.
+type Evaluator interface {
+	Eval(ctx context.Context, input map[string]interface{}) (interface{}, error)
+}
+
+// EvaluatorFunc adapts a function to Evaluator.
+type EvaluatorFunc func(ctx context.Context, input map[string]interface{}) (interface{}, error)
+
+// Eval calls f(ctx, input).
+func (f EvaluatorFunc) Eval(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	return f(ctx, input)
+}
+
+// Authorizer authorizes the queries the policy decision allows. The decision
+// may be:
+//   - a boolean, e.g. the value of an allow rule;
+//   - a set of denial messages, e.g. the value of a deny[msg] rule, which
+//     allows the query if it is empty;
+//   - an object having an "allow" boolean and an optional "reason" string
+//     or "deny" set of messages, or having the "deny" set only.
+//
+// An undefined decision denies the query.
+type Authorizer struct {
+	Evaluator Evaluator
+}
+
+// NewAuthorizer returns an Authorizer consulting e.
+func NewAuthorizer(e Evaluator) *Authorizer {
+	return &Authorizer{Evaluator: e}
+}
+
+// AuthorizeQuery implements options.QueryAuthorizer.
+func (a *Authorizer) AuthorizeQuery(ctx context.Context, input *options.AuthorizationInput) error {
+	doc, err := input.Document()
+	if err != nil {
+		return err
+	}
+	decision, err := a.Evaluator.Eval(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("opa: %s", err)
+	}
+
+	allowed, reason, err := interpret(decision)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		if reason == "" {
+			reason = "denied by policy"
+		}
+		return &options.PermissionDeniedError{Reason: reason}
+	}
+	return nil
+}
+
+func interpret(decision interface{}) (allowed bool, reason string, err error) {
+	switch d := decision.(type) {
+	case nil:
+		return false, "undefined policy decision", nil
+
+	case bool:
+		return d, "", nil
+
+	case []interface{}:
+		msgs := messages(d)
+		return len(msgs) == 0, strings.Join(msgs, "; "), nil
+
+	case map[string]interface{}:
+		allow, hasAllow := d["allow"]
+		deny, hasDeny := d["deny"]
+		if !hasAllow && !hasDeny {
+			return false, "", fmt.Errorf("opa: decision has neither allow nor deny")
+		}
+
+		allowed = true
+		if hasAllow {
+			b, ok := allow.(bool)
+			if !ok {
+				return false, "", fmt.Errorf("opa: unexpected allow of type %T", allow)
+			}
+			allowed = b
+		}
+		var msgs []string
+		if hasDeny {
+			set, ok := deny.([]interface{})
+			if !ok {
+				return false, "", fmt.Errorf("opa: unexpected deny of type %T", deny)
+			}
+			msgs = messages(set)
+			allowed = allowed && len(msgs) == 0
+		}
+		if r, ok := d["reason"].(string); ok && r != "" {
+			msgs = append([]string{r}, msgs...)
+		}
+		return allowed, strings.Join(msgs, "; "), nil
+	}
+	return false, "", fmt.Errorf("opa: unexpected decision of type %T", decision)
+}
+
+// messages returns the sorted denial messages of the set.
+func messages(set []interface{}) []string {
+	var res []string
+	for _, m := range set {
+		if s, ok := m.(string); ok {
+			res = append(res, s)
+		} else {
+			res = append(res, fmt.Sprint(m))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,72 @@
+package opa
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+func TestAuthorizer(t *testing.T) {
+	input := &options.AuthorizationInput{
+		Method:    "/example.TestService/List",
+		Principal: &options.Principal{Subject: "sam", Roles: []string{"hr"}},
+		Query:     &options.QueryInput{FilterFields: []string{"created_by"}, SortFields: []string{}, Fields: []string{}},
+	}
+
+	tests := []struct {
+		Name     string
+		Decision interface{}
+		Denied   string
+		Err      string
+	}{
+		{Name: "allow", Decision: true},
+		{Name: "deny", Decision: false, Denied: "denied by policy"},
+		{Name: "undefined", Decision: nil, Denied: "undefined policy decision"},
+		{Name: "empty deny set", Decision: []interface{}{}},
+		{Name: "deny set", Decision: []interface{}{"b", "a", 1.0}, Denied: "1; a; b"},
+		{Name: "allow object", Decision: map[string]interface{}{"allow": true}},
+		{Name: "deny object", Decision: map[string]interface{}{"allow": false, "reason": "only admins"}, Denied: "only admins"},
+		{Name: "allow with denials", Decision: map[string]interface{}{"allow": true, "deny": []interface{}{"no"}}, Denied: "no"},
+		{Name: "reason and denials", Decision: map[string]interface{}{"allow": false, "reason": "r", "deny": []interface{}{"no"}}, Denied: "r; no"},
+		{Name: "deny only", Decision: map[string]interface{}{"deny": []interface{}{}}},
+		{Name: "empty object", Decision: map[string]interface{}{}, Err: "opa: decision has neither allow nor deny"},
+		{Name: "invalid allow", Decision: map[string]interface{}{"allow": "yes"}, Err: "opa: unexpected allow of type string"},
+		{Name: "invalid deny", Decision: map[string]interface{}{"deny": "no"}, Err: "opa: unexpected deny of type string"},
+		{Name: "invalid decision", Decision: 1.0, Err: "opa: unexpected decision of type float64"},
+	}
+
+	for _, test := range tests {
+		var got map[string]interface{}
+		a := NewAuthorizer(EvaluatorFunc(func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+			got = input
+			return test.Decision, nil
+		}))
+
+		err := a.AuthorizeQuery(context.Background(), input)
+		switch {
+		case test.Denied != "":
+			if pde, ok := err.(*options.PermissionDeniedError); !ok || pde.Reason != test.Denied {
+				t.Errorf("%s: expected denial %q, but got %v", test.Name, test.Denied, err)
+			}
+		case test.Err != "":
+			if _, ok := err.(*options.PermissionDeniedError); ok || err == nil || err.Error() != test.Err {
+				t.Errorf("%s: expected error %q, but got %v", test.Name, test.Err, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %s", test.Name, err)
+		}
+
+		if got["method"] != input.Method {
+			t.Errorf("%s: expected the input document of the method, but got %v", test.Name, got)
+		}
+	}
+
+	a := NewAuthorizer(EvaluatorFunc(func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		return nil, errors.New("compile error")
+	}))
+	if err := a.AuthorizeQuery(context.Background(), input); err == nil || err.Error() != "opa: compile error" {
+		t.Errorf("Expected the error of the evaluator, but got %v", err)
+	}
+}
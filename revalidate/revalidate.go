@@ -0,0 +1,143 @@
+// Package revalidate implements a command which checks stored filtering
+// expressions, e.g. saved searches, against the rules of a gRPC method
+// compiled into the binary by the generated code.
+//
+// The rules are taken from a registry, usually options.Registry populated at
+// initialization of the generated packages, so a service builds the command
+// by importing its generated package:
+//
+//	package main
+//
+//	import (
+//		"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+//		"github.com/infobloxopen/protoc-gen-atlas-query-validate/revalidate"
+//
+//		_ "github.com/example/service/pb"
+//	)
+//
+//	func main() {
+//		revalidate.Main(options.Registry)
+//	}
+package revalidate
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+)
+
+type aliasFlag map[string]string
+
+func (a aliasFlag) String() string {
+	var pairs []string
+	for k, v := range a {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (a aliasFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 || i == len(s)-1 {
+		return fmt.Errorf("invalid alias %q, expect old=new", s)
+	}
+	a[s[:i]] = s[i+1:]
+	return nil
+}
+
+// Main parses the command line and reports the filters read one per line
+// from the file given as the argument or from stdin against the rules of
+// the method registered in registry. Blank lines and lines starting with '#'
+// are skipped. Main exits with status 1 if any of the filters is invalid.
+func Main(registry *options.MethodRegistry) {
+	var (
+		method  = flag.String("method", "", "full gRPC method name, e.g. /example.TestService/List")
+		list    = flag.Bool("list", false, "list methods having rules and exit")
+		aliases = aliasFlag{}
+	)
+	flag.Var(aliases, "alias", "field rename as old=new, may be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s -method <method> [-alias old=new]... [file]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *list {
+		for _, m := range registry.Methods() {
+			fmt.Println(m)
+		}
+		return
+	}
+
+	if *method == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	in := io.Reader(os.Stdin)
+	if flag.NArg() == 1 && flag.Arg(0) != "-" {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	invalid, err := Run(os.Stdout, in, registry, *method, aliases)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+// Run reports the filters read from r against the rules of method in
+// registry to w, one line per filter, and returns the number of invalid
+// filters.
+func Run(w io.Writer, r io.Reader, registry *options.MethodRegistry, method string, aliases map[string]string) (int, error) {
+	v, ok := registry.Validator(method)
+	if !ok {
+		return 0, fmt.Errorf("no rules for method %s", method)
+	}
+
+	exprs, err := readFilters(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var invalid int
+	for _, rep := range v.RevalidateFilters(exprs, aliases) {
+		switch rep.Status {
+		case options.FilterValid:
+			fmt.Fprintf(w, "%s\t%s\n", rep.Status, rep.Expr)
+		case options.FilterFixable:
+			fmt.Fprintf(w, "%s\t%s\t%s\n", rep.Status, rep.Expr, rep.Fixed)
+		default:
+			invalid++
+			fmt.Fprintf(w, "%s\t%s\t%s\n", rep.Status, rep.Expr, rep.Err)
+		}
+	}
+	return invalid, nil
+}
+
+func readFilters(r io.Reader) ([]string, error) {
+	var exprs []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exprs = append(exprs, line)
+	}
+	return exprs, s.Err()
+}
@@ -0,0 +1,43 @@
+package revalidate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/protoc-gen-atlas-query-validate/options"
+
+	_ "github.com/infobloxopen/protoc-gen-atlas-query-validate/example"
+)
+
+func TestRun(t *testing.T) {
+	in := strings.NewReader(`
+# saved searches
+first_name=="Sam"
+name=="Sam"
+id=="1"
+`)
+	var out bytes.Buffer
+	invalid, err := Run(&out, in, options.Registry, "/example.TestService/List", map[string]string{"name": "first_name"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if invalid != 1 {
+		t.Errorf("Expected 1 invalid filter, but got %d", invalid)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []string{"VALID\tfirst_name==\"Sam\"", "FIXABLE\tname==\"Sam\"\tfirst_name==\"Sam\"", "INVALID\tid==\"1\"\t"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, but got %q", len(expected), out.String())
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Expected line %d to start with %q, but got %q", i, prefix, lines[i])
+		}
+	}
+
+	if _, err := Run(&out, strings.NewReader(""), options.Registry, "/example.TestService/Unknown", nil); err == nil {
+		t.Errorf("Expected an error for a method without rules")
+	}
+}